@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"cmd/internal/models"
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"cmd/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// errBatchFailed is returned once a batch has printed what it could, some wallets failed
+var errBatchFailed = errors.New("some wallets failed")
+
+// batchOptions are the flags of a multi-wallet run
+type batchOptions struct {
+	workers     int
+	walletsFile string // where the wallets came from, if a file
+	failedFile  string // where failed wallets are written for a rerun, "" to skip
+	summary     summaryOptions
+}
+
+// readWalletsFile reads one wallet or .ron name per line, blank lines and # comments are
+// skipped, so a failed wallets file can be fed straight back in
+func readWalletsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading wallets: %w", err)
+	}
+	defer f.Close()
+
+	var wallets []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		wallets = append(wallets, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+// uniqueWallets drops repeats, any case, keeping the first
+func uniqueWallets(wallets []string) []string {
+	seen := make(map[string]bool, len(wallets))
+	out := make([]string, 0, len(wallets))
+	for _, w := range wallets {
+		key := strings.ToLower(strings.TrimSpace(w))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, strings.TrimSpace(w))
+	}
+	return out
+}
+
+// runBatch fetches several wallets' NFTs concurrently and prints them merged, with an owner
+// column, sorted/grouped like a single wallet: over every page of every wallet, a plain
+// listing is one page of each. Wallets that fail are summarised on stderr and written to the
+// failed file, to rerun just those
+func runBatch(ctx context.Context, svc *service.NFTService, resolver *rns.Resolver, wallets []string, params models.QueryParams, opts batchOptions) error {
+	format, err := report.ParseFormat(opts.summary.output)
+	if err != nil {
+		return err
+	}
+	sortSpec, err := service.ParseSort(opts.summary.sortBy)
+	if err != nil {
+		return fmt.Errorf("sort: %w", err)
+	}
+	aggregation, err := service.ParseAggregation(opts.summary.agg)
+	if err != nil {
+		return fmt.Errorf("agg: %w", err)
+	}
+
+	wallets = uniqueWallets(wallets)
+	if len(wallets) == 0 {
+		return errors.New("no wallets given")
+	}
+
+	// results are reported by the wallet as given, so .ron names survive into the failed file
+	results := make([]service.WalletResult, len(wallets))
+	var resolved []string
+	var index []int
+	for i, input := range wallets {
+		addr, err := resolver.ResolveInput(ctx, input)
+		if err != nil {
+			results[i] = service.WalletResult{Wallet: input, Err: err}
+			continue
+		}
+		resolved = append(resolved, addr.Hex())
+		index = append(index, i)
+	}
+
+	// sorting, grouping and aggregating need every NFT, a plain listing is a page per wallet
+	summarized := opts.summary.sortBy != "" || opts.summary.groupBy != "" || opts.summary.agg != ""
+	show := opts.summary.top
+	if summarized && show <= 0 {
+		show = params.Limit
+	}
+	fetched, err := svc.GetNFTsByWallets(ctx, resolved, params, opts.workers, summarized)
+	if err != nil {
+		return err
+	}
+	var merged []models.NFT
+	for k, r := range fetched {
+		r.Wallet = wallets[index[k]]
+		results[index[k]] = r
+		merged = append(merged, r.NFTs...)
+	}
+	service.SortNFTs(merged, sortSpec)
+
+	if opts.summary.groupBy == "" && opts.summary.agg == "" {
+		if show > 0 && len(merged) > show {
+			merged = merged[:show]
+		}
+		err = report.WriteOwnedNFTs(os.Stdout, merged, format)
+	} else {
+		var groups []service.Group
+		if groups, err = service.GroupNFTs(merged, opts.summary.groupBy, aggregation); err != nil {
+			return fmt.Errorf("group by: %w", err)
+		}
+		if opts.summary.top > 0 && len(groups) > opts.summary.top {
+			groups = groups[:opts.summary.top]
+		}
+		err = report.WriteGroups(os.Stdout, groups, opts.summary.groupBy, aggregation, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Wallet)
+		}
+	}
+	if len(failed) == 0 {
+		// a rerun of the failed wallets that all went through, nothing left to rerun
+		if opts.walletsFile != "" && opts.walletsFile == opts.failedFile {
+			if err := os.Remove(opts.failedFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("removing failed wallets: %w", err)
+			}
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d of %d wallets failed:\n", len(failed), len(wallets))
+	if err := report.WriteBatchErrors(os.Stderr, results); err != nil {
+		return err
+	}
+	if opts.failedFile != "" {
+		if err := writeFailedWallets(opts.failedFile, failed); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Rerun just these with -wallets-file %s\n", opts.failedFile)
+	}
+	return fmt.Errorf("%w: %d of %d", errBatchFailed, len(failed), len(wallets))
+}
+
+// writeFailedWallets writes wallets in the -wallets-file format
+func writeFailedWallets(path string, wallets []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# wallets that failed in the batch at %s\n", time.Now().UTC().Format(time.RFC3339))
+	for _, w := range wallets {
+		b.WriteString(w + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("writing failed wallets: %w", err)
+	}
+	return nil
+}
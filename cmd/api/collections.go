@@ -0,0 +1,111 @@
+package main
+
+import (
+	"cmd/internal/collections"
+	"cmd/internal/report"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// collectionsUsage is printed for `collections` with no or an unknown subcommand
+const collectionsUsage = `usage: api collections <command> [flags]
+
+commands:
+  list                                   show tracked collections
+  add -address 0x... -name Name [-category c] [-tags a,b]
+                                         track a collection, or update it
+  remove -address 0x...                  stop tracking a collection
+  init                                   add the Axie Infinity defaults
+`
+
+// runCollections
+// Explanation -> the `collections` subcommand, edits the registry file the service and
+// HTTP API read (COLLECTIONS_FILE)
+// Return -> an error to print, nil on success
+func runCollections(path string, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, collectionsUsage)
+		return errors.New("missing collections command")
+	}
+
+	registry, err := collections.Load(path)
+	if err != nil {
+		return err
+	}
+
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("collections "+cmd, flag.ContinueOnError)
+	switch cmd {
+	case "list":
+		output := fs.String("output", "table", "Output format: table or json")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		format, err := report.ParseFormat(*output)
+		if err != nil {
+			return err
+		}
+		return report.WriteCollections(os.Stdout, registry.List(), format)
+
+	case "add":
+		addr := fs.String("address", "", "Collection contract address")
+		name := fs.String("name", "", "Friendly name")
+		category := fs.String("category", "", "Category, e.g. axie, land, item")
+		tags := fs.String("tags", "", "Comma separated tags")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		var tagList []string
+		if *tags != "" {
+			tagList = strings.Split(*tags, ",")
+		}
+		entry, err := registry.Add(collections.Entry{
+			Address:  *addr,
+			Name:     *name,
+			Category: *category,
+			Tags:     tagList,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Tracking %s (%s)\n", entry.Name, entry.Address)
+		return nil
+
+	case "remove":
+		addr := fs.String("address", "", "Collection contract address")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		removed, err := registry.Remove(*addr)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("%s is not tracked", *addr)
+		}
+		fmt.Printf("Stopped tracking %s\n", *addr)
+		return nil
+
+	case "init":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		for _, e := range collections.Defaults {
+			if _, ok := registry.Lookup(e.Address); ok {
+				continue
+			}
+			if _, err := registry.Add(e); err != nil {
+				return err
+			}
+			fmt.Printf("Tracking %s (%s)\n", e.Name, e.Address)
+		}
+		return nil
+
+	default:
+		fmt.Fprint(os.Stderr, collectionsUsage)
+		return fmt.Errorf("unknown collections command %q", cmd)
+	}
+}
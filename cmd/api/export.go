@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"cmd/internal/export"
+	"cmd/internal/models"
+	"cmd/internal/rns"
+	"cmd/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Data sets the export subcommand can write
+const (
+	exportNFTs    = "nfts"
+	exportHistory = "history"
+	exportAll     = "all"
+)
+
+// exportUsage is printed for `export` with bad flags
+const exportUsage = `usage: api [filter flags] export -out file [flags]
+
+  -out file            file to write, the format defaults to its extension
+  -format f            csv, xlsx, parquet or sqlite
+  -data d              nfts (default), history, or all (xlsx and sqlite only)
+  -wallet w            wallet or .ron, defaults to -wallet before export or WALLET_ADDRESS
+  -traits a,b          attributes to give their own columns, instead of every one the
+                       wallet's NFTs have
+  -from / -to date     history range, YYYY-MM-DD
+  -max-pages n         stop history after n pages
+
+Filter flags before export (-class, -part, -filter, -spam-mode, -all-collections,
+-exclude-spam) apply to the NFTs.
+
+Without -traits the columns are only known once the whole wallet has been read, so the
+NFTs are held in a temp file until then, as are history transfers until the transactions
+are written. Both take about as much disk as the JSON the API returns.
+`
+
+// runExport
+// Explanation -> the `export` subcommand, streams the wallet's NFTs and/or history page by
+// page into a CSV, XLSX, Parquet or SQLite file, so big wallets never sit in memory whole
+// Return -> an error to print, nil on success
+func runExport(ctx context.Context, svc *service.NFTService, resolver *rns.Resolver, wallet string, params models.QueryParams, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, exportUsage) }
+	out := fs.String("out", "", "File to write")
+	format := fs.String("format", "", "csv, xlsx, parquet or sqlite (default from -out)")
+	data := fs.String("data", exportNFTs, "nfts, history or all")
+	walletFlag := fs.String("wallet", "", "Wallet or .ron")
+	traits := fs.String("traits", "", "Comma separated attributes to give columns, default every one in the wallet")
+	from := fs.String("from", "", "History from date, YYYY-MM-DD")
+	to := fs.String("to", "", "History to date, YYYY-MM-DD")
+	maxPages := fs.Int("max-pages", 0, "Stop history after this many pages, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fs.Usage()
+		return errors.New("missing -out")
+	}
+	f := *format
+	if f == "" {
+		if f = export.FormatFromPath(*out); f == "" {
+			return fmt.Errorf("can't tell the format from %s, use -format", *out)
+		}
+	}
+	f, err := export.ParseFormat(f)
+	if err != nil {
+		return err
+	}
+
+	var withNFTs, withHistory bool
+	switch strings.ToLower(*data) {
+	case exportNFTs:
+		withNFTs = true
+	case exportHistory:
+		withHistory = true
+	case exportAll:
+		withNFTs, withHistory = true, true
+	default:
+		return fmt.Errorf("unknown -data %q, want nfts, history or all", *data)
+	}
+	// history is two tables, transactions and transfers
+	if withHistory && (f == export.FormatCSV || f == export.FormatParquet) {
+		return fmt.Errorf("%w: %s holds one table, use xlsx or sqlite for history", export.ErrOneTable, f)
+	}
+
+	if *walletFlag != "" {
+		wallet = *walletFlag
+	}
+	if wallet == "" {
+		return errors.New("missing wallet, use -wallet or WALLET_ADDRESS")
+	}
+	addr, err := resolver.ResolveInput(ctx, wallet)
+	if err != nil {
+		return fmt.Errorf("wallet address: %w", err)
+	}
+	wallet = addr.Hex()
+
+	sink, err := export.Create(f, *out)
+	if err != nil {
+		return err
+	}
+
+	var columnTraits []string
+	if *traits != "" {
+		columnTraits = strings.Split(*traits, ",")
+	}
+
+	var nfts, txs, transfers int
+	err = func() error {
+		if withNFTs {
+			n, err := exportNFTPages(ctx, svc, sink, wallet, params, columnTraits)
+			nfts = n
+			if err != nil {
+				return err
+			}
+		}
+		if withHistory {
+			var err error
+			txs, transfers, err = exportHistoryPages(ctx, svc, sink, wallet, models.HistoryParams{
+				FromDate: *from,
+				ToDate:   *to,
+				MaxPages: *maxPages,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if cerr := sink.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s (%s):", *out, f)
+	if withNFTs {
+		fmt.Printf(" %d NFTs", nfts)
+	}
+	if withHistory {
+		fmt.Printf(" %d transactions, %d transfers", txs, transfers)
+	}
+	fmt.Println()
+	return nil
+}
+
+// exportNFTPages writes the nfts table. Its attr_ columns are traits, or without them every
+// trait the wallet's NFTs have. Those are only known once the whole wallet has been read, so
+// the NFTs are kept in a temp file on the way and written from it, the wallet is walked once
+// and always exports the same columns whatever order Moralis pages it in
+func exportNFTPages(ctx context.Context, svc *service.NFTService, sink export.Sink, wallet string, params models.QueryParams, traits []string) (int, error) {
+	if len(traits) == 0 {
+		return exportCachedNFTPages(ctx, svc, sink, wallet, params)
+	}
+
+	schema := export.NewNFTSchema(traits)
+	table, err := sink.Table(export.TableNFTs, schema.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int
+	err = svc.EachNFTPage(ctx, wallet, params, func(nfts []models.NFT) error {
+		for _, nft := range nfts {
+			if err := table.Write(schema.Row(nft)); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// exportCachedNFTPages collects the traits while writing each NFT to a temp file as a JSON
+// line, then builds the schema and writes the table from the file
+func exportCachedNFTPages(ctx context.Context, svc *service.NFTService, sink export.Sink, wallet string, params models.QueryParams) (int, error) {
+	cache, err := os.CreateTemp("", "export-nfts-*.jsonl")
+	if err != nil {
+		return 0, fmt.Errorf("caching nfts: %w", err)
+	}
+	defer os.Remove(cache.Name())
+	defer cache.Close()
+
+	buf := bufio.NewWriter(cache)
+	enc := json.NewEncoder(buf)
+	var traits []string
+	seen := make(map[string]bool)
+	err = svc.EachNFTPage(ctx, wallet, params, func(nfts []models.NFT) error {
+		for _, trait := range export.Traits(nfts) {
+			if !seen[trait] {
+				seen[trait] = true
+				traits = append(traits, trait)
+			}
+		}
+		for _, nft := range nfts {
+			if err := enc.Encode(nft); err != nil {
+				return fmt.Errorf("caching nfts: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("caching nfts: %w", err)
+	}
+	if _, err := cache.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("caching nfts: %w", err)
+	}
+
+	schema := export.NewNFTSchema(traits)
+	table, err := sink.Table(export.TableNFTs, schema.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int
+	dec := json.NewDecoder(bufio.NewReader(cache))
+	for {
+		var nft models.NFT
+		if err := dec.Decode(&nft); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return rows, fmt.Errorf("reading cached nfts: %w", err)
+		}
+		if err := table.Write(schema.Row(nft)); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	return rows, nil
+}
+
+// exportHistoryPages writes the transactions table, then the transfers table. Sinks take one
+// table at a time, so transfers are spooled to a temp file until the transactions are done
+func exportHistoryPages(ctx context.Context, svc *service.NFTService, sink export.Sink, wallet string, params models.HistoryParams) (int, int, error) {
+	table, err := sink.Table(export.TableTransactions, export.TransactionColumns)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	transfers, err := export.NewSpool(export.TransferColumns)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer transfers.Close()
+
+	var txs int
+	err = svc.EachHistoryPage(ctx, wallet, params, func(page []models.Transaction) error {
+		for _, tx := range page {
+			if err := table.Write(export.TransactionRow(tx)); err != nil {
+				return err
+			}
+			txs++
+			for _, row := range export.TransferRows(tx) {
+				if err := transfers.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return txs, 0, err
+	}
+
+	table, err = sink.Table(export.TableTransfers, export.TransferColumns)
+	if err != nil {
+		return txs, 0, err
+	}
+	if err := transfers.Replay(table); err != nil {
+		return txs, 0, err
+	}
+	return txs, transfers.Rows(), nil
+}
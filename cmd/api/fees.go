@@ -0,0 +1,50 @@
+package main
+
+import (
+	"cmd/internal/models"
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"cmd/internal/service"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runFees
+// Explanation -> the `fees` subcommand, the gas a wallet paid over -from/-to by method label,
+// contract and month, with failed transactions apart
+// Return -> an error to print, nil on success
+func runFees(ctx context.Context, svc *service.NFTService, resolver *rns.Resolver, wallet string, args []string) error {
+	fs := flag.NewFlagSet("fees", flag.ContinueOnError)
+	from := fs.String("from", "", "From date, YYYY-MM-DD")
+	to := fs.String("to", "", "To date, YYYY-MM-DD")
+	output := fs.String("output", "table", "Output format: table or json")
+	walletFlag := fs.String("wallet", "", "Wallet or .ron")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := report.ParseFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	if *walletFlag != "" {
+		wallet = *walletFlag
+	}
+	if wallet == "" {
+		return errors.New("missing wallet, use -wallet or WALLET_ADDRESS")
+	}
+	addr, err := resolver.ResolveInput(ctx, wallet)
+	if err != nil {
+		return fmt.Errorf("wallet address: %w", err)
+	}
+
+	r, err := svc.GetFeeReport(ctx, addr.Hex(), models.HistoryParams{FromDate: *from, ToDate: *to})
+	if err != nil {
+		return err
+	}
+	return report.WriteFeeReport(os.Stdout, r, format)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"cmd/internal/guild"
+	"cmd/internal/report"
+	"cmd/internal/service"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// guildUsage is printed for `guild` with no or an unknown subcommand
+const guildUsage = `usage: api guild <command> [flags]
+
+commands:
+  list                                   show scholars and their Axies
+  add -name Name -address ronin:... [-share 30]
+                                         add a scholar, or update one
+  remove -scholar name|address           remove a scholar and their assignments
+  payout -scholar name|address -address ronin:...
+                                         set the wallet the scholar's share is paid to
+  assign -scholar name|address -axies 1,2,3
+                                         assign Axies, moving them off other scholars
+  unassign -axies 1,2,3                  take Axies off whoever has them
+  reconcile                              check scholar accounts against assignments
+`
+
+// runGuild
+// Explanation -> the `guild` subcommand, edits the guild file (GUILD_FILE) the HTTP API also
+// reads, and reconciles it against the scholars' accounts
+// Return -> an error to print, nil on success
+func runGuild(ctx context.Context, svc *service.NFTService, store *guild.Store, args []string) error {
+	if store == nil {
+		return errors.New("GUILD_FILE is empty, the guild is off")
+	}
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, guildUsage)
+		return errors.New("missing guild command")
+	}
+
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("guild "+cmd, flag.ContinueOnError)
+	switch cmd {
+	case "list":
+		output := fs.String("output", "table", "Output format: table or json")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		format, err := report.ParseFormat(*output)
+		if err != nil {
+			return err
+		}
+		return report.WriteScholars(os.Stdout, store.List(), format)
+
+	case "add":
+		name := fs.String("name", "", "Scholar name")
+		addr := fs.String("address", "", "Scholar account, ronin: or 0x")
+		share := fs.Int("share", guild.DefaultManagerShare, "Percent of earnings the manager keeps")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *share < 0 {
+			return guild.ErrInvalidShare
+		}
+		sc, err := store.Add(*name, *addr, *share)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Scholar %s (%s), manager keeps %d%%\n", sc.Name, sc.Address, sc.ManagerShare)
+		return nil
+
+	case "remove":
+		scholar := fs.String("scholar", "", "Scholar name or address")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if err := store.Remove(*scholar); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s\n", *scholar)
+		return nil
+
+	case "payout":
+		scholar := fs.String("scholar", "", "Scholar name or address")
+		addr := fs.String("address", "", "Scholar's own wallet, empty to clear")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		sc, err := store.SetPayoutAddress(*scholar, *addr)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is paid to %s\n", sc.Name, orNone(sc.PayoutAddress))
+		return nil
+
+	case "assign":
+		scholar := fs.String("scholar", "", "Scholar name or address")
+		axies := fs.String("axies", "", "Comma separated Axie ids")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		sc, err := store.Assign(*scholar, strings.Split(*axies, ","))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s now has %d Axies assigned\n", sc.Name, len(sc.Axies))
+		return nil
+
+	case "unassign":
+		axies := fs.String("axies", "", "Comma separated Axie ids")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		removed, err := store.Unassign(strings.Split(*axies, ","))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Unassigned %d Axies\n", removed)
+		return nil
+
+	case "reconcile":
+		output := fs.String("output", "table", "Output format: table or json")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		format, err := report.ParseFormat(*output)
+		if err != nil {
+			return err
+		}
+		r, err := svc.ReconcileGuild(ctx, store.List())
+		if err != nil {
+			return err
+		}
+		if err := report.WriteReconciliation(os.Stdout, r, format); err != nil {
+			return err
+		}
+		if len(r.Discrepancies) > 0 {
+			return fmt.Errorf("%d discrepancies", len(r.Discrepancies))
+		}
+		return nil
+
+	default:
+		fmt.Fprint(os.Stderr, guildUsage)
+		return fmt.Errorf("unknown guild command %q", cmd)
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "nobody"
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cmd/internal/config"
+	"cmd/internal/events"
+	"cmd/internal/price"
+	"cmd/internal/rns"
+	"cmd/internal/stream"
+	"cmd/pkg/decimal"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startLiveSources
+// Explanation -> starts what publishes to hub besides the Moralis webhook in serve mode: the
+// price watcher (PRICE_WATCH_INTERVAL) and, for WATCH_WALLETS, the node stream on
+// RONIN_WS_URL. Node transfers go through store when there is one, so a transfer Moralis
+// already delivered isn't published twice
+// Return -> an error for bad config, the sources themselves run until ctx is done
+func startLiveSources(ctx context.Context, log *logger.Logger, cfg *config.Config, hub *events.Hub,
+	store *events.Store, prices price.Provider, resolver *rns.Resolver) error {
+	if cfg.PriceWatchInterval > 0 {
+		minChange, err := decimal.Parse(strconv.FormatFloat(cfg.PriceChangePct, 'f', -1, 64))
+		if err != nil {
+			return fmt.Errorf("PRICE_CHANGE_PCT: %w", err)
+		}
+		go events.WatchPrices(ctx, hub, prices, events.PriceWatchOptions{
+			Interval:  cfg.PriceWatchInterval,
+			MinChange: minChange,
+		}, log)
+	}
+
+	var wallets []string
+	for _, w := range strings.Split(cfg.WatchWallets, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			wallets = append(wallets, w)
+		}
+	}
+	if len(wallets) == 0 {
+		return nil
+	}
+	if cfg.RoninWSURL == "" {
+		return errors.New("WATCH_WALLETS needs RONIN_WS_URL")
+	}
+	addrs, err := resolveAll(ctx, resolver, wallets)
+	if err != nil {
+		return fmt.Errorf("WATCH_WALLETS: %w", err)
+	}
+	sub, err := stream.New(stream.Options{URL: cfg.RoninWSURL, Addresses: addrs}, log)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := sub.Run(ctx, func(se stream.Event) {
+			e, ok := events.FromStream(se, time.Now())
+			if !ok {
+				return
+			}
+			if store != nil {
+				inserted, err := store.Insert(ctx, e)
+				if err != nil {
+					log.WarnContext(ctx, "Failed to store node event",
+						"error", err,
+						"event_id", e.ID,
+					)
+				} else if !inserted {
+					return
+				}
+			}
+			hub.Publish(e)
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.ErrorContext(ctx, "Node stream stopped",
+				"error", err,
+			)
+		}
+	}()
+	log.InfoContext(ctx, "Following watched wallets on the node",
+		"wallets", len(addrs),
+	)
+	return nil
+}
@@ -1,66 +1,103 @@
 package main
 
 import (
+	"cmd/internal/axie"
 	"cmd/internal/client"
+	"cmd/internal/collections"
 	"cmd/internal/commands"
 	"cmd/internal/config"
+	"cmd/internal/discord"
+	"cmd/internal/events"
+	"cmd/internal/guild"
+	"cmd/internal/media"
+	"cmd/internal/metrics"
 	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/internal/rarity"
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"cmd/internal/ronin"
+	"cmd/internal/server"
 	"cmd/internal/service"
+	"cmd/internal/spam"
+	"cmd/internal/tokenmeta"
+	"cmd/pkg/address"
 	"cmd/pkg/logger"
+	"cmd/pkg/requestid"
+	"cmd/pkg/tracing"
 	"cmd/pkg/utils"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/dotenv-org/godotenvvault"
 )
 
 func main() {
-	// Init. logger based on environment
-	logLevel := os.Getenv("LOG_LEVEL")
-	var log *logger.Logger
+	// load env file
+	// remember the outcome, the logger depends on the config so it can't log this yet
+	envErr := godotenvvault.Load()
 
-	switch logLevel {
-	case "debug":
-		log = logger.NewWithLevel(slog.LevelDebug)
-	case "warn":
-		log = logger.NewWithLevel(slog.LevelWarn)
-	case "error":
-		log = logger.NewWithLevel(slog.LevelError)
-	default:
-		log = logger.NewWithLevel(slog.LevelInfo)
+	// load configs
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load configuration:", err)
+		os.Exit(1)
+	}
+
+	// Init. logger based on config
+	log, err := logger.NewWithOptions(logger.Options{
+		Level:      logger.ParseLevel(cfg.LogLevel),
+		Format:     cfg.LogFormat,
+		Output:     cfg.LogOutput,
+		AddSource:  cfg.LogAddSource,
+		Redactor:   logger.NewRedactor(cfg.MoralisAPIKey, cfg.DiscordToken, cfg.JWTSecret, cfg.DatabaseURL, cfg.MoralisStreamsSecret),
+		MaxSizeMB:  cfg.LogMaxSizeMB,
+		MaxBackups: cfg.LogMaxBackups,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to set up logger:", err)
+		os.Exit(1)
 	}
+	defer log.Close()
 
 	// Log application start
 	log.Info("Starting NFT CLI application",
 		"version", "1.0.0",
-		"log_level", logLevel,
+		"log_level", cfg.LogLevel,
 	)
 
-	// load env file
-	if err := godotenvvault.Load(); err != nil {
+	if envErr != nil {
 		log.Warn("No env file found, using system environment variables")
 	}
 
-	// load configs
-	// TODO: change log to slog (DONE)
-	// TODO: update logger with relevant changes
-	cfg, err := config.Load()
-	if err != nil {
-		log.Error(
-			"Failed to load configuration",
-			"error", err,
-		)
-		os.Exit(1)
+	// subcommands, before the flags (they have their own)
+	if len(os.Args) > 1 && os.Args[1] == "collections" {
+		if err := runCollections(cfg.CollectionsFile, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "collections:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
+	// Log configuration loading with structured data
+	log.Info("configuration loaded",
+		"moralis_base_url", cfg.MoralisBaseURL,
+		"port", cfg.Port,
+		"log_level", cfg.LogLevel,
+		"log_format", cfg.LogFormat,
+		"log_output", cfg.LogOutput,
+	)
+
 	// parse CLI flags
 	var (
-		walletAddr    = flag.String("wallet", "", "Wallet address")                // wallet addr.
+		walletAddrs   multiFlag                                                    // wallet addr., repeatable for a batch
 		tokenAddr     = flag.String("token-address", "", "Token contract address") // token addr.
 		tokenID       = flag.String("token-id", "", "Token ID")                    // token id
 		tokensFile    = flag.String("tokens-file", "", "File with tokens JSON")    // token file, containing token addr. and token id
@@ -68,26 +105,207 @@ func main() {
 		excludeSpam   = flag.Bool("exclude-spam", false, "Exclude spam")           // query param
 		fetchNFT      = flag.Bool("nft", false, "Fetch NFTs by wallet")            // get NFT by wallet addr.
 		fetchSpecific = flag.Bool("specific-nft", false, "Fetch specific NFTs")    // get metadata for NFTs
+
+		serve       = flag.Bool("serve", false, "Run the HTTP API server")           // serve mode, listens on PORT
+		dumpMetrics = flag.Bool("metrics", false, "Print metrics to stderr on exit") // Prometheus text format
+
+		addrFormat = flag.String("address-format", cfg.AddressFormat, "Show addresses as 0x or ronin") // display preference
+		showNames  = flag.Bool("names", false, "Label owner addresses with their .ron names")          // reverse RNS lookups
+
+		axieClass = flag.String("class", "", "Only Axies of this class, e.g. Aquatic") // axie filter
+		axieParts multiFlag                                                            // axie filter, repeatable
+
+		sortBy  = flag.String("sort", "", "Sort by field, e.g. rarity_rank or floor_price:desc")          // applied after fetching
+		groupBy = flag.String("group-by", "", "Group by collection, a field or an attribute, e.g. Class") // summary instead of a list
+		agg     = flag.String("agg", "", "Aggregate per group: count (default), sum|avg|min|max(field)")  // e.g. sum(floor_price)
+		top     = flag.Int("top", 0, "Only show the first N NFTs or groups")                              // after sorting
+		output  = flag.String("output", "table", "Output format for -sort/-group-by/-agg: table or json") // rendering
+
+		portfolio     = flag.Bool("portfolio", false, "Value the wallet's NFTs at floor, in RON and USD")    // uses -output
+		includePrices = flag.Bool("include-prices", false, "Ask Moralis for floor prices when listing NFTs") // query param
+
+		localRarity = flag.Bool("local-rarity", cfg.LocalRarity, "Rank NFTs Moralis has no rarity for from their whole collection") // fetches every token once
+
+		spamMode   = flag.String("spam-mode", "", "What to do with spam: drop, flag or only (default SPAM_MODE)") // overrides config
+		spamReport = flag.Bool("spam-report", false, "Show why each NFT was or wasn't classed as spam")           // uses -output
+
+		allCollections = flag.Bool("all-collections", false, "Show collections that aren't in COLLECTIONS_FILE") // registry is an allow list
+
+		downloadMedia = flag.Bool("download-media", false, "Download the wallet's NFT images and thumbnails into MEDIA_DIR") // uses -output
+
+		resync = flag.Bool("resync", false, "Ask Moralis to refresh metadata for -token-address/-token-id or -tokens-file") // async on Moralis' side
 	)
+	filterExpr := flag.String("filter", "", `Filter expression, e.g. 'attr.class == "Beast" && rarity_rank < 1000 && verified'`)
+	walletsFile := flag.String("wallets-file", "", "File with one wallet or .ron per line, listed together with -nft")
+	failedFile := flag.String("failed-file", "failed-wallets.txt", "Where a batch writes the wallets that failed, \"\" to skip")
+	workers := flag.Int("workers", cfg.BatchWorkers, "Wallets fetched at once in a batch")
+	flag.Var(&walletAddrs, "wallet", "Wallet or .ron, repeat to list several together")
+	flag.Var(&axieParts, "part", `Only Axies with this dominant part, e.g. "Mouth:Risky Fish" (repeatable)`)
 	flag.Parse()
 
 	// Log parsed args
 	log.Debug("CLI flags parsed",
-		"wallet_address", *walletAddr,
+		"wallet_address", walletAddrs.String(),
 		"fetch_nft", *fetchNFT,
 		"fetch_specific", *fetchSpecific,
 		"limit", *limit,
 	)
 
-	// set up deps
-	moralisClient := client.NewMoralisClient(cfg.MoralisAPIKey, cfg.MoralisBaseURL, cfg.WalletAddress)
-	nftService := service.NewNFTService(moralisClient)
-	nftCommand := commands.NewNFTCommand(nftService)
-
 	// set up ctx for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// set up tracing, a no-op unless TRACE_EXPORTER is stdout or otlp
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName: "axs-trackerz",
+		Version:     "1.0.0",
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		Insecure:    cfg.TraceInsecure,
+	})
+	if err != nil {
+		log.Error("Failed to set up tracing",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	defer func() {
+		// own ctx, the main one may already be cancelled and spans still need flushing
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+
+	displayFormat, err := address.ParseFormat(*addrFormat)
+	if err != nil {
+		log.Error("Invalid address format",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	// set up deps
+	moralisClient := client.NewMoralisClient(cfg.MoralisAPIKey, cfg.MoralisBaseURL, cfg.WalletAddress, log)
+	moralisClient.SetRateLimit(cfg.MoralisRateLimit, cfg.MoralisBurst)
+	moralisClient.SetMaxRetries(cfg.MoralisMaxRetries)
+	moralisClient.SetStreamsURL(cfg.MoralisStreamsURL)
+	nftService := service.NewNFTService(moralisClient, log)
+	nftService.SetAddressFormat(displayFormat)
+
+	roninRPC := ronin.NewRPCClient(cfg.RoninRPCURL, log)
+	nameResolver, err := rns.NewResolver(roninRPC, cfg.RNSResolverAddress, cfg.RNSCacheTTL, log)
+	if err != nil {
+		log.Error("Failed to set up RNS resolver",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	if *showNames {
+		nftService.SetNameResolver(nameResolver)
+	}
+
+	if cfg.AxiePartsFile != "" {
+		catalog, err := axie.LoadPartCatalog(cfg.AxiePartsFile)
+		if err != nil {
+			log.Error("Failed to load Axie part catalog",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		nftService.SetAxieEnricher(axie.NewEnricher(catalog))
+	}
+
+	// live prices with manual overrides on top, PRICE_PROVIDER=none for overrides only
+	overrides, err := price.ParseStatic(cfg.PriceOverrides)
+	if err != nil {
+		log.Error("Invalid PRICE_OVERRIDES",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	var liveProvider price.Provider
+	if cfg.PriceProvider == "coingecko" {
+		liveProvider = price.NewCoinGecko(cfg.CoinGeckoURL, cfg.PriceCacheTTL, log)
+	}
+	prices := price.Fallback(overrides, liveProvider)
+	nftService.SetPriceProvider(prices)
+
+	registry, err := collections.Load(cfg.CollectionsFile)
+	if err != nil {
+		log.Error("Failed to load collections registry",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	nftService.SetCollections(registry)
+
+	// an empty GUILD_FILE turns the guild commands and endpoints off
+	var guildStore *guild.Store
+	if cfg.GuildFile != "" {
+		if guildStore, err = guild.Load(cfg.GuildFile); err != nil {
+			log.Error("Failed to load guild",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+	}
+
+	// spam: Moralis' flag alone, or with SPAM_CLASSIFIER=rules the rules and the user's allow/deny lists
+	if cfg.SpamClassifier == "rules" {
+		lists, err := spam.LoadLists(cfg.SpamListsFile, cfg.SpamAllow, cfg.SpamDeny)
+		if err != nil {
+			log.Error("Failed to load spam lists",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		nftService.SetSpamClassifier(spam.NewRules(lists, cfg.SpamThreshold))
+	}
+	if err := nftService.SetSpamMode(cfg.SpamMode); err != nil {
+		log.Error("Invalid SPAM_MODE",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	if *localRarity {
+		var store rarity.Store
+		if cfg.RarityDir != "" {
+			if store, err = rarity.NewFileStore(cfg.RarityDir); err != nil {
+				log.Error("Failed to set up rarity store",
+					"error", err,
+				)
+				os.Exit(1)
+			}
+		}
+		engine, err := rarity.NewEngine(moralisClient, store, rarity.Options{
+			Method:    cfg.RarityMethod,
+			MaxTokens: cfg.RarityMaxTokens,
+			TTL:       cfg.RarityTTL,
+		}, log)
+		if err != nil {
+			log.Error("Failed to set up rarity engine",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		nftService.SetRarityEngine(engine)
+	}
+
+	// metadata for NFTs Moralis has none for, from token_uri or the contract
+	if cfg.ResolveMetadata {
+		nftService.SetMetadataResolver(tokenmeta.NewResolver(roninRPC, tokenmeta.Options{
+			Gateways: tokenmeta.Gateways{IPFS: cfg.IPFSGateway, Arweave: cfg.ArweaveGateway},
+		}, log))
+	}
+	if cfg.MetadataAutoResync {
+		nftService.SetAutoResync(cfg.MetadataStaleAfter)
+	}
+
+	nftCommand := commands.NewNFTCommand(nftService)
+
 	// goroutine listens for ctrl + c signal from the terminal
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -97,35 +315,350 @@ func main() {
 		cancel()
 	}()
 
+	// serve mode: every HTTP request gets its own request ID, see server middleware
+	if *serve {
+		srv := server.New(":"+cfg.Port, nftService, log)
+		srv.SetJWTSecret(cfg.JWTSecret)
+		mediaDownloader, err := openMediaDownloader(cfg, log)
+		if err != nil {
+			log.Error("Failed to open media library",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		if mediaDownloader != nil {
+			srv.SetMediaDownloader(mediaDownloader)
+		}
+		if guildStore != nil {
+			srv.SetGuild(guildStore)
+		}
+
+		// wallet events from webhooks and the node, and price moves, all go through one hub
+		hub := events.NewHub()
+		var eventStore *events.Store
+		if cfg.EventsDB != "" {
+			eventStore, err = events.OpenStore(cfg.EventsDB)
+			if err != nil {
+				log.Error("Failed to open events store",
+					"error", err,
+					"events_db", cfg.EventsDB,
+				)
+				os.Exit(1)
+			}
+			defer eventStore.Close()
+			srv.SetStreamsWebhook(cfg.MoralisStreamsSecret, eventStore, hub)
+		}
+		if cfg.DiscordToken != "" && cfg.DiscordAlertChannelID != "" {
+			alerts := discord.New(cfg.DiscordToken, cfg.DiscordAlertChannelID, log)
+			go alerts.Watch(ctx, hub.Subscribe(256))
+		}
+		srv.SetLiveEvents(hub)
+		if err := startLiveSources(ctx, log, cfg, hub, eventStore, prices, nameResolver); err != nil {
+			log.Error("Failed to start live events",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+
+		if err := srv.Run(ctx); err != nil {
+			log.Error("HTTP server failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// dump metrics once the command is done
+	if *dumpMetrics {
+		defer func() {
+			if err := metrics.WriteText(os.Stderr); err != nil {
+				log.WarnContext(ctx, "Failed to write metrics", "error", err)
+			}
+		}()
+	}
+
+	// one request ID per CLI run, tags every log line and outbound Moralis call
+	ctx = requestid.NewContext(ctx, requestid.New())
+	log.InfoContext(ctx, "Executing command")
+
 	// build query params
 	params := models.QueryParams{
 		Limit:       *limit,
 		ExcludeSpam: *excludeSpam,
+		AxieClass:   *axieClass,
+		AxieParts:   axieParts,
+		Filter:      *filterExpr,
+
+		IncludePrices: *includePrices,
+		SpamMode:      *spamMode,
+
+		AllCollections: *allCollections,
+	}
+
+	// several wallets are a batch, fetched concurrently and listed together
+	if *walletsFile != "" || len(walletAddrs) > 1 {
+		wallets := append([]string{}, walletAddrs...)
+		if *walletsFile != "" {
+			fromFile, err := readWalletsFile(*walletsFile)
+			if err != nil {
+				log.ErrorContext(ctx, "NFT batch failed", "error", err)
+				os.Exit(1)
+			}
+			wallets = append(wallets, fromFile...)
+		}
+		if !*fetchNFT {
+			log.ErrorContext(ctx, "Several wallets can only be listed, add -nft")
+			os.Exit(1)
+		}
+		if err := runBatch(ctx, nftService, nameResolver, wallets, params, batchOptions{
+			workers:     *workers,
+			walletsFile: *walletsFile,
+			failedFile:  *failedFile,
+			summary: summaryOptions{
+				sortBy:  *sortBy,
+				groupBy: *groupBy,
+				agg:     *agg,
+				top:     *top,
+				output:  *output,
+			},
+		}); err != nil {
+			log.ErrorContext(ctx, "NFT batch failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		log.InfoContext(ctx, "NFT batch completed successfully")
+		return
 	}
 
 	// determine wallet address: CLI overrides env
 	var finalWalletAddr string
-	if *walletAddr != "" {
-		finalWalletAddr = *walletAddr // CLI flag provided
+	if len(walletAddrs) == 1 {
+		finalWalletAddr = walletAddrs[0] // CLI flag provided
 	} else {
 		finalWalletAddr = cfg.WalletAddress // use from env
 	}
 
+	// accept name.ron, ronin:, 0x, any case, and catch typos before calling the API
+	if finalWalletAddr != "" {
+		wallet, err := nameResolver.ResolveInput(ctx, finalWalletAddr)
+		if err != nil {
+			log.ErrorContext(ctx, "Invalid wallet address",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		finalWalletAddr = wallet.Hex()
+	}
+
+	// export takes the filter flags before it and its own after, e.g. -class Beast export -out a.parquet
+	if flag.Arg(0) == "export" {
+		if err := runExport(ctx, nftService, nameResolver, finalWalletAddr, params, flag.Args()[1:]); err != nil {
+			log.ErrorContext(ctx, "Export failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "guild" {
+		if err := runGuild(ctx, nftService, guildStore, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "guild:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "payouts" {
+		if err := runPayouts(ctx, nftService, guildStore, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "payouts:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "tax" {
+		if err := runTax(ctx, nftService, nameResolver, finalWalletAddr, flag.Args()[1:]); err != nil {
+			log.ErrorContext(ctx, "Tax report failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "fees" {
+		if err := runFees(ctx, nftService, nameResolver, finalWalletAddr, flag.Args()[1:]); err != nil {
+			log.ErrorContext(ctx, "Fee report failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "streams" {
+		if err := runStreams(ctx, moralisClient, nameResolver, finalWalletAddr, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "streams:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "stream" {
+		if err := runStream(ctx, log, nameResolver, streamOptions{
+			url:    cfg.RoninWSURL,
+			wallet: finalWalletAddr,
+			format: displayFormat,
+		}, flag.Args()[1:]); err != nil {
+			log.ErrorContext(ctx, "Stream failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Execute commands
+	if *portfolio {
+		format, err := report.ParseFormat(*output)
+		if err == nil {
+			var p *models.Portfolio
+			if p, err = nftService.GetPortfolio(ctx, finalWalletAddr, models.QueryParams{
+				ExcludeSpam: *excludeSpam,
+				AxieClass:   *axieClass,
+				AxieParts:   axieParts,
+				Filter:      *filterExpr,
+				SpamMode:    *spamMode,
+
+				AllCollections: *allCollections,
+			}); err == nil {
+				err = report.WritePortfolio(os.Stdout, p, format)
+			}
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "Portfolio command failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *spamReport {
+		format, err := report.ParseFormat(*output)
+		if err == nil {
+			reportParams := params
+			reportParams.SpamMode = spam.ModeFlag
+			var nfts []models.NFT
+			if nfts, err = nftService.GetNFTsByWallet(ctx, finalWalletAddr, reportParams); err == nil {
+				err = report.WriteSpamReport(os.Stdout, nfts, format)
+			}
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "Spam report failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *downloadMedia {
+		format, err := report.ParseFormat(*output)
+		var mediaDownloader *media.Downloader
+		if err == nil {
+			mediaDownloader, err = openMediaDownloader(cfg, log)
+		}
+		if err == nil && mediaDownloader == nil {
+			err = errors.New("MEDIA_DIR is not set, it's where the media library is kept")
+		}
+		if err == nil {
+			mediaParams := params
+			mediaParams.MediaItems = true // Moralis' CDN copies download faster than most token hosts
+			var nfts []models.NFT
+			if nfts, err = nftService.GetNFTsByWallet(ctx, finalWalletAddr, mediaParams); err == nil {
+				var result *media.Result
+				result, err = mediaDownloader.Download(ctx, nfts)
+				if result != nil {
+					if werr := report.WriteMediaResult(os.Stdout, result, format); err == nil {
+						err = werr
+					}
+				}
+			}
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "Media download failed",
+				"error", err,
+				"wallet_address", finalWalletAddr,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *resync {
+		var tokens []models.TokenRequest
+		if *tokensFile != "" {
+			tokens, err = utils.LoadTokensFromFile(*tokensFile)
+		} else if *tokenAddr != "" && *tokenID != "" {
+			tokens = []models.TokenRequest{{TokenAddress: *tokenAddr, TokenID: *tokenID}}
+		} else {
+			err = errors.New("need either -tokens-file or both -token-address and -token-id")
+		}
+		if err == nil {
+			err = nftService.ResyncMetadata(ctx, tokens)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "Metadata resync failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		fmt.Printf("Resync requested for %d token(s), Moralis refreshes them in the background\n", len(tokens))
+		return
+	}
+
 	if *fetchNFT {
 		if finalWalletAddr == "" {
-			log.Info("Executing NFT wallet command",
+			log.InfoContext(ctx, "Executing NFT wallet command",
 				"wallet_address", finalWalletAddr,
 			)
 		}
+		// sorting, grouping and aggregating render here, plain listing stays with the command
+		if *sortBy != "" || *groupBy != "" || *agg != "" {
+			if err := summarizeWallet(ctx, nftService, finalWalletAddr, params, summaryOptions{
+				sortBy:  *sortBy,
+				groupBy: *groupBy,
+				agg:     *agg,
+				top:     *top,
+				output:  *output,
+			}); err != nil {
+				log.ErrorContext(ctx, "NFT wallet command failed",
+					"error", err,
+					"wallet_address", finalWalletAddr,
+				)
+				os.Exit(1)
+			}
+			log.InfoContext(ctx, "NFT wallet command completed successfully")
+			return
+		}
 		if err := nftCommand.GetByWallet(ctx, finalWalletAddr, params); err != nil {
-			log.Error("NFT wallet command failed",
+			log.ErrorContext(ctx, "NFT wallet command failed",
 				"error", err,
 				"wallet_address", finalWalletAddr,
 			)
 			os.Exit(1)
 		}
-		log.Info("NFT wallet command completed successfully")
+		log.InfoContext(ctx, "NFT wallet command completed successfully")
 	} else if *fetchSpecific {
 		var tokens []models.TokenRequest
 
@@ -133,7 +666,7 @@ func main() {
 			// Load from file
 			tokens, err = utils.LoadTokensFromFile(*tokensFile)
 			if err != nil {
-				log.Error(
+				log.ErrorContext(ctx,
 					"Failed to load tokens file/missing JSON file",
 					"error", err,
 				)
@@ -147,11 +680,11 @@ func main() {
 				},
 			}
 		} else {
-			log.Error("Need either -tokens-file or both -token-address and -token-id")
+			log.ErrorContext(ctx, "Need either -tokens-file or both -token-address and -token-id")
 		}
 
 		if err := nftCommand.GetSpecific(ctx, tokens); err != nil {
-			log.Error(
+			log.ErrorContext(ctx,
 				"Failed to get NFT, check if the tokens are correct",
 				"Error:", err)
 		}
@@ -159,3 +692,92 @@ func main() {
 		flag.Usage()
 	}
 }
+
+// summaryOptions are the -sort, -group-by, -agg, -top and -output flags
+type summaryOptions struct {
+	sortBy  string
+	groupBy string
+	agg     string
+	top     int
+	output  string
+}
+
+// summarizeWallet fetches every page of a wallet's NFTs, sorts them, and prints either the
+// list or, with -group-by or -agg, one row per group. Groups cover the whole wallet, a sorted
+// list shows -top NFTs, or -limit without it
+func summarizeWallet(ctx context.Context, svc *service.NFTService, wallet string, params models.QueryParams, opts summaryOptions) error {
+	format, err := report.ParseFormat(opts.output)
+	if err != nil {
+		return err
+	}
+	sortSpec, err := service.ParseSort(opts.sortBy)
+	if err != nil {
+		return fmt.Errorf("sort: %w", err)
+	}
+	aggregation, err := service.ParseAggregation(opts.agg)
+	if err != nil {
+		return fmt.Errorf("agg: %w", err)
+	}
+
+	show := opts.top
+	if show <= 0 {
+		show = params.Limit
+	}
+	params.Limit = 0 // pages as large as Moralis allows
+
+	var nfts []models.NFT
+	err = svc.EachNFTPage(ctx, wallet, params, func(page []models.NFT) error {
+		nfts = append(nfts, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	service.SortNFTs(nfts, sortSpec)
+
+	if opts.groupBy == "" && opts.agg == "" {
+		if show > 0 && len(nfts) > show {
+			nfts = nfts[:show]
+		}
+		return report.WriteNFTs(os.Stdout, nfts, format)
+	}
+
+	groups, err := service.GroupNFTs(nfts, opts.groupBy, aggregation)
+	if err != nil {
+		return fmt.Errorf("group by: %w", err)
+	}
+	if opts.top > 0 && len(groups) > opts.top {
+		groups = groups[:opts.top]
+	}
+	return report.WriteGroups(os.Stdout, groups, opts.groupBy, aggregation, format)
+}
+
+// openMediaDownloader opens the media library in MEDIA_DIR, creating it, for the commands
+// that download images. Nil without a MEDIA_DIR, other commands never touch the disk
+func openMediaDownloader(cfg *config.Config, log *logger.Logger) (*media.Downloader, error) {
+	if cfg.MediaDir == "" {
+		return nil, nil
+	}
+	library, err := media.OpenLibrary(cfg.MediaDir)
+	if err != nil {
+		return nil, err
+	}
+	return media.NewDownloader(library, media.Options{
+		Workers:   cfg.MediaWorkers,
+		MaxBytes:  int64(cfg.MediaMaxBytes),
+		ThumbSize: cfg.MediaThumbSize,
+		Gateways:  tokenmeta.Gateways{IPFS: cfg.IPFSGateway, Arweave: cfg.ArweaveGateway},
+	}, log), nil
+}
+
+// multiFlag collects every value of a repeatable flag
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ", ")
+}
+
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"cmd/internal/guild"
+	"cmd/internal/models"
+	"cmd/internal/payout"
+	"cmd/internal/report"
+	"cmd/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runPayouts
+// Explanation -> the `payouts` subcommand, scholars' AXS/SLP earnings over -from/-to split by
+// their manager share, and with -out an unsigned batch of the transfers paying them. Nothing
+// is signed or sent, the batch is for a wallet or Safe to review
+// Return -> an error to print, nil on success
+func runPayouts(ctx context.Context, svc *service.NFTService, store *guild.Store, args []string) error {
+	if store == nil {
+		return errors.New("GUILD_FILE is empty, the guild is off")
+	}
+
+	fs := flag.NewFlagSet("payouts", flag.ContinueOnError)
+	from := fs.String("from", "", "Period start, YYYY-MM-DD")
+	to := fs.String("to", "", "Period end, YYYY-MM-DD")
+	scholar := fs.String("scholar", "", "Only this scholar, name or address")
+	exclude := fs.String("exclude", "", "Comma separated wallets whose transfers aren't earnings, e.g. the team wallet")
+	out := fs.String("out", "", "Write the unsigned batch transfer JSON here")
+	payer := fs.String("payer", "", "Wallet or Safe the payouts come from, recorded in the batch")
+	multiSend := fs.String("multisend", "", "MultiSendCallOnly contract, adds all payouts as one call")
+	output := fs.String("output", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("payouts need a period, -from and -to")
+	}
+	format, err := report.ParseFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	scholars := store.List()
+	if *scholar != "" {
+		sc, ok := store.Get(*scholar)
+		if !ok {
+			return fmt.Errorf("%w: %s", guild.ErrNotFound, *scholar)
+		}
+		scholars = []guild.Scholar{sc}
+	}
+	if len(scholars) == 0 {
+		return errors.New("no scholars, add some with `guild add`")
+	}
+
+	var excluded []string
+	if *exclude != "" {
+		excluded = strings.Split(*exclude, ",")
+	}
+	if *payer != "" {
+		excluded = append(excluded, *payer)
+	}
+
+	earnings, err := svc.ScholarEarnings(ctx, scholars, models.HistoryParams{FromDate: *from, ToDate: *to}, excluded)
+	if err != nil {
+		return err
+	}
+	plan, err := payout.NewPlan(scholars, earnings, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		batch, err := payout.NewBatch(plan, payout.BatchOptions{Payer: *payer, MultiSend: *multiSend})
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(batch, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding batch: %w", err)
+		}
+		if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("writing batch: %w", err)
+		}
+	}
+	return report.WritePayoutPlan(os.Stdout, plan, format)
+}
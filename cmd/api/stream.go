@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"cmd/internal/stream"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// streamOptions is what the stream command takes from config and the global flags
+type streamOptions struct {
+	url    string // RONIN_WS_URL
+	wallet string // -wallet or WALLET_ADDRESS, watched when -watch isn't given
+	format address.Format
+}
+
+// runStream
+// Explanation -> the `stream` subcommand, prints transfers to and from watched wallets as the
+// node produces blocks, until ctrl + c. Reconnects on its own, backfilling what it missed
+// Return -> an error to print, nil once stopped
+func runStream(ctx context.Context, log *logger.Logger, resolver *rns.Resolver, opts streamOptions, args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ContinueOnError)
+	url := fs.String("url", opts.url, "Node WebSocket URL")
+	fromBlock := fs.Uint64("from-block", 0, "Backfill from this block first, e.g. where a previous run stopped")
+	heads := fs.Bool("heads", false, "Print every new block too")
+	all := fs.Bool("all", false, "Watch every Transfer on chain instead of wallets, busy")
+	output := fs.String("output", "table", "Output format: table or json (one object per line)")
+	var watch multiFlag
+	fs.Var(&watch, "watch", "Wallet or .ron to watch (repeatable), defaults to -wallet")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := report.ParseFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	if len(watch) == 0 && opts.wallet != "" {
+		watch = multiFlag{opts.wallet}
+	}
+	if len(watch) == 0 && !*all {
+		return errors.New("nothing to watch, use -watch, -wallet or -all")
+	}
+	var addrs []string
+	if !*all {
+		if addrs, err = resolveAll(ctx, resolver, watch); err != nil {
+			return err
+		}
+	}
+
+	sub, err := stream.New(stream.Options{
+		URL:           *url,
+		Addresses:     addrs,
+		FromBlock:     *fromBlock,
+		AddressFormat: opts.format,
+	}, log)
+	if err != nil {
+		return err
+	}
+
+	// stop on a failed write, e.g. piped into something that exited
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var writeErr error
+	err = sub.Run(ctx, func(e stream.Event) {
+		if e.Kind == stream.KindHead && !*heads || writeErr != nil {
+			return
+		}
+		if writeErr = report.WriteStreamEvent(os.Stdout, e, format); writeErr != nil {
+			cancel()
+		}
+	})
+	if last := sub.LastBlock(); last > 0 {
+		fmt.Fprintf(os.Stderr, "Stopped at block %d, continue with -from-block %d\n", last, last)
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmd/internal/client"
+	"cmd/internal/models"
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// streamsUsage is printed for `streams` with no or an unknown subcommand
+const streamsUsage = `usage: api streams <command> [flags]
+
+commands:
+  list                                   show the account's Moralis streams
+  add -webhook https://host/v1/webhooks/moralis [-watch wallet ...] [-description text] [-tag tag]
+                                         register a stream for wallets, -wallet if no -watch
+  watch -id stream -watch wallet ...     add wallets to a stream
+  delete -id stream                      remove a stream
+`
+
+// runStreams
+// Explanation -> the `streams` subcommand, manages the Moralis streams that post webhooks to
+// this app's /v1/webhooks/moralis
+// Return -> an error to print, nil on success
+func runStreams(ctx context.Context, moralis *client.MoralisClient, resolver *rns.Resolver, wallet string, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, streamsUsage)
+		return errors.New("missing streams command")
+	}
+
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("streams "+cmd, flag.ContinueOnError)
+	switch cmd {
+	case "list":
+		output := fs.String("output", "table", "Output format: table or json")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		format, err := report.ParseFormat(*output)
+		if err != nil {
+			return err
+		}
+		streams, err := moralis.ListStreams(ctx)
+		if err != nil {
+			return err
+		}
+		return report.WriteStreams(os.Stdout, streams, format)
+
+	case "add":
+		webhook := fs.String("webhook", "", "Public URL of this app's /v1/webhooks/moralis")
+		description := fs.String("description", "AXS Trackerz wallet alerts", "Description shown in the Moralis dashboard")
+		tag := fs.String("tag", "axs-trackerz", "Tag sent back with every webhook")
+		var watch multiFlag
+		fs.Var(&watch, "watch", "Wallet or .ron to watch (repeatable)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *webhook == "" {
+			return errors.New("missing -webhook")
+		}
+		if len(watch) == 0 && wallet != "" {
+			watch = multiFlag{wallet}
+		}
+		addrs, err := resolveAll(ctx, resolver, watch)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return errors.New("nothing to watch, use -watch or -wallet")
+		}
+
+		stream, err := moralis.CreateStream(ctx, models.StreamConfig{
+			WebhookURL:          *webhook,
+			Description:         *description,
+			Tag:                 *tag,
+			ChainIDs:            []string{models.RoninChainID},
+			IncludeContractLogs: true,
+		}, addrs)
+		if err != nil {
+			if stream != nil {
+				return fmt.Errorf("stream %s created without its addresses: %w", stream.ID, err)
+			}
+			return err
+		}
+		fmt.Printf("Stream %s posting to %s, watching %d wallet(s)\n", stream.ID, stream.WebhookURL, len(addrs))
+		return nil
+
+	case "watch":
+		id := fs.String("id", "", "Stream id")
+		var watch multiFlag
+		fs.Var(&watch, "watch", "Wallet or .ron to watch (repeatable)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *id == "" || len(watch) == 0 {
+			return errors.New("need -id and at least one -watch")
+		}
+		addrs, err := resolveAll(ctx, resolver, watch)
+		if err != nil {
+			return err
+		}
+		if err := moralis.AddStreamAddresses(ctx, *id, addrs); err != nil {
+			return err
+		}
+		fmt.Printf("Stream %s now also watches %d wallet(s)\n", *id, len(addrs))
+		return nil
+
+	case "delete":
+		id := fs.String("id", "", "Stream id")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *id == "" {
+			return errors.New("missing -id")
+		}
+		if err := moralis.DeleteStream(ctx, *id); err != nil {
+			return err
+		}
+		fmt.Printf("Stream %s deleted\n", *id)
+		return nil
+
+	default:
+		fmt.Fprint(os.Stderr, streamsUsage)
+		return fmt.Errorf("unknown streams command %q", cmd)
+	}
+}
+
+// resolveAll resolves wallets and .ron names to 0x addresses
+func resolveAll(ctx context.Context, resolver *rns.Resolver, inputs []string) ([]string, error) {
+	addrs := make([]string, 0, len(inputs))
+	for _, in := range inputs {
+		addr, err := resolver.ResolveInput(ctx, in)
+		if err != nil {
+			return nil, fmt.Errorf("wallet %s: %w", in, err)
+		}
+		addrs = append(addrs, addr.Hex())
+	}
+	return addrs, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmd/internal/report"
+	"cmd/internal/rns"
+	"cmd/internal/service"
+	"cmd/internal/tax"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runTax
+// Explanation -> the `tax` subcommand, realized gains for a year from the wallet's history,
+// printed as a table or JSON and optionally written as a Form 8949 CSV with -out
+// Return -> an error to print, nil on success
+func runTax(ctx context.Context, svc *service.NFTService, resolver *rns.Resolver, wallet string, args []string) error {
+	fs := flag.NewFlagSet("tax", flag.ContinueOnError)
+	year := fs.Int("year", time.Now().UTC().Year()-1, "Tax year, 0 for every year")
+	method := fs.String("method", tax.FIFO, "Cost basis method: "+strings.Join(tax.Methods, ", "))
+	out := fs.String("out", "", "Write a Form 8949 CSV here")
+	output := fs.String("output", "table", "Output format: table or json")
+	walletFlag := fs.String("wallet", "", "Wallet or .ron")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := tax.ParseMethod(*method)
+	if err != nil {
+		return err
+	}
+	format, err := report.ParseFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	if *walletFlag != "" {
+		wallet = *walletFlag
+	}
+	if wallet == "" {
+		return errors.New("missing wallet, use -wallet or WALLET_ADDRESS")
+	}
+	addr, err := resolver.ResolveInput(ctx, wallet)
+	if err != nil {
+		return fmt.Errorf("wallet address: %w", err)
+	}
+
+	r, err := svc.TaxReport(ctx, addr.Hex(), *year, m)
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("creating form 8949: %w", err)
+		}
+		err = tax.WriteForm8949(f, r)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return report.WriteTaxReport(os.Stdout, r, format)
+}
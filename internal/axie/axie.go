@@ -0,0 +1,130 @@
+package axie
+
+import (
+	"cmd/pkg/address"
+	"strings"
+)
+
+// ContractAddress is the Axie ERC-721 contract on Ronin
+const ContractAddress = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+
+var contract = address.MustParse(ContractAddress)
+
+// IsAxie reports whether tokenAddress is the Axie contract, in any address format
+func IsAxie(tokenAddress string) bool {
+	addr, err := address.Parse(tokenAddress)
+	return err == nil && addr == contract
+}
+
+// Class is an Axie class
+type Class string
+
+const (
+	ClassBeast   Class = "Beast"
+	ClassBug     Class = "Bug"
+	ClassBird    Class = "Bird"
+	ClassPlant   Class = "Plant"
+	ClassAquatic Class = "Aquatic"
+	ClassReptile Class = "Reptile"
+	ClassMech    Class = "Mech"
+	ClassDawn    Class = "Dawn"
+	ClassDusk    Class = "Dusk"
+)
+
+var classes = []Class{
+	ClassBeast, ClassBug, ClassBird, ClassPlant, ClassAquatic, ClassReptile,
+	ClassMech, ClassDawn, ClassDusk,
+}
+
+// ParseClass matches a class name case-insensitively
+// Return -> the class, false if it isn't one
+func ParseClass(s string) (Class, bool) {
+	s = strings.TrimSpace(s)
+	for _, c := range classes {
+		if strings.EqualFold(string(c), s) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// PartType is one of the six body parts, in the order they appear in the genes
+type PartType string
+
+const (
+	PartEyes  PartType = "eyes"
+	PartMouth PartType = "mouth"
+	PartEars  PartType = "ears"
+	PartHorn  PartType = "horn"
+	PartBack  PartType = "back"
+	PartTail  PartType = "tail"
+)
+
+// PartTypes lists the body parts in genes order
+var PartTypes = []PartType{PartEyes, PartMouth, PartEars, PartHorn, PartBack, PartTail}
+
+// ParsePartType matches a part type case-insensitively
+// Return -> the part type, false if it isn't one
+func ParsePartType(s string) (PartType, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, p := range PartTypes {
+		if string(p) == s {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// Stats are an Axie's battle stats
+type Stats struct {
+	HP     int
+	Speed  int
+	Skill  int
+	Morale int
+}
+
+func (s Stats) add(o Stats) Stats {
+	return Stats{s.HP + o.HP, s.Speed + o.Speed, s.Skill + o.Skill, s.Morale + o.Morale}
+}
+
+// classBaseStats are the stats an Axie starts with for its class, before part bonuses
+var classBaseStats = map[Class]Stats{
+	ClassBeast:   {31, 35, 31, 43},
+	ClassBug:     {35, 31, 35, 39},
+	ClassBird:    {27, 43, 35, 35},
+	ClassPlant:   {43, 31, 31, 35},
+	ClassAquatic: {39, 39, 35, 27},
+	ClassReptile: {39, 35, 31, 35},
+	ClassMech:    {31, 39, 43, 27},
+	ClassDawn:    {35, 35, 39, 31},
+	ClassDusk:    {43, 39, 27, 31},
+}
+
+// partBonusStats is what each dominant body part adds, by the part's class
+var partBonusStats = map[Class]Stats{
+	ClassBeast:   {0, 1, 0, 3},
+	ClassBug:     {1, 0, 0, 3},
+	ClassBird:    {0, 3, 0, 1},
+	ClassPlant:   {3, 0, 0, 1},
+	ClassAquatic: {1, 3, 0, 0},
+	ClassReptile: {3, 1, 0, 0},
+}
+
+// ComputeStats
+// Explanation -> class base stats plus the bonus of every dominant part's class
+// Return -> the stats, false if the class or a part class is unknown
+func ComputeStats(class Class, partClasses []Class) (Stats, bool) {
+	stats, ok := classBaseStats[class]
+	if !ok || len(partClasses) != len(PartTypes) {
+		return Stats{}, false
+	}
+
+	for _, pc := range partClasses {
+		bonus, ok := partBonusStats[pc]
+		if !ok {
+			return Stats{}, false
+		}
+		stats = stats.add(bonus)
+	}
+	return stats, true
+}
@@ -0,0 +1,63 @@
+package axie
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PartCatalog maps part genes to their in-game names, e.g. Aquatic mouth 10 -> Risky Fish
+// the genes only carry ids, names come from a catalog file exported from the Axie API
+type PartCatalog map[partKey]string
+
+type partKey struct {
+	Type   PartType
+	Class  Class
+	PartID int
+}
+
+// catalogEntry is one line of the catalog file
+type catalogEntry struct {
+	Type   string `json:"type"`
+	Class  string `json:"class"`
+	PartID int    `json:"part_id"`
+	Name   string `json:"name"`
+}
+
+// LoadPartCatalog
+// Explanation -> reads a JSON array of {"type", "class", "part_id", "name"} entries
+// Return -> the catalog
+func LoadPartCatalog(path string) (PartCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading part catalog: %w", err)
+	}
+
+	var entries []catalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing part catalog: %w", err)
+	}
+
+	catalog := make(PartCatalog, len(entries))
+	for i, e := range entries {
+		pt, ok := ParsePartType(e.Type)
+		if !ok {
+			return nil, fmt.Errorf("part catalog entry %d: unknown part type %q", i, e.Type)
+		}
+		class, ok := ParseClass(e.Class)
+		if !ok {
+			return nil, fmt.Errorf("part catalog entry %d: unknown class %q", i, e.Class)
+		}
+		catalog[partKey{pt, class, e.PartID}] = strings.TrimSpace(e.Name)
+	}
+	return catalog, nil
+}
+
+// Name returns the part's name, or an id like "Aquatic-mouth-10" when the catalog doesn't know it
+func (c PartCatalog) Name(pt PartType, g Gene) string {
+	if name, ok := c[partKey{pt, g.Class, g.PartID}]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s-%02d", g.Class, pt, g.PartID)
+}
@@ -0,0 +1,131 @@
+package axie
+
+import (
+	"cmd/internal/filter"
+	"cmd/internal/models"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Enricher struct fills models.NFT.Axie for tokens of the Axie contract
+type Enricher struct {
+	catalog PartCatalog
+}
+
+// NewEnricher func creates an enricher, catalog may be nil (parts are then named by id)
+func NewEnricher(catalog PartCatalog) *Enricher {
+	return &Enricher{catalog: catalog}
+}
+
+// Enrich
+// Explanation -> decodes the genes attribute if there is one, falling back to the
+// class, part and stat traits in the metadata for whatever the genes can't provide
+// Return -> true if nft is an Axie and there was something to enrich it with
+func (e *Enricher) Enrich(nft *models.NFT) bool {
+	if !IsAxie(nft.TokenAddress) {
+		return false
+	}
+
+	traits := normalizeTraits(nft.Attributes)
+	info := &models.AxieInfo{}
+
+	var genes *Genes
+	if raw, ok := traits["genes"].(string); ok && raw != "" {
+		if decoded, err := DecodeGenes(raw); err == nil {
+			genes = decoded
+			info.Genes = raw
+		}
+	}
+
+	if genes != nil {
+		info.Class = string(genes.Class)
+		info.Parts = make(map[string]models.AxiePart, len(PartTypes))
+		for _, pt := range PartTypes {
+			pg := genes.Parts[pt]
+			part := models.AxiePart{
+				Class: string(pg.D.Class),
+				D:     e.catalog.Name(pt, pg.D),
+				R1:    e.catalog.Name(pt, pg.R1),
+				R2:    e.catalog.Name(pt, pg.R2),
+			}
+			// the metadata name is authoritative for what the Axie shows
+			if name := traitString(traits, string(pt)); name != "" {
+				part.D = name
+			}
+			info.Parts[string(pt)] = part
+		}
+
+		purity := genes.Purity()
+		info.Purity = &purity
+
+		if stats, ok := ComputeStats(genes.Class, genes.DominantClasses()); ok {
+			info.Stats = &models.AxieStats{HP: stats.HP, Speed: stats.Speed, Skill: stats.Skill, Morale: stats.Morale}
+		}
+	} else {
+		if class, ok := ParseClass(traitString(traits, "class")); ok {
+			info.Class = string(class)
+		}
+		for _, pt := range PartTypes {
+			if name := traitString(traits, string(pt)); name != "" {
+				if info.Parts == nil {
+					info.Parts = make(map[string]models.AxiePart, len(PartTypes))
+				}
+				info.Parts[string(pt)] = models.AxiePart{D: name}
+			}
+		}
+	}
+
+	// stats in the metadata win over computed ones, they include anything we don't model
+	hp, okHP := traitInt(traits, "hp")
+	speed, okSpeed := traitInt(traits, "speed")
+	skill, okSkill := traitInt(traits, "skill")
+	morale, okMorale := traitInt(traits, "morale")
+	if okHP && okSpeed && okSkill && okMorale {
+		info.Stats = &models.AxieStats{HP: hp, Speed: speed, Skill: skill, Morale: morale}
+	}
+
+	if breeds, ok := traitInt(traits, "breedcount"); ok {
+		info.BreedCount = &breeds
+	}
+
+	// nothing to go on, e.g. the metadata hasn't been fetched
+	if info.Class == "" && info.Parts == nil && info.Stats == nil && info.BreedCount == nil {
+		return false
+	}
+
+	nft.Axie = info
+	return true
+}
+
+// normalizeTraits rekeys attrs by filter.NormalizeKey, so "Breed Count" == "breed_count"
+func normalizeTraits(attrs map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(attrs))
+	for key, value := range attrs {
+		out[filter.NormalizeKey(key)] = value
+	}
+	return out
+}
+
+func traitString(traits map[string]interface{}, key string) string {
+	v, _ := traits[filter.NormalizeKey(key)].(string)
+	return strings.TrimSpace(v)
+}
+
+// traitInt reads a whole number trait, metadata sends them as numbers or strings
+func traitInt(traits map[string]interface{}, key string) (int, bool) {
+	switch v := traits[filter.NormalizeKey(key)].(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
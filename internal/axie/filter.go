@@ -0,0 +1,98 @@
+package axie
+
+import (
+	"cmd/internal/models"
+	"fmt"
+	"strings"
+)
+
+// Filter keeps Axies of a class and/or with given dominant parts
+// every condition has to match, non-Axie NFTs never match
+type Filter struct {
+	Class Class
+	Parts []PartFilter
+}
+
+// PartFilter matches a dominant body part by name, e.g. mouth Risky Fish
+type PartFilter struct {
+	Type PartType
+	Name string
+}
+
+// ParseFilter
+// Explanation -> builds a filter from CLI/query input, parts look like "Mouth:Risky Fish"
+// Return -> nil if there's nothing to filter on
+func ParseFilter(class string, parts []string) (*Filter, error) {
+	if strings.TrimSpace(class) == "" && len(parts) == 0 {
+		return nil, nil
+	}
+
+	f := &Filter{}
+	if strings.TrimSpace(class) != "" {
+		c, ok := ParseClass(class)
+		if !ok {
+			return nil, fmt.Errorf("unknown axie class %q", class)
+		}
+		f.Class = c
+	}
+
+	for _, p := range parts {
+		typ, name, ok := strings.Cut(p, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("part filter %q should look like Type:Name, e.g. Mouth:Risky Fish", p)
+		}
+		pt, ok := ParsePartType(typ)
+		if !ok {
+			return nil, fmt.Errorf("unknown axie part type %q", typ)
+		}
+		f.Parts = append(f.Parts, PartFilter{Type: pt, Name: strings.TrimSpace(name)})
+	}
+
+	return f, nil
+}
+
+// Match reports whether an enriched NFT passes the filter
+func (f *Filter) Match(nft models.NFT) bool {
+	if nft.Axie == nil {
+		return false
+	}
+
+	if f.Class != "" && !strings.EqualFold(nft.Axie.Class, string(f.Class)) {
+		return false
+	}
+
+	for _, pf := range f.Parts {
+		part, ok := nft.Axie.Parts[string(pf.Type)]
+		if !ok || !samePartName(pf.Type, part.D, pf.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the NFTs that match, a nil filter keeps everything
+func (f *Filter) Apply(nfts []models.NFT) []models.NFT {
+	if f == nil {
+		return nfts
+	}
+
+	var kept []models.NFT
+	for _, nft := range nfts {
+		if f.Match(nft) {
+			kept = append(kept, nft)
+		}
+	}
+	return kept
+}
+
+// samePartName compares part names loosely, so "Risky Fish", "risky-fish" and
+// the Axie API id "mouth-risky-fish" all match
+func samePartName(pt PartType, a, b string) bool {
+	return partSlug(pt, a) == partSlug(pt, b)
+}
+
+func partSlug(pt PartType, name string) string {
+	slug := strings.ToLower(strings.TrimSpace(name))
+	slug = strings.NewReplacer(" ", "-", "_", "-").Replace(slug)
+	return strings.TrimPrefix(slug, string(pt)+"-")
+}
@@ -0,0 +1,177 @@
+package axie
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidGenes is wrapped by every decode error
+var ErrInvalidGenes = errors.New("invalid axie genes")
+
+// Gene is a single dominant or recessive body part gene
+type Gene struct {
+	Class  Class
+	PartID int // index of the part within its class and type, see PartCatalog
+}
+
+// PartGenes holds the three genes of a body part, only D shows on the Axie
+type PartGenes struct {
+	D  Gene
+	R1 Gene
+	R2 Gene
+}
+
+// Genes is a decoded Axie genome
+type Genes struct {
+	Class Class
+	Parts map[PartType]PartGenes
+	Bits  int // 256 for legacy genes, 512 for current ones
+}
+
+// Purity counts the dominant parts that match the Axie's own class, 0 to 6
+func (g *Genes) Purity() int {
+	purity := 0
+	for _, part := range g.Parts {
+		if part.D.Class == g.Class {
+			purity++
+		}
+	}
+	return purity
+}
+
+// DominantClasses returns the class of each dominant part, in genes order
+func (g *Genes) DominantClasses() []Class {
+	out := make([]Class, 0, len(PartTypes))
+	for _, pt := range PartTypes {
+		out = append(out, g.Parts[pt].D.Class)
+	}
+	return out
+}
+
+// layout describes where things live in one genes format, offsets are from the most significant bit
+type layout struct {
+	bits       int
+	classBits  int
+	classCodes map[uint64]Class
+	partStarts []int // start of each part group, in PartTypes order
+	skinBits   int   // skip at the start of each part group
+	geneClass  int   // class bits per gene
+	genePartID int   // part id bits per gene
+}
+
+// legacy 256 bit genes: 4 bit class, then six 32 bit part groups from bit 64
+// each group is 2 skin bits then D, R1, R2 as 4 bit class + 6 bit part
+var layout256 = layout{
+	bits:      256,
+	classBits: 4,
+	classCodes: map[uint64]Class{
+		0b0000: ClassBeast, 0b0001: ClassBug, 0b0010: ClassBird,
+		0b0011: ClassPlant, 0b0100: ClassAquatic, 0b0101: ClassReptile,
+		0b1000: ClassMech, 0b1001: ClassDawn, 0b1010: ClassDusk,
+	},
+	partStarts: []int{64, 96, 128, 160, 192, 224},
+	skinBits:   2,
+	geneClass:  4,
+	genePartID: 6,
+}
+
+// current 512 bit genes: 5 bit class, six part groups each ending on a 64 bit boundary
+// each group is 4 skin bits then D, R1, R2 as 5 bit class + 8 bit part
+var layout512 = layout{
+	bits:      512,
+	classBits: 5,
+	classCodes: map[uint64]Class{
+		0: ClassBeast, 1: ClassBug, 2: ClassBird,
+		3: ClassPlant, 4: ClassAquatic, 5: ClassReptile,
+		16: ClassMech, 17: ClassDawn, 18: ClassDusk,
+	},
+	partStarts: []int{149, 213, 277, 341, 405, 469},
+	skinBits:   4,
+	geneClass:  5,
+	genePartID: 8,
+}
+
+// DecodeGenes
+// Explanation -> decodes genes given as 0x hex (as the Axie API returns them) or a decimal string
+// values that fit in 256 bits with a hex form of at most 64 digits are read as legacy genes
+// Return -> the decoded genes, or an error wrapping ErrInvalidGenes
+func DecodeGenes(s string) (*Genes, error) {
+	s = strings.TrimSpace(s)
+
+	value := new(big.Int)
+	var ok bool
+	hexDigits := 0
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		hexDigits = len(s) - 2
+		_, ok = value.SetString(s[2:], 16)
+	} else {
+		_, ok = value.SetString(s, 10)
+	}
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q is not a number", ErrInvalidGenes, s)
+	}
+
+	l := layout512
+	switch {
+	case value.BitLen() > 512:
+		return nil, fmt.Errorf("%w: more than 512 bits", ErrInvalidGenes)
+	case value.BitLen() <= 256 && hexDigits <= 64:
+		l = layout256
+	}
+
+	return decodeLayout(value, l)
+}
+
+func decodeLayout(value *big.Int, l layout) (*Genes, error) {
+	bits := bitReader{value: value, size: l.bits}
+
+	class, ok := l.classCodes[bits.read(0, l.classBits)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown class code %d", ErrInvalidGenes, bits.read(0, l.classBits))
+	}
+
+	genes := &Genes{
+		Class: class,
+		Parts: make(map[PartType]PartGenes, len(PartTypes)),
+		Bits:  l.bits,
+	}
+
+	geneLen := l.geneClass + l.genePartID
+	for i, pt := range PartTypes {
+		start := l.partStarts[i] + l.skinBits
+
+		var three [3]Gene
+		for g := range three {
+			offset := start + g*geneLen
+			geneClass, ok := l.classCodes[bits.read(offset, l.geneClass)]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown %s gene class code %d", ErrInvalidGenes, pt, bits.read(offset, l.geneClass))
+			}
+			three[g] = Gene{
+				Class:  geneClass,
+				PartID: int(bits.read(offset+l.geneClass, l.genePartID)),
+			}
+		}
+
+		genes.Parts[pt] = PartGenes{D: three[0], R1: three[1], R2: three[2]}
+	}
+
+	return genes, nil
+}
+
+// bitReader reads big-endian bit ranges from a fixed width number
+type bitReader struct {
+	value *big.Int
+	size  int
+}
+
+// read returns length bits starting at offset, counted from the most significant bit
+func (b bitReader) read(offset, length int) uint64 {
+	var out uint64
+	for i := 0; i < length; i++ {
+		out = out<<1 | uint64(b.value.Bit(b.size-1-offset-i))
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package axie
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// put writes x as length bits at offset, counted from the most significant of size bits
+func put(v *big.Int, size, offset, length int, x uint64) {
+	for i := 0; i < length; i++ {
+		bit := uint(x >> (length - 1 - i) & 1)
+		v.SetBit(v, size-1-offset-i, bit)
+	}
+}
+
+func TestDecodeGenesGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		genes string
+		bits  int
+		class Class
+		part  PartType
+		want  Gene
+	}{
+		{
+			// class 0010, eyes D at bit 66: class 0100, part 000101
+			name:  "256 bit",
+			genes: "0x20" + strings.Repeat("00", 7) + "1050" + strings.Repeat("00", 22),
+			bits:  256,
+			class: ClassBird,
+			part:  PartEyes,
+			want:  Gene{Class: ClassAquatic, PartID: 5},
+		},
+		{
+			// class 10000, tail D at bit 473: class 10010, part 11001000
+			name:  "512 bit",
+			genes: "0x80" + strings.Repeat("00", 58) + "4b20" + strings.Repeat("00", 3),
+			bits:  512,
+			class: ClassMech,
+			part:  PartTail,
+			want:  Gene{Class: ClassDusk, PartID: 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := DecodeGenes(tt.genes)
+			if err != nil {
+				t.Fatalf("DecodeGenes: %v", err)
+			}
+			if g.Bits != tt.bits || g.Class != tt.class {
+				t.Errorf("genes = %d bit %s, want %d bit %s", g.Bits, g.Class, tt.bits, tt.class)
+			}
+			for _, pt := range PartTypes {
+				want := PartGenes{D: Gene{Class: ClassBeast}, R1: Gene{Class: ClassBeast}, R2: Gene{Class: ClassBeast}}
+				if pt == tt.part {
+					want.D = tt.want
+				}
+				if got := g.Parts[pt]; got != want {
+					t.Errorf("%s = %+v, want %+v", pt, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeGenesLayouts(t *testing.T) {
+	for _, l := range []layout{layout256, layout512} {
+		codes := make(map[Class]uint64, len(l.classCodes))
+		for code, c := range l.classCodes {
+			codes[c] = code
+		}
+
+		// a Plant whose parts are Plant, Plant, Plant, Reptile, Dusk, Plant, each recessive
+		// a different class and every part id distinct
+		dominant := []Class{ClassPlant, ClassPlant, ClassPlant, ClassReptile, ClassDusk, ClassPlant}
+		v := new(big.Int)
+		put(v, l.bits, 0, l.classBits, codes[ClassPlant])
+		for i := range PartTypes {
+			start := l.partStarts[i] + l.skinBits
+			geneLen := l.geneClass + l.genePartID
+			for g, c := range []Class{dominant[i], ClassBug, ClassDawn} {
+				put(v, l.bits, start+g*geneLen, l.geneClass, codes[c])
+				put(v, l.bits, start+g*geneLen+l.geneClass, l.genePartID, uint64(i*3+g+1))
+			}
+			// skin bits are ignored
+			put(v, l.bits, l.partStarts[i], l.skinBits, 1)
+		}
+		hexDigits := l.bits / 4
+		text := v.Text(16)
+		text = "0x" + strings.Repeat("0", hexDigits-len(text)) + text
+
+		for _, in := range []string{text, " " + text + " ", v.String()} {
+			g, err := DecodeGenes(in)
+			if err != nil {
+				t.Fatalf("%d bit DecodeGenes(%s): %v", l.bits, in, err)
+			}
+			if g.Bits != l.bits || g.Class != ClassPlant {
+				t.Errorf("%d bit genes = %d bit %s", l.bits, g.Bits, g.Class)
+			}
+			for i, pt := range PartTypes {
+				want := PartGenes{
+					D:  Gene{Class: dominant[i], PartID: i*3 + 1},
+					R1: Gene{Class: ClassBug, PartID: i*3 + 2},
+					R2: Gene{Class: ClassDawn, PartID: i*3 + 3},
+				}
+				if got := g.Parts[pt]; got != want {
+					t.Errorf("%d bit %s = %+v, want %+v", l.bits, pt, got, want)
+				}
+			}
+			if got := g.Purity(); got != 4 {
+				t.Errorf("%d bit purity = %d, want 4", l.bits, got)
+			}
+			if got := g.DominantClasses(); len(got) != 6 || got[3] != ClassReptile || got[4] != ClassDusk {
+				t.Errorf("%d bit dominant classes = %v", l.bits, got)
+			}
+		}
+	}
+}
+
+func TestDecodeGenesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		genes string
+	}{
+		{"empty", ""},
+		{"not hex", "0xzz"},
+		{"not decimal", "12ab"},
+		{"negative", "-1"},
+		{"over 512 bits", "0x1" + strings.Repeat("0", 128)},
+		{"unknown 256 bit class", "0x6" + strings.Repeat("0", 63)},
+		{"unknown 512 bit class", "0x30" + strings.Repeat("0", 126)},
+		// eyes D class 0111 at bit 66
+		{"unknown part class", "0x00" + strings.Repeat("00", 7) + "1c" + strings.Repeat("00", 23)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if g, err := DecodeGenes(tt.genes); !errors.Is(err, ErrInvalidGenes) {
+				t.Errorf("DecodeGenes = %+v, %v, want an ErrInvalidGenes", g, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"cmd/internal/metrics"
+	"sync"
+	"time"
+)
+
+// TTL is a concurrency safe in-memory cache where entries expire after a fixed time
+// lookups are counted in the cache metrics under the cache's name
+type TTL[K comparable, V any] struct {
+	mu      sync.Mutex
+	name    string
+	ttl     time.Duration
+	entries map[K]entry[V]
+	now     func() time.Time
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// NewTTL func creates a cache, name is used as the metrics label
+func NewTTL[K comparable, V any](name string, ttl time.Duration) *TTL[K, V] {
+	return &TTL[K, V]{
+		name:    name,
+		ttl:     ttl,
+		entries: make(map[K]entry[V]),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached value and true, or the zero value and false if missing or expired
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if ok && c.now().After(e.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+
+	metrics.ObserveCache(c.name, ok)
+	return e.value, ok
+}
+
+// Set stores value under key for the cache's TTL
+func (c *TTL[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry[V]{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// Delete drops a key
+func (c *TTL[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Purge drops every expired entry, call it now and then on long running processes
+func (c *TTL[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
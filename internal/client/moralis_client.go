@@ -2,16 +2,41 @@ package client
 
 import (
 	"bytes"
+	"cmd/internal/metrics"
 	"cmd/internal/models"
 	"cmd/pkg/logger"
+	"cmd/pkg/requestid"
+	"cmd/pkg/tracing"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("cmd/internal/client")
+
+// Endpoint names, used as metric labels
+const (
+	endpointWalletNFTs   = "wallet_nfts"
+	endpointMultipleNFTs = "multiple_nfts"
+	endpointContractNFTs = "contract_nfts"
+	endpointResync       = "metadata_resync"
+	endpointHistory      = "wallet_history"
+	endpointStreams      = "streams"
+)
+
+// Moralis reports the compute units a call cost in this response header
+const computeUnitsHeader = "X-Request-Weight"
+
 // MoralisClient struct responsible for 'talking' to the Moralis API
 type MoralisClient struct {
 	httpClient *http.Client
@@ -19,17 +44,24 @@ type MoralisClient struct {
 	apiKey     string
 	walletAddr string
 	logger     *logger.Logger
+
+	limiter    *limiter // nil means no client side limit
+	maxRetries int
+
+	streamsURL string // Moralis Streams lives on its own host
 }
 
 // NewMoralisClient func creates a new client
-func NewMoralisClient(apiKey, baseURL, walletAddr string) *MoralisClient {
-	log := logger.New().WithGroup("moralis_client")
+// log is the application's root logger, the client logs under its own group
+func NewMoralisClient(apiKey, baseURL, walletAddr string, log *logger.Logger) *MoralisClient {
+	log = log.WithGroup("moralis_client")
 
 	client := &MoralisClient{
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 		baseURL:    baseURL,
 		apiKey:     apiKey,
 		logger:     log,
+		streamsURL: defaultStreamsURL,
 	}
 
 	log.Info("Moralis client initalized",
@@ -39,35 +71,57 @@ func NewMoralisClient(apiKey, baseURL, walletAddr string) *MoralisClient {
 	return client
 }
 
+// SetRateLimit caps requests per second across every caller of the client, with bursts of
+// up to burst, 0 or less turns the limit off
+func (c *MoralisClient) SetRateLimit(perSecond float64, burst int) {
+	c.limiter = newLimiter(perSecond, burst)
+}
+
+// SetMaxRetries sets how many times a call rate limited or failed by Moralis is retried
+func (c *MoralisClient) SetMaxRetries(n int) {
+	c.maxRetries = max(n, 0)
+}
+
 // GetNFTsByWallet
 // Explanation -> Gets all NFTs for a wallet
 // Return -> Data from the Moralis API (pick whatever you fancy, if need arises)
 func (c *MoralisClient) GetNFTsByWallet(ctx context.Context, walletAddr string, params models.QueryParams) ([]models.RawNFTData, error) {
+	nfts, _, err := c.GetNFTsByWalletPage(ctx, walletAddr, params)
+	return nfts, err
+}
+
+// GetNFTsByWalletPage
+// Explanation -> same as GetNFTsByWallet, starting at params.Cursor if set
+// Return -> the page and the cursor for the next one, "" on the last page
+func (c *MoralisClient) GetNFTsByWalletPage(ctx context.Context, walletAddr string, params models.QueryParams) (_ []models.RawNFTData, next string, err error) {
 	start := time.Now()
 
+	ctx, span := tracer.Start(ctx, "moralis.GetNFTsByWallet")
+	span.SetAttributes(attribute.String("wallet_address", walletAddr))
+	defer func() { endSpan(span, err) }()
+
 	// Log request starts
-	c.logger.Info("Starting NFT wallet request",
+	c.logger.InfoContext(ctx, "Starting NFT wallet request",
 		"wallet_address", walletAddr,
 		"limit", params.Limit,
 		"exclude_spam", params.ExcludeSpam,
+		"include_prices", params.IncludePrices,
 	)
 
 	// Build URL, make request
 	// Format: baseURL/{address}/nft
-	url := fmt.Sprintf("%s/%s/nft", strings.TrimSuffix(c.baseURL, "/"), walletAddr)
+	// escaped so odd input can't change the path, callers should validate with pkg/address first
+	url := fmt.Sprintf("%s/%s/nft", strings.TrimSuffix(c.baseURL, "/"), neturl.PathEscape(walletAddr))
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := c.newRequest(ctx, "GET", url, nil)
 	if err != nil {
-		c.logger.Error("Failed to create request",
+		c.logger.ErrorContext(ctx, "Failed to create request",
 			"error", err,
 			"wallet_address", walletAddr,
 		)
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, "", fmt.Errorf("creating request: %w", err)
 	}
 
-	// Set header
-	req.Header.Set("X-API-Key", c.apiKey)
-
 	// Add query params
 	// TODO: handle multiple chains in the future
 	query := req.URL.Query()
@@ -78,43 +132,54 @@ func (c *MoralisClient) GetNFTsByWallet(ctx context.Context, walletAddr string,
 	if params.ExcludeSpam {
 		query.Add("exclude_spam", "true")
 	}
+	if params.IncludePrices {
+		query.Add("include_prices", "true")
+	}
+	if params.MediaItems {
+		query.Add("media_items", "true")
+	}
+	if params.Cursor != nil && *params.Cursor != "" {
+		query.Add("cursor", *params.Cursor)
+	}
 	req.URL.RawQuery = query.Encode()
 
 	// Make request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, endpointWalletNFTs)
 	if err != nil {
-		c.logger.Error("error", err,
+		c.logger.ErrorContext(ctx, "Request failed",
+			"error", err,
 			"wallet_address", walletAddr,
 			"url", url,
 			"duration", time.Since(start))
-		return nil, fmt.Errorf("making request: %w", err)
+		return nil, "", fmt.Errorf("making request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// log response
 	duration := time.Since(start)
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("API request failed",
+		c.logger.ErrorContext(ctx, "API request failed",
 			"status_code", resp.StatusCode,
 			"status", resp.Status,
 			"wallet_address", walletAddr,
 			"duration", duration,
 		)
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
 	// Parse response, we need the queries sent
 	var apiResp models.APIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		c.logger.Error("Failed to parse respsonse",
+		c.logger.ErrorContext(ctx, "Failed to parse respsonse",
 			"error", err,
 			"wallet_address", walletAddr,
 		)
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return nil, "", fmt.Errorf("parsing response: %w", err)
 	}
 
 	// log success
-	c.logger.Info("NFT wallet request completed",
+	c.logger.InfoContext(ctx, "NFT wallet request completed",
 		"wallet_address", walletAddr,
 		"nfts_found", len(apiResp.Result),
 		"duration", duration,
@@ -122,13 +187,17 @@ func (c *MoralisClient) GetNFTsByWallet(ctx context.Context, walletAddr string,
 	)
 
 	// Return the result of the queries
-	return apiResp.Result, nil
+	return apiResp.Result, apiResp.Cursor, nil
 }
 
 // GetSpecificNFTs
 // Explanation -> Takes the token address and token ID as params, which are in the TokenRequest struct
 // Return -> Data from the RawNFTData struct (pick whichever you fancy)
-func (c *MoralisClient) GetSpecificNFTs(ctx context.Context, tokens []models.TokenRequest) ([]models.RawNFTData, error) {
+func (c *MoralisClient) GetSpecificNFTs(ctx context.Context, tokens []models.TokenRequest) (_ []models.RawNFTData, err error) {
+	ctx, span := tracer.Start(ctx, "moralis.GetSpecificNFTs")
+	span.SetAttributes(attribute.Int("tokens", len(tokens)))
+	defer func() { endSpan(span, err) }()
+
 	// Format: baseURL/nft/getMultipleNFTs
 	url := fmt.Sprintf("%s/nft/getMultipleNFTs", strings.TrimSuffix(c.baseURL, "/"))
 
@@ -147,14 +216,11 @@ func (c *MoralisClient) GetSpecificNFTs(ctx context.Context, tokens []models.Tok
 	}
 
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	req, err := c.newRequest(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("creating request(client/moralis_client): %w", err)
 	}
-
-	// Add headers
-	req.Header.Set("X-API-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	markIdempotent(req) // a lookup, POST only to fit the token list
 
 	// Add query params
 	query := req.URL.Query()
@@ -162,12 +228,13 @@ func (c *MoralisClient) GetSpecificNFTs(ctx context.Context, tokens []models.Tok
 	req.URL.RawQuery = query.Encode()
 
 	// Make request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, endpointMultipleNFTs)
 	if err != nil {
 		return nil, fmt.Errorf("making request(client/moralis_client): %w)", err)
 	}
 	defer resp.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
@@ -182,3 +249,281 @@ func (c *MoralisClient) GetSpecificNFTs(ctx context.Context, tokens []models.Tok
 
 	return nftData, nil
 }
+
+// GetContractNFTs
+// Explanation -> gets one page of every token in a collection, pass the returned cursor back
+// in to get the next page, "" starts from the beginning
+// Return -> the page, the cursor for the next one ("" on the last page) and the collection's
+// token count, 0 when Moralis didn't report one
+func (c *MoralisClient) GetContractNFTs(ctx context.Context, contractAddr, cursor string, limit int) (_ []models.RawNFTData, next string, total int, err error) {
+	ctx, span := tracer.Start(ctx, "moralis.GetContractNFTs")
+	span.SetAttributes(
+		attribute.String("token_address", contractAddr),
+		attribute.Bool("has_cursor", cursor != ""),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Format: baseURL/nft/{address}
+	url := fmt.Sprintf("%s/nft/%s", strings.TrimSuffix(c.baseURL, "/"), neturl.PathEscape(contractAddr))
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Add("chain", "ronin")
+	query.Add("format", "decimal")
+	query.Add("normalizeMetadata", "true")
+	if limit > 0 {
+		query.Add("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		query.Add("cursor", cursor)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := c.do(req, endpointContractNFTs)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		c.logger.ErrorContext(ctx, "API request failed",
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"token_address", contractAddr,
+		)
+		return nil, "", 0, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp models.APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, "", 0, fmt.Errorf("parsing response: %w", err)
+	}
+
+	c.logger.DebugContext(ctx, "Contract NFTs page fetched",
+		"token_address", contractAddr,
+		"page", apiResp.Page,
+		"nfts_found", len(apiResp.Result),
+	)
+	if apiResp.Total != nil {
+		total = *apiResp.Total
+	}
+	return apiResp.Result, apiResp.Cursor, total, nil
+}
+
+// GetWalletHistory
+// Explanation -> gets one page of a wallet's transactions, decoded into NFT, token and native
+// transfers, starting at params.Cursor
+// Return -> the page and the cursor for the next one, "" on the last page
+func (c *MoralisClient) GetWalletHistory(ctx context.Context, walletAddr string, params models.HistoryParams) (_ []models.Transaction, next string, err error) {
+	ctx, span := tracer.Start(ctx, "moralis.GetWalletHistory")
+	span.SetAttributes(
+		attribute.String("wallet_address", walletAddr),
+		attribute.Bool("has_cursor", params.Cursor != ""),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Format: baseURL/wallets/{address}/history
+	url := fmt.Sprintf("%s/wallets/%s/history", strings.TrimSuffix(c.baseURL, "/"), neturl.PathEscape(walletAddr))
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating request: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Add("chain", "ronin")
+	query.Add("include_internal_transactions", "true") // sale proceeds are paid by internal calls
+	if params.Limit > 0 {
+		query.Add("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Cursor != "" {
+		query.Add("cursor", params.Cursor)
+	}
+	if params.FromDate != "" {
+		query.Add("from_date", params.FromDate)
+	}
+	if params.ToDate != "" {
+		query.Add("to_date", params.ToDate)
+	}
+	if params.Order != "" {
+		query.Add("order", strings.ToUpper(params.Order))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := c.do(req, endpointHistory)
+	if err != nil {
+		return nil, "", fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		c.logger.ErrorContext(ctx, "API request failed",
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"wallet_address", walletAddr,
+		)
+		return nil, "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp models.HistoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, "", fmt.Errorf("parsing response: %w", err)
+	}
+
+	c.logger.DebugContext(ctx, "Wallet history page fetched",
+		"wallet_address", walletAddr,
+		"page", apiResp.Page,
+		"transactions", len(apiResp.Result),
+	)
+	return apiResp.Result, apiResp.Cursor, nil
+}
+
+// ResyncMetadata
+// Explanation -> asks Moralis to re-fetch a token's token_uri and metadata, for tokens whose
+// metadata is missing or stale. Moralis does the work in the background, the fresh metadata
+// shows up in later responses
+// Return -> an error if Moralis didn't accept the request
+func (c *MoralisClient) ResyncMetadata(ctx context.Context, contractAddr, tokenID string) (err error) {
+	ctx, span := tracer.Start(ctx, "moralis.ResyncMetadata")
+	span.SetAttributes(
+		attribute.String("token_address", contractAddr),
+		attribute.String("token_id", tokenID),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Format: baseURL/nft/{address}/{token_id}/metadata/resync
+	url := fmt.Sprintf("%s/nft/%s/%s/metadata/resync", strings.TrimSuffix(c.baseURL, "/"),
+		neturl.PathEscape(contractAddr), neturl.PathEscape(tokenID))
+
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Add("chain", "ronin")
+	query.Add("flag", "uri") // re-read token_uri from the contract too, not just the JSON
+	query.Add("mode", "async")
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := c.do(req, endpointResync)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	// 200 for sync mode, 202 when queued
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		c.logger.WarnContext(ctx, "Metadata resync rejected",
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"token_address", contractAddr,
+			"token_id", tokenID,
+		)
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	c.logger.DebugContext(ctx, "Metadata resync requested",
+		"token_address", contractAddr,
+		"token_id", tokenID,
+	)
+	return nil
+}
+
+// newRequest
+// Explanation -> builds a request with the headers every Moralis call needs,
+// including the request ID in ctx so calls can be matched up with Moralis support
+// Return -> the request
+func (c *MoralisClient) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-API-Key", c.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	return req, nil
+}
+
+// do
+// Explanation -> sends the request once the rate limiter allows, retrying 429s, 5xx and
+// transport errors with backoff if the request is idempotent, and records latency, status
+// and compute units per attempt
+// Return -> the response, caller closes the body
+func (c *MoralisClient) do(req *http.Request, endpoint string) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		waited, err := c.limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if waited > 0 {
+			metrics.ObserveRateLimitWait(waited)
+		}
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+			if units, perr := strconv.ParseFloat(resp.Header.Get(computeUnitsHeader), 64); perr == nil {
+				metrics.ObserveComputeUnits(endpoint, units)
+			}
+		}
+		metrics.ObserveMoralisRequest(endpoint, status, time.Since(start))
+
+		if attempt >= c.maxRetries || !idempotent(req) || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		// the body has been sent, it needs a fresh copy for the next attempt
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+		delay := backoff(resp, attempt)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		c.logger.WarnContext(ctx, "Retrying Moralis request",
+			"endpoint", endpoint,
+			"status", status,
+			"error", err,
+			"attempt", attempt+1,
+			"delay", delay,
+		)
+		metrics.IncRetry(endpoint)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// endSpan records the error, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
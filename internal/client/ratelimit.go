@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry backoff bounds, doubled per attempt with jitter, Retry-After wins when Moralis sends it
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+	retryAfterMax  = 30 * time.Second
+)
+
+// limiter is a token bucket shared by every call the client makes, so concurrent callers
+// (e.g. a batch over many wallets) stay under the Moralis rate limit together
+type limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter
+// Explanation -> a bucket allowing perSecond requests with bursts of up to burst
+// Return -> the limiter, nil (no limit) for a rate of 0 or less
+func newLimiter(perSecond float64, burst int) *limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait
+// Explanation -> takes a token, sleeping until one is free. Callers reserve their slot before
+// sleeping, so waiters are served in the order they arrived
+// Return -> how long it waited, ctx's error if it was cancelled first
+func (l *limiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		// give the slot back, nobody used it
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// idempotencyKey marks a request safe to send twice, the net/http convention: present with
+// no value, it is never sent to Moralis
+const idempotencyKey = "Idempotency-Key"
+
+// markIdempotent lets do retry a request that isn't a GET, e.g. a POST that only reads
+func markIdempotent(req *http.Request) {
+	req.Header[idempotencyKey] = nil
+}
+
+// idempotent reports whether req can be sent again after a failure without doing anything
+// twice. Creating a stream is a PUT, but a retry after a timeout creates a second one
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	_, marked := req.Header[idempotencyKey]
+	return marked
+}
+
+// retryable reports whether a response or transport error is worth another attempt:
+// rate limited, a server error, or the request never got an answer
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff is how long to wait before retry number attempt (0 based), honouring Retry-After
+func backoff(resp *http.Response, attempt int) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, retryAfterMax)
+		}
+	}
+	d := min(retryBaseDelay<<attempt, retryMaxDelay)
+	// full jitter, so workers that failed together don't retry together
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep waits d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"cmd/internal/models"
+	"cmd/pkg/logger"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unavailable answers 503 with Retry-After: 0 to every request, counting them by method
+type unavailable struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	markers int // requests that carried the idempotency marker to the wire
+	bodies  []string
+}
+
+func (u *unavailable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	u.mu.Lock()
+	u.calls[r.Method]++
+	if _, ok := r.Header[idempotencyKey]; ok {
+		u.markers++
+	}
+	u.bodies = append(u.bodies, string(body))
+	u.mu.Unlock()
+	w.Header().Set("Retry-After", "0")
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func newUnavailableClient(t *testing.T) (*MoralisClient, *unavailable) {
+	t.Helper()
+	u := &unavailable{calls: make(map[string]int)}
+	srv := httptest.NewServer(u)
+	t.Cleanup(srv.Close)
+
+	c := NewMoralisClient("key", srv.URL, "", logger.NewWithLevel(slog.LevelError+4))
+	c.SetStreamsURL(srv.URL)
+	c.SetMaxRetries(2)
+	return c, u
+}
+
+func TestRetriesOnlyIdempotentRequests(t *testing.T) {
+	ctx := context.Background()
+	c, u := newUnavailableClient(t)
+
+	if _, err := c.CreateStream(ctx, models.StreamConfig{WebhookURL: "https://example.com/hook"}, nil); err == nil {
+		t.Fatal("CreateStream succeeded against a 503")
+	}
+	if _, _, err := c.GetNFTsByWalletPage(ctx, "0x1111111111111111111111111111111111111111", models.QueryParams{}); err == nil {
+		t.Fatal("GetNFTsByWalletPage succeeded against a 503")
+	}
+	tokens := []models.TokenRequest{{TokenAddress: "0x32950db2a7164ae833121501c797d79e7b79d74c", TokenID: "1"}}
+	if _, err := c.GetSpecificNFTs(ctx, tokens); err == nil {
+		t.Fatal("GetSpecificNFTs succeeded against a 503")
+	}
+
+	// a retried PUT could create a second stream
+	if got := u.calls[http.MethodPut]; got != 1 {
+		t.Errorf("stream PUT sent %d times, want once", got)
+	}
+	if got := u.calls[http.MethodGet]; got != 3 {
+		t.Errorf("GET sent %d times, want 3", got)
+	}
+	// the token lookup is a POST marked safe to repeat, resent with its body each time
+	if got := u.calls[http.MethodPost]; got != 3 {
+		t.Errorf("lookup POST sent %d times, want 3", got)
+	}
+	last := u.bodies[len(u.bodies)-1]
+	if last == "" || last != u.bodies[len(u.bodies)-3] {
+		t.Errorf("retried POST body = %q, want the original", last)
+	}
+	if u.markers != 0 {
+		t.Errorf("%d requests sent the %s marker to Moralis", u.markers, idempotencyKey)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	if waited, err := (*limiter)(nil).wait(ctx); waited != 0 || err != nil {
+		t.Errorf("nil limiter waited %s, %v", waited, err)
+	}
+	if newLimiter(0, 5) != nil {
+		t.Error("a rate of 0 should be no limiter")
+	}
+
+	l := newLimiter(10, 2)
+	for i := range 2 {
+		if waited, _ := l.wait(ctx); waited != 0 {
+			t.Errorf("burst request %d waited %s", i, waited)
+		}
+	}
+	if waited, err := l.wait(ctx); err != nil || waited < 90*time.Millisecond {
+		t.Errorf("request past the burst waited %s, %v, want about 100ms", waited, err)
+	}
+
+	// a cancelled waiter gives its slot back
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := l.wait(cancelled); err == nil {
+		t.Error("wait ignored a cancelled context")
+	}
+	if waited, _ := l.wait(ctx); waited > 150*time.Millisecond {
+		t.Errorf("waited %s after a cancelled waiter, its slot wasn't returned", waited)
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"cmd/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultStreamsURL is the Moralis Streams API, separate from the Web3 data API
+const defaultStreamsURL = "https://api.moralis-streams.com"
+
+// SetStreamsURL points stream management at another host, e.g. a test server
+func (c *MoralisClient) SetStreamsURL(url string) {
+	if url != "" {
+		c.streamsURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// CreateStream
+// Explanation -> registers a stream posting to cfg.WebhookURL, then adds the addresses it
+// watches. Moralis sends a test webhook to the URL before answering, it has to be reachable
+// Return -> the stream as Moralis stored it
+func (c *MoralisClient) CreateStream(ctx context.Context, cfg models.StreamConfig, addresses []string) (_ *models.Stream, err error) {
+	ctx, span := tracer.Start(ctx, "moralis.CreateStream")
+	span.SetAttributes(attribute.Int("addresses", len(addresses)))
+	defer func() { endSpan(span, err) }()
+
+	var stream models.Stream
+	if err := c.streamsCall(ctx, http.MethodPut, "/streams/evm", cfg, &stream); err != nil {
+		return nil, fmt.Errorf("creating stream: %w", err)
+	}
+	if len(addresses) > 0 {
+		if err := c.AddStreamAddresses(ctx, stream.ID, addresses); err != nil {
+			return &stream, err
+		}
+		stream.AmountOfAddresses = len(addresses)
+	}
+
+	c.logger.InfoContext(ctx, "Moralis stream created",
+		"stream_id", stream.ID,
+		"webhook_url", stream.WebhookURL,
+		"addresses", len(addresses),
+	)
+	return &stream, nil
+}
+
+// AddStreamAddresses adds addresses to an existing stream
+func (c *MoralisClient) AddStreamAddresses(ctx context.Context, streamID string, addresses []string) error {
+	body := map[string][]string{"address": addresses}
+	path := "/streams/evm/" + neturl.PathEscape(streamID) + "/address"
+	if err := c.streamsCall(ctx, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("adding addresses to stream %s: %w", streamID, err)
+	}
+	return nil
+}
+
+// ListStreams
+// Explanation -> gets every stream on the account, following the cursor
+// Return -> the streams
+func (c *MoralisClient) ListStreams(ctx context.Context) (_ []models.Stream, err error) {
+	ctx, span := tracer.Start(ctx, "moralis.ListStreams")
+	defer func() { endSpan(span, err) }()
+
+	var streams []models.Stream
+	cursor := ""
+	for {
+		query := neturl.Values{"limit": {"100"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var page models.StreamsPage
+		if err := c.streamsCall(ctx, http.MethodGet, "/streams/evm?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("listing streams: %w", err)
+		}
+		streams = append(streams, page.Result...)
+		if page.Cursor == "" || len(page.Result) == 0 {
+			break
+		}
+		cursor = page.Cursor
+	}
+	span.SetAttributes(attribute.Int("streams", len(streams)))
+	return streams, nil
+}
+
+// DeleteStream removes a stream, Moralis stops posting to its webhook
+func (c *MoralisClient) DeleteStream(ctx context.Context, streamID string) (err error) {
+	ctx, span := tracer.Start(ctx, "moralis.DeleteStream")
+	span.SetAttributes(attribute.String("stream_id", streamID))
+	defer func() { endSpan(span, err) }()
+
+	if err := c.streamsCall(ctx, http.MethodDelete, "/streams/evm/"+neturl.PathEscape(streamID), nil, nil); err != nil {
+		return fmt.Errorf("deleting stream %s: %w", streamID, err)
+	}
+	c.logger.InfoContext(ctx, "Moralis stream deleted", "stream_id", streamID)
+	return nil
+}
+
+// streamsCall
+// Explanation -> sends body (if any) as JSON to the Streams API and decodes the answer into
+// out (if any)
+// Return -> an error with Moralis' message for non 2xx answers
+func (c *MoralisClient) streamsCall(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, c.streamsURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.do(req, endpointStreams)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr)
+		if apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package collections
+
+import (
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one tracked collection
+type Entry struct {
+	Address  string   `json:"address"` // checksummed 0x
+	Name     string   `json:"name"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Defaults are the Axie Infinity collections most users want tracked, `collections init` adds them
+// the Origin addresses are from the Ronin explorer, check them there if they stop matching
+var Defaults = []Entry{
+	{Address: "0x32950db2a7164ae833121501c797d79e7b79d74c", Name: "Axie", Category: "axie", Tags: []string{"axie-infinity"}},
+	{Address: "0x8c811e3c958e190f5ec15fb376533a3398620500", Name: "Land", Category: "land", Tags: []string{"axie-infinity"}},
+	{Address: "0xa96660f0e4a3e9bc7388925d245a6d4d79e21259", Name: "Land Items", Category: "item", Tags: []string{"axie-infinity"}},
+	{Address: "0xc25970724f032af21d801978c73653c440cf787c", Name: "Origin Runes", Category: "origin", Tags: []string{"axie-infinity", "rune"}},
+	{Address: "0x814a9c959a3ef6ca44b5e2349e3bba9845393947", Name: "Origin Charms", Category: "origin", Tags: []string{"axie-infinity", "charm"}},
+}
+
+// reloadEvery is how often reads check whether the file changed, e.g. edited by the CLI
+// while the server is running
+const reloadEvery = time.Second
+
+// Registry struct is the collections file, safe for concurrent use
+type Registry struct {
+	path string
+
+	mu        sync.Mutex
+	entries   map[address.Address]Entry
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// Load
+// Explanation -> reads the registry file, a missing file is an empty registry
+// Return -> the registry, an error for unreadable files or bad addresses in them
+func Load(path string) (*Registry, error) {
+	r := &Registry{path: path, entries: make(map[address.Address]Entry)}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the file if it changed since the last read, caller holds mu (or owns r)
+func (r *Registry) reload() error {
+	info, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		r.entries = make(map[address.Address]Entry)
+		r.modTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading collections: %w", err)
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("reading collections: %w", err)
+	}
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parsing collections %s: %w", r.path, err)
+	}
+
+	entries := make(map[address.Address]Entry, len(list))
+	for _, e := range list {
+		addr, err := address.Parse(e.Address)
+		if err != nil {
+			return fmt.Errorf("collections %s: %w", r.path, err)
+		}
+		e.Address = addr.Hex()
+		entries[addr] = e
+	}
+
+	r.entries = entries
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// refresh reloads at most once per reloadEvery, a failed reload keeps the old entries
+func (r *Registry) refresh() {
+	if time.Since(r.checkedAt) < reloadEvery {
+		return
+	}
+	r.checkedAt = time.Now()
+	_ = r.reload()
+}
+
+// Lookup finds the collection a token address belongs to, in any address format
+func (r *Registry) Lookup(tokenAddress string) (Entry, bool) {
+	addr, err := address.Parse(tokenAddress)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+
+	e, ok := r.entries[addr]
+	return e, ok
+}
+
+// Len is the number of tracked collections
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+
+	return len(r.entries)
+}
+
+// List returns every entry, sorted by category then name
+func (r *Registry) List() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+
+	list := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Category != list[j].Category {
+			return list[i].Category < list[j].Category
+		}
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// Add
+// Explanation -> adds or replaces a collection and saves the file
+// Return -> the entry as stored (address checksummed, tags trimmed)
+func (r *Registry) Add(e Entry) (Entry, error) {
+	addr, err := address.Parse(e.Address)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Address = addr.Hex()
+	e.Name = strings.TrimSpace(e.Name)
+	e.Category = strings.ToLower(strings.TrimSpace(e.Category))
+	tags := e.Tags[:0:0]
+	for _, t := range e.Tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	e.Tags = tags
+	if e.Name == "" {
+		return Entry{}, errors.New("collection name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.reload(); err != nil {
+		return Entry{}, err
+	}
+
+	r.entries[addr] = e
+	return e, r.save()
+}
+
+// Remove
+// Explanation -> removes a collection and saves the file
+// Return -> false if it wasn't in the registry
+func (r *Registry) Remove(tokenAddress string) (bool, error) {
+	addr, err := address.Parse(tokenAddress)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.reload(); err != nil {
+		return false, err
+	}
+
+	if _, ok := r.entries[addr]; !ok {
+		return false, nil
+	}
+	delete(r.entries, addr)
+	return true, r.save()
+}
+
+// save writes the file sorted by address so diffs stay small, via a temp file, caller holds mu
+func (r *Registry) save() error {
+	list := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Address) < strings.ToLower(list[j].Address)
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding collections: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("saving collections: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving collections: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving collections: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving collections: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("saving collections: %w", err)
+	}
+
+	// our own write shouldn't trigger a reload
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return nil
+}
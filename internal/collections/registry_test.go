@@ -0,0 +1,190 @@
+package collections
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	axie = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+	land = "0x8c811e3c958e190f5ec15fb376533a3398620500"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string // "" leaves the file missing
+		entries int
+		err     bool
+	}{
+		{"missing file", "", 0, false},
+		{"empty list", `[]`, 0, false},
+		{"entries in any address form", `[{"address":"ronin:` + axie[2:] + `","name":"Axie"},{"address":"` + strings.ToUpper(land) + `","name":"Land"}]`, 2, false},
+		{"same collection twice", `[{"address":"` + axie + `","name":"Old"},{"address":"ronin:` + axie[2:] + `","name":"Axie"}]`, 1, false},
+		{"bad json", `{`, 0, true},
+		{"bad address", `[{"address":"0x12","name":"Short"}]`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "collections.json")
+			if tt.body != "" {
+				writeFile(t, path, tt.body)
+			}
+
+			r, err := Load(path)
+			if tt.err {
+				if err == nil {
+					t.Fatal("Load succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if r.Len() != tt.entries {
+				t.Errorf("Len = %d, want %d", r.Len(), tt.entries)
+			}
+			// addresses are kept checksummed whatever form the file used
+			for _, e := range r.List() {
+				if !strings.HasPrefix(e.Address, "0x") || e.Address == strings.ToLower(e.Address) {
+					t.Errorf("address %s isn't checksummed", e.Address)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collections.json")
+	writeFile(t, path, `[{"address":"`+axie+`","name":"Axie","category":"axie","tags":["axie-infinity"]}]`)
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		found   bool
+	}{
+		{"lowercase", axie, true},
+		{"upper case", "0x" + strings.ToUpper(axie[2:]), true},
+		{"ronin prefix", "ronin:" + axie[2:], true},
+		{"untracked", land, false},
+		{"not an address", "axie", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := r.Lookup(tt.address)
+			if ok != tt.found {
+				t.Fatalf("Lookup = %+v, %v, want found %v", e, ok, tt.found)
+			}
+			if ok && (e.Name != "Axie" || e.Category != "axie" || len(e.Tags) != 1) {
+				t.Errorf("Lookup = %+v", e)
+			}
+		})
+	}
+}
+
+func TestAddMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "collections.json")
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Add(Entry{Address: axie, Name: "  "}); err == nil {
+		t.Error("Add without a name succeeded")
+	}
+	if _, err := r.Add(Entry{Address: "0x12", Name: "Short"}); err == nil {
+		t.Error("Add with a bad address succeeded")
+	}
+
+	e, err := r.Add(Entry{Address: "ronin:" + axie[2:], Name: " Axie ", Category: " AXIE ", Tags: []string{" Axie-Infinity ", ""}})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if e.Name != "Axie" || e.Category != "axie" || strings.Join(e.Tags, ",") != "axie-infinity" {
+		t.Errorf("Add stored %+v", e)
+	}
+
+	// another process, the CLI, adds to the file while r is open
+	other, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Add(Entry{Address: land, Name: "Land", Category: "land"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// make sure the edit is seen as one even on coarse mtimes
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	// adding reloads first, the other process' entry is kept and the name replaced
+	if _, err := r.Add(Entry{Address: axie, Name: "Axies"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	list := r.List()
+	if len(list) != 2 || list[0].Name != "Axies" || list[1].Name != "Land" {
+		t.Errorf("List = %+v, want Axies then Land", list)
+	}
+
+	if ok, err := r.Remove("0x" + strings.ToUpper(land[2:])); err != nil || !ok {
+		t.Errorf("Remove = %v, %v", ok, err)
+	}
+	if ok, err := r.Remove(land); err != nil || ok {
+		t.Errorf("Remove again = %v, %v, want false", ok, err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.Lookup(land); ok || reloaded.Len() != 1 {
+		t.Errorf("reloaded %+v, want only Axies", reloaded.List())
+	}
+}
+
+func TestReadsPickUpEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collections.json")
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 0 {
+		t.Fatalf("Len = %d", r.Len())
+	}
+
+	writeFile(t, path, `[{"address":"`+land+`","name":"Land"}]`)
+	r.checkedAt = time.Time{} // as if reloadEvery had passed
+	if _, ok := r.Lookup(land); !ok {
+		t.Error("edit not picked up")
+	}
+
+	// a broken edit keeps what was there
+	writeFile(t, path, `[`)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	r.checkedAt = time.Time{}
+	if _, ok := r.Lookup(land); !ok {
+		t.Error("broken edit dropped the entries")
+	}
+	if _, err := Load(path); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load of the broken file = %v", err)
+	}
+}
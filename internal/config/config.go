@@ -1,8 +1,10 @@
 package config
 
 import (
-	"cmd/pkg/logger"
+	"errors"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +16,18 @@ type Config struct {
 	Port     string
 	LogLevel string
 
+	// Logging
+	LogFormat     string // json or text
+	LogOutput     string // stdout, stderr or a file path
+	LogAddSource  bool
+	LogMaxSizeMB  int
+	LogMaxBackups int
+
+	// Tracing
+	TraceExporter string // none, stdout or otlp
+	TraceEndpoint string // otlp collector host:port
+	TraceInsecure bool
+
 	// Database
 	DatabaseURL string
 
@@ -31,16 +45,91 @@ type Config struct {
 	// Optional defaults
 	WalletAddress string
 	TokenAddress  string
+
+	// Display
+	AddressFormat string // 0x or ronin
+
+	// Ronin node / RNS
+	RoninRPCURL        string
+	RoninWSURL         string // WebSocket endpoint for the event stream, eth_subscribe
+	RNSResolverAddress string
+	RNSCacheTTL        time.Duration
+
+	// Axie
+	AxiePartsFile string // optional part catalog, names recessive genes
+
+	// Prices
+	PriceProvider  string // coingecko or none (overrides only)
+	CoinGeckoURL   string
+	PriceCacheTTL  time.Duration
+	PriceOverrides string // fixed USD prices, e.g. RON=2.10,AXS=6.5, win over the provider
+
+	// Local rarity, for collections Moralis doesn't rank
+	LocalRarity     bool
+	RarityMethod    string // information or statistical
+	RarityDir       string // where computed collections are kept, empty for memory only
+	RarityMaxTokens int
+	RarityTTL       time.Duration
+
+	// Collections registry, NFTs outside it are hidden once it has entries
+	CollectionsFile string
+
+	// Spam
+	SpamClassifier string // moralis (possible_spam only, default) or rules
+	SpamMode       string // drop, flag or only
+	SpamThreshold  float64
+	SpamListsFile  string // JSON {"allow": [...], "deny": [...]} of contracts
+	SpamAllow      string // comma separated contracts, added to the file's
+	SpamDeny       string
+
+	// Metadata resolution, for NFTs Moralis has no metadata for
+	ResolveMetadata    bool
+	IPFSGateway        string
+	ArweaveGateway     string
+	MetadataAutoResync bool
+	MetadataStaleAfter time.Duration // auto resync tokens last synced longer ago than this
+
+	// Media library, downloaded images and thumbnails
+	MediaDir       string // "" turns the library off, /v1/media and -download-media need one
+	MediaWorkers   int
+	MediaMaxBytes  int
+	MediaThumbSize int // longest side, in pixels
+
+	// Guild, scholars and the Axies lent to them
+	GuildFile string
+
+	// Moralis rate limiting, shared by every request the process makes
+	MoralisRateLimit  float64 // requests per second, 0 turns the limit off
+	MoralisBurst      int
+	MoralisMaxRetries int // for 429s, 5xx and network errors
+	BatchWorkers      int // wallets fetched at once in batch mode
+
+	// Moralis Streams webhooks, and the alerts they raise
+	MoralisStreamsURL     string
+	MoralisStreamsSecret  string // signs webhooks, defaults to the API key like Moralis does
+	EventsDB              string // SQLite file the webhook events go to, "" turns webhooks off
+	DiscordAlertChannelID string // channel the bot posts wallet alerts in, "" for none
+
+	// Live events in serve mode, pushed over SSE/WebSocket to clients holding a JWT_SECRET token
+	WatchWallets       string        // comma separated, followed on RONIN_WS_URL
+	PriceWatchInterval time.Duration // how often prices are checked for price_change, 0 for never
+	PriceChangePct     float64       // percent move that makes a price_change
 }
 
 func Load() (*Config, error) {
-	log := logger.New()
-
 	cfg := &Config{
 		MoralisAPIKey:    getEnv("MORALIS_API_KEY", ""),
 		MoralisBaseURL:   getEnv("MORALIS_BASE_URL", "https://deep-index.moralis.io/api/v2.2"),
 		Port:             getEnv("PORT", "8080"),
 		LogLevel:         getEnv("LOG_LEVEL", "info"),
+		LogFormat:        getEnv("LOG_FORMAT", "json"),
+		LogOutput:        getEnv("LOG_OUTPUT", "stderr"),
+		LogAddSource:     getEnvBool("LOG_ADD_SOURCE", true),
+		LogMaxSizeMB:     getEnvInt("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups:    getEnvInt("LOG_MAX_BACKUPS", 3),
+		TraceExporter:    getEnv("TRACE_EXPORTER", "none"),
+		TraceEndpoint:    getEnv("TRACE_ENDPOINT", "localhost:4318"),
+		TraceInsecure:    getEnvBool("TRACE_INSECURE", true),
 		DatabaseURL:      getEnv("DATABASE_URL", ""),
 		DiscordToken:     getEnv("DISCORD_BOT_TOKEN", ""),
 		DiscordClientID:  getEnv("DISCORD_CLIENT_ID", ""),
@@ -49,22 +138,71 @@ func Load() (*Config, error) {
 		JWTSecret:        getEnv("JWT_SECRET", ""),
 		WalletAddress:    getEnv("WALLET_ADDRESS", ""),
 		TokenAddress:     getEnv("TOKEN_ADDRESS", ""),
-	}
+		AddressFormat:    getEnv("ADDRESS_FORMAT", "0x"),
+
+		// Ronin node / RNS
+		RoninRPCURL:        getEnv("RONIN_RPC_URL", "https://api.roninchain.com/rpc"),
+		RoninWSURL:         getEnv("RONIN_WS_URL", ""),
+		RNSResolverAddress: getEnv("RNS_RESOLVER_ADDRESS", ""),
+		RNSCacheTTL:        getEnvDuration("RNS_CACHE_TTL", time.Hour),
+
+		AxiePartsFile: getEnv("AXIE_PARTS_FILE", ""),
+
+		PriceProvider:  getEnv("PRICE_PROVIDER", "coingecko"),
+		CoinGeckoURL:   getEnv("COINGECKO_BASE_URL", "https://api.coingecko.com/api/v3"),
+		PriceCacheTTL:  getEnvDuration("PRICE_CACHE_TTL", 5*time.Minute),
+		PriceOverrides: getEnv("PRICE_OVERRIDES", ""),
+
+		LocalRarity:     getEnvBool("LOCAL_RARITY", false),
+		RarityMethod:    getEnv("RARITY_METHOD", "information"),
+		RarityDir:       getEnv("RARITY_DIR", ""),
+		RarityMaxTokens: getEnvInt("RARITY_MAX_TOKENS", 10000),
+		RarityTTL:       getEnvDuration("RARITY_TTL", 24*time.Hour),
+
+		CollectionsFile: getEnv("COLLECTIONS_FILE", "collections.json"),
+
+		SpamClassifier: getEnv("SPAM_CLASSIFIER", "moralis"),
+		SpamMode:       getEnv("SPAM_MODE", "drop"),
+		SpamThreshold:  getEnvFloat("SPAM_THRESHOLD", 1.0),
+		SpamListsFile:  getEnv("SPAM_LISTS_FILE", ""),
+		SpamAllow:      getEnv("SPAM_ALLOW", ""),
+		SpamDeny:       getEnv("SPAM_DENY", ""),
+
+		ResolveMetadata:    getEnvBool("METADATA_RESOLVE", true),
+		IPFSGateway:        getEnv("IPFS_GATEWAY", "https://ipfs.io/ipfs/"),
+		ArweaveGateway:     getEnv("ARWEAVE_GATEWAY", "https://arweave.net/"),
+		MetadataAutoResync: getEnvBool("METADATA_AUTO_RESYNC", false),
+		MetadataStaleAfter: getEnvDuration("METADATA_STALE_AFTER", 30*24*time.Hour),
+
+		MediaDir:       getEnv("MEDIA_DIR", ""),
+		MediaWorkers:   getEnvInt("MEDIA_WORKERS", 4),
+		MediaMaxBytes:  getEnvInt("MEDIA_MAX_BYTES", 20<<20),
+		MediaThumbSize: getEnvInt("MEDIA_THUMB_SIZE", 256),
+
+		GuildFile: getEnv("GUILD_FILE", "guild.json"),
+
+		MoralisRateLimit:  getEnvFloat("MORALIS_RATE_LIMIT", 20),
+		MoralisBurst:      getEnvInt("MORALIS_BURST", 5),
+		MoralisMaxRetries: getEnvInt("MORALIS_MAX_RETRIES", 3),
+		BatchWorkers:      getEnvInt("BATCH_WORKERS", 4),
 
-	// Log configuration loading with structured data
-	log.Info("configuration loaded",
-		"moralis_base_url", cfg.MoralisBaseURL,
-		"port", cfg.Port,
-		"log_level", cfg.LogLevel,
-	)
+		MoralisStreamsURL:     getEnv("MORALIS_STREAMS_URL", "https://api.moralis-streams.com"),
+		MoralisStreamsSecret:  getEnv("MORALIS_STREAMS_SECRET", ""),
+		EventsDB:              getEnv("EVENTS_DB", ""),
+		DiscordAlertChannelID: getEnv("DISCORD_ALERT_CHANNEL_ID", ""),
+
+		WatchWallets:       getEnv("WATCH_WALLETS", ""),
+		PriceWatchInterval: getEnvDuration("PRICE_WATCH_INTERVAL", time.Minute),
+		PriceChangePct:     getEnvFloat("PRICE_CHANGE_PCT", 1.0),
+	}
 
 	// Check if requiired fields are set
+	// the logger isn't built yet (it depends on this config), so let the caller log it
 	if cfg.MoralisAPIKey == "" {
-		log.Error("Missing required configuration",
-			"field", "MORALIS_API_KEY",
-			"message", "MORALIS_API_KEY environment variable is required",
-		)
-		os.Exit(1)
+		return nil, errors.New("MORALIS_API_KEY environment variable is required")
+	}
+	if cfg.MoralisStreamsSecret == "" {
+		cfg.MoralisStreamsSecret = cfg.MoralisAPIKey
 	}
 
 	return cfg, nil
@@ -77,3 +215,35 @@ func getEnv(key, defaultValue string) string {
 
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+
+	return defaultValue
+}
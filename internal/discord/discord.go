@@ -0,0 +1,171 @@
+// Package discord posts wallet alerts to a Discord channel through the bot API, with the bot
+// token the app already has
+package discord
+
+import (
+	"bytes"
+	"cmd/internal/events"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAPIURL is Discord's REST API
+const defaultAPIURL = "https://discord.com/api/v10"
+
+// explorerTx links a transaction on the Ronin explorer
+const explorerTx = "https://app.roninchain.com/tx/"
+
+// maxRetryAfter caps how long a rate limited message waits before its one retry
+const maxRetryAfter = 10 * time.Second
+
+// Notifier struct posts messages to one channel
+type Notifier struct {
+	httpClient *http.Client
+	apiURL     string
+	token      string
+	channelID  string
+	logger     *logger.Logger
+}
+
+// New creates a notifier posting to channelID as the bot with token
+// log is the application's root logger, the notifier logs under its own group
+func New(token, channelID string, log *logger.Logger) *Notifier {
+	return &Notifier{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		apiURL:     defaultAPIURL,
+		token:      token,
+		channelID:  channelID,
+		logger:     log.WithGroup("discord"),
+	}
+}
+
+// SetAPIURL points the notifier at another API, e.g. a test server
+func (n *Notifier) SetAPIURL(url string) {
+	n.apiURL = strings.TrimSuffix(url, "/")
+}
+
+// Watch
+// Explanation -> posts an alert for every wallet event on sub until it closes or ctx is done.
+// Possible spam is skipped, nobody wants an alert for an airdropped scam token, and so are
+// price moves, they'd drown the wallet alerts out
+// Return -> nothing, failed posts are logged and dropped
+func (n *Notifier) Watch(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if spam(e) || e.Wallet == "" {
+				continue
+			}
+			if err := n.Send(ctx, Message(e)); err != nil {
+				n.logger.WarnContext(ctx, "Discord alert failed",
+					"error", err,
+					"event_id", e.ID,
+				)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Send
+// Explanation -> posts content to the channel, waiting out one rate limit if Discord asks
+// Return -> an error for anything but success
+func (n *Notifier) Send(ctx context.Context, content string) error {
+	body, err := json.Marshal(map[string]any{
+		"content":          content,
+		"allowed_mentions": map[string]any{"parse": []string{}}, // never ping from event data
+	})
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			n.apiURL+"/channels/"+n.channelID+"/messages", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bot "+n.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := n.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("posting message: %w", err)
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests && attempt == 0:
+			wait := time.Second
+			if secs, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil {
+				wait = min(time.Duration(secs*float64(time.Second)), maxRetryAfter)
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		default:
+			return fmt.Errorf("discord returned status %d", resp.StatusCode)
+		}
+	}
+}
+
+// Message is the alert text for an event
+func Message(e events.Event) string {
+	var b strings.Builder
+	switch {
+	case e.NFT != nil && e.Type == events.TypeNFTIn:
+		fmt.Fprintf(&b, "**NFT in** `%s` received #%s of `%s` from `%s`",
+			e.Wallet, e.NFT.TokenID, e.NFT.TokenAddress, e.NFT.FromAddress)
+	case e.NFT != nil:
+		fmt.Fprintf(&b, "**NFT out** `%s` sent #%s of `%s` to `%s`",
+			e.Wallet, e.NFT.TokenID, e.NFT.TokenAddress, e.NFT.ToAddress)
+	case e.ERC20 != nil:
+		amount := e.ERC20.ValueFormatted
+		if amount == "" {
+			amount = e.ERC20.Value + " units"
+		}
+		symbol := e.ERC20.TokenSymbol
+		if symbol == "" {
+			symbol = "`" + e.ERC20.Address + "`"
+		}
+		if e.ERC20.Direction == "receive" {
+			fmt.Fprintf(&b, "**Token in** `%s` received %s %s from `%s`", e.Wallet, amount, symbol, e.ERC20.FromAddress)
+		} else {
+			fmt.Fprintf(&b, "**Token out** `%s` sent %s %s to `%s`", e.Wallet, amount, symbol, e.ERC20.ToAddress)
+		}
+	default:
+		fmt.Fprintf(&b, "**%s** `%s`", e.Type, e.Wallet)
+	}
+
+	if e.BlockNumber > 0 {
+		fmt.Fprintf(&b, " in block %d", e.BlockNumber)
+	}
+	if !e.Confirmed && e.TxHash != "" {
+		b.WriteString(" (unconfirmed)")
+	}
+	if e.TxHash != "" {
+		b.WriteString("\n" + explorerTx + e.TxHash)
+	}
+	return b.String()
+}
+
+// spam reports whether Moralis flagged the event's token as possible spam
+func spam(e events.Event) bool {
+	return (e.NFT != nil && e.NFT.PossibleSpam) || (e.ERC20 != nil && e.ERC20.PossibleSpam)
+}
@@ -0,0 +1,130 @@
+// Package events is what happens to watched wallets as it happens: transfers pushed by Moralis
+// Streams (or seen on a node) and token price moves, kept once each in a SQLite store and
+// fanned out to whoever is watching, e.g. Discord alerts or dashboards over SSE/WebSocket
+package events
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types
+const (
+	TypeNFTIn         = "nft_in"         // an NFT arrived in a watched wallet
+	TypeNFTOut        = "nft_out"        // an NFT left a watched wallet
+	TypeTokenTransfer = "token_transfer" // an ERC-20 moved in or out, see ERC20.Direction
+	TypePriceChange   = "price_change"   // a token's USD price moved, concerns no wallet
+)
+
+// Event sources
+const (
+	SourceMoralisStreams = "moralis_streams"
+	SourceNode           = "node"
+	SourcePrices         = "prices"
+)
+
+// Event is one thing that happened to a watched wallet
+type Event struct {
+	ID          string    `json:"id"` // tx hash:log index, what duplicates are found by
+	Type        string    `json:"type"`
+	Wallet      string    `json:"wallet"` // the watched wallet it concerns
+	Source      string    `json:"source"`
+	BlockNumber uint64    `json:"block_number,omitempty"`
+	BlockTime   time.Time `json:"block_time,omitzero"`
+	TxHash      string    `json:"transaction_hash,omitempty"`
+	LogIndex    int       `json:"log_index"`
+	Confirmed   bool      `json:"confirmed"`
+	ReceivedAt  time.Time `json:"received_at"`
+
+	NFT   *models.NFTTransfer   `json:"nft,omitempty"`
+	ERC20 *models.ERC20Transfer `json:"erc20,omitempty"`
+	Price *PriceChange          `json:"price,omitempty"`
+}
+
+// TransferID is the dedupe key of a transfer, the same log seen twice has the same id
+func TransferID(txHash string, logIndex int) string {
+	return strings.ToLower(txHash) + ":" + strconv.Itoa(logIndex)
+}
+
+// Hub struct fans events out to subscribers, safe for concurrent use
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription is one watcher's feed of events
+type Subscription struct {
+	hub     *Hub
+	ch      chan Event
+	match   func(Event) bool // nil takes everything
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// NewHub creates a hub with no subscribers
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe
+// Explanation -> adds a watcher that can fall up to buffer events behind. Publishing never
+// waits on a watcher, events for one that is full are dropped and counted
+// Return -> the subscription, Close it when done
+func (h *Hub) Subscribe(buffer int) *Subscription {
+	return h.SubscribeFunc(buffer, nil)
+}
+
+// SubscribeFunc is Subscribe for only the events match accepts, so a watcher of a few wallets
+// doesn't fill up with everyone else's. match is called on the publisher's goroutine and
+// must be quick and safe for concurrent use
+func (h *Hub) SubscribeFunc(buffer int, match func(Event) bool) *Subscription {
+	s := &Subscription{hub: h, ch: make(chan Event, max(buffer, 1)), match: match}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Publish hands e to every subscriber with room for it
+func (h *Hub) Publish(e Event) {
+	metrics.IncEventPublished(e.Type)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if s.match != nil && !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+			metrics.IncEventDropped()
+		}
+	}
+}
+
+// Events is the subscription's feed, closed by Close
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped is how many events were missed because the feed was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the feed, safe to call more than once
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		close(s.ch)
+	})
+}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"cmd/internal/models"
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is where Moralis Streams puts a webhook's signature
+const SignatureHeader = "X-Signature"
+
+// VerifySignature
+// Explanation -> checks a Moralis Streams signature: keccak256 of the raw body followed by the
+// streams secret, hex encoded. The secret keys the hash the way an HMAC key would, so only
+// Moralis (and us) can produce it
+// Return -> true if the signature matches
+func VerifySignature(body []byte, signature, secret string) bool {
+	if signature == "" || secret == "" {
+		return false
+	}
+	want := "0x" + hex.EncodeToString(ronin.Keccak256(body, []byte(secret)))
+	got := strings.ToLower(strings.TrimSpace(signature))
+	if !strings.HasPrefix(got, "0x") {
+		got = "0x" + got
+	}
+	return subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// FromWebhook
+// Explanation -> turns a stream webhook's NFT and ERC-20 transfers into events for the watched
+// wallets in them, the recipient's if it's watched, else the sender's. The test webhook
+// Moralis sends when a stream is created has no block and gives no events
+// Return -> the events, an error for a payload with bad block fields
+func FromWebhook(p *models.StreamWebhook, receivedAt time.Time) ([]Event, error) {
+	if p.Block.Number == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseUint(p.Block.Number, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("block number %q: %w", p.Block.Number, err)
+	}
+	var blockTime time.Time
+	if ts, err := strconv.ParseInt(p.Block.Timestamp, 10, 64); err == nil {
+		blockTime = time.Unix(ts, 0).UTC()
+	}
+
+	base := Event{
+		Source:      SourceMoralisStreams,
+		BlockNumber: number,
+		BlockTime:   blockTime,
+		Confirmed:   p.Confirmed,
+		ReceivedAt:  receivedAt.UTC(),
+	}
+
+	var out []Event
+	for _, t := range p.NFTTransfers {
+		index, err := strconv.Atoi(t.LogIndex)
+		if err != nil {
+			return nil, fmt.Errorf("nft transfer %s log index %q: %w", t.TransactionHash, t.LogIndex, err)
+		}
+		from, to := normalize(t.From), normalize(t.To)
+		incoming := watching(t.TriggeredBy, to) || !watching(t.TriggeredBy, from)
+
+		e := base
+		e.ID, e.TxHash, e.LogIndex = TransferID(t.TransactionHash, index), t.TransactionHash, index
+		e.Type, e.Wallet = TypeNFTOut, from
+		direction := "send"
+		if incoming {
+			e.Type, e.Wallet, direction = TypeNFTIn, to, "receive"
+		}
+		e.NFT = &models.NFTTransfer{
+			LogIndex:     index,
+			ContractType: t.TokenContractType,
+			TokenAddress: normalize(t.Contract),
+			TokenID:      t.TokenID,
+			FromAddress:  from,
+			ToAddress:    to,
+			Amount:       t.Amount,
+			Operator:     t.Operator,
+			PossibleSpam: t.PossibleSpam,
+			Direction:    direction,
+		}
+		out = append(out, e)
+	}
+
+	for _, t := range p.ERC20Transfers {
+		index, err := strconv.Atoi(t.LogIndex)
+		if err != nil {
+			return nil, fmt.Errorf("erc20 transfer %s log index %q: %w", t.TransactionHash, t.LogIndex, err)
+		}
+		from, to := normalize(t.From), normalize(t.To)
+		incoming := watching(t.TriggeredBy, to) || !watching(t.TriggeredBy, from)
+
+		e := base
+		e.ID, e.TxHash, e.LogIndex = TransferID(t.TransactionHash, index), t.TransactionHash, index
+		e.Type, e.Wallet = TypeTokenTransfer, from
+		direction := "send"
+		if incoming {
+			e.Wallet, direction = to, "receive"
+		}
+		e.ERC20 = &models.ERC20Transfer{
+			TokenName:      t.TokenName,
+			TokenSymbol:    t.TokenSymbol,
+			TokenDecimals:  t.TokenDecimals,
+			FromAddress:    from,
+			ToAddress:      to,
+			Address:        normalize(t.Contract),
+			LogIndex:       index,
+			Value:          t.Value,
+			ValueFormatted: t.ValueWithDecimals,
+			PossibleSpam:   t.PossibleSpam,
+			Direction:      direction,
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// normalize checksums an address, leaving anything unparseable as it came
+func normalize(s string) string {
+	if addr, err := address.Parse(s); err == nil {
+		return addr.Hex()
+	}
+	return s
+}
+
+// watching reports whether addr is one of the stream addresses that triggered the webhook
+func watching(triggeredBy []string, addr string) bool {
+	for _, t := range triggeredBy {
+		if address.Equal(t, addr) {
+			return true
+		}
+	}
+	return false
+}
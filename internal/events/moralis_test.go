@@ -0,0 +1,40 @@
+package events
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	webhookBody   = `{"confirmed":true,"block":{"number":"1"}}`
+	webhookSecret = "s3cret"
+	// keccak256(webhookBody ‖ webhookSecret)
+	webhookSignature = "0xc126e478b120247358a3936f072a60b5a252725674373ffe4e371bd5d5fab258"
+)
+
+func TestVerifySignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		secret    string
+		want      bool
+	}{
+		{"valid", webhookBody, webhookSignature, webhookSecret, true},
+		{"upper case hex", webhookBody, "0x" + strings.ToUpper(webhookSignature[2:]), webhookSecret, true},
+		{"missing 0x", webhookBody, webhookSignature[2:], webhookSecret, true},
+		{"surrounding space", webhookBody, " " + webhookSignature + "\n", webhookSecret, true},
+		{"body changed", webhookBody + " ", webhookSignature, webhookSecret, false},
+		{"wrong secret", webhookBody, webhookSignature, "other", false},
+		{"truncated", webhookBody, webhookSignature[:len(webhookSignature)-2], webhookSecret, false},
+		{"no signature", webhookBody, "", webhookSecret, false},
+		{"empty secret", webhookBody, webhookSignature, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature([]byte(tt.body), tt.signature, tt.secret); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"cmd/internal/stream"
+	"time"
+)
+
+// FromStream
+// Explanation -> turns a transfer seen by the node subscription into an event for the watched
+// wallet in it, the recipient's if it's watched, else the sender's. Node logs are final once
+// delivered, reorged ones (Removed) and heads give no event
+// Return -> the event, false if there's none
+func FromStream(s stream.Event, receivedAt time.Time) (Event, bool) {
+	if s.Removed {
+		return Event{}, false
+	}
+	e := Event{
+		ID:          TransferID(s.TxHash, s.LogIndex),
+		Source:      SourceNode,
+		BlockNumber: s.BlockNumber,
+		BlockTime:   s.BlockTime,
+		TxHash:      s.TxHash,
+		LogIndex:    s.LogIndex,
+		Confirmed:   true,
+		ReceivedAt:  receivedAt.UTC(),
+	}
+
+	switch {
+	case s.NFT != nil && s.NFT.Direction == "receive":
+		e.Type, e.Wallet, e.NFT = TypeNFTIn, s.NFT.ToAddress, s.NFT
+	case s.NFT != nil && s.NFT.Direction == "send":
+		e.Type, e.Wallet, e.NFT = TypeNFTOut, s.NFT.FromAddress, s.NFT
+	case s.ERC20 != nil && s.ERC20.Direction == "receive":
+		e.Type, e.Wallet, e.ERC20 = TypeTokenTransfer, s.ERC20.ToAddress, s.ERC20
+	case s.ERC20 != nil && s.ERC20.Direction == "send":
+		e.Type, e.Wallet, e.ERC20 = TypeTokenTransfer, s.ERC20.FromAddress, s.ERC20
+	default: // a head, or a transfer with nothing watched
+		return Event{}, false
+	}
+	return e, true
+}
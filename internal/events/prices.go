@@ -0,0 +1,97 @@
+package events
+
+import (
+	"cmd/internal/price"
+	"cmd/pkg/decimal"
+	"cmd/pkg/logger"
+	"context"
+	"strconv"
+	"time"
+)
+
+// hundred turns a ratio into a percentage
+var hundred = decimal.FromInt(100)
+
+// PriceChange is what a price_change event carries
+type PriceChange struct {
+	Symbol    string          `json:"symbol"`
+	USD       decimal.Decimal `json:"usd"`
+	Previous  decimal.Decimal `json:"previous_usd"` // the price last published for the symbol
+	ChangePct string          `json:"change_pct"`   // signed, 2 places, e.g. "-3.20"
+}
+
+// PriceWatchOptions configures WatchPrices
+type PriceWatchOptions struct {
+	Symbols   []string        // tokens to follow, price.Symbols if empty
+	Interval  time.Duration   // how often prices are read
+	MinChange decimal.Decimal // percent a price has to move, either way, to be published
+}
+
+// WatchPrices
+// Explanation -> reads the USD price of every symbol each interval and publishes a price_change
+// to hub when one has moved at least MinChange percent since it was last published. The
+// first read of a symbol only sets its baseline, so a restart doesn't announce every price
+// Return -> nothing, runs until ctx is done. Failed reads are logged and retried next interval
+func WatchPrices(ctx context.Context, hub *Hub, p price.Provider, opts PriceWatchOptions, log *logger.Logger) {
+	log = log.WithGroup("prices")
+	symbols := opts.Symbols
+	if len(symbols) == 0 {
+		symbols = price.Symbols
+	}
+	last := make(map[string]decimal.Decimal, len(symbols))
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		for _, sym := range symbols {
+			usd, err := p.USDPrice(ctx, sym)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.WarnContext(ctx, "Price read failed",
+					"error", err,
+					"symbol", sym,
+				)
+				continue
+			}
+
+			prev, seen := last[sym]
+			if !seen || prev.IsZero() {
+				last[sym] = usd
+				continue
+			}
+			ratio, _ := usd.Sub(prev).Div(prev)
+			pct := ratio.Mul(hundred)
+			move := pct
+			if move.Sign() < 0 {
+				move = move.Neg()
+			}
+			if move.Cmp(opts.MinChange) < 0 {
+				continue
+			}
+
+			last[sym] = usd
+			now := time.Now().UTC()
+			hub.Publish(Event{
+				ID:         "price:" + sym + ":" + strconv.FormatInt(now.UnixNano(), 10),
+				Type:       TypePriceChange,
+				Source:     SourcePrices,
+				Confirmed:  true,
+				ReceivedAt: now,
+				Price: &PriceChange{
+					Symbol:    sym,
+					USD:       usd,
+					Previous:  prev,
+					ChangePct: pct.StringFixed(2),
+				},
+			})
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
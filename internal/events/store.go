@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // pure Go driver, the CLI builds without cgo
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS events (
+	id           TEXT PRIMARY KEY,
+	type         TEXT NOT NULL,
+	wallet       TEXT NOT NULL,
+	source       TEXT NOT NULL,
+	block_number INTEGER NOT NULL,
+	confirmed    INTEGER NOT NULL,
+	received_at  TEXT NOT NULL,
+	payload      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS events_wallet ON events (wallet COLLATE NOCASE, received_at);
+CREATE INDEX IF NOT EXISTS events_received ON events (received_at);
+`
+
+// Store struct keeps every event once, in a SQLite file
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore
+// Explanation -> opens (creating if needed) the events database at path
+// Return -> the store, Close it when done
+func OpenStore(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("opening events store: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening events store: %w", err)
+	}
+	db.SetMaxOpenConns(1) // one writer, and PRAGMAs apply per connection
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", schema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("opening events store %s: %w", path, err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Insert
+// Explanation -> stores e unless an event with its id is already there. A repeat that is
+// confirmed marks the stored one confirmed, Moralis sends every block twice
+// Return -> true if e was new
+func (s *Store) Insert(ctx context.Context, e Event) (bool, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return false, fmt.Errorf("encoding event %s: %w", e.ID, err)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO events (id, type, wallet, source, block_number, confirmed, received_at, payload)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Type, e.Wallet, e.Source, int64(e.BlockNumber), e.Confirmed,
+		e.ReceivedAt.UTC().Format(time.RFC3339Nano), string(payload),
+	)
+	if err != nil {
+		return false, fmt.Errorf("storing event %s: %w", e.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return true, nil
+	}
+
+	if e.Confirmed {
+		if _, err := s.db.ExecContext(ctx, `UPDATE events SET confirmed = 1 WHERE id = ? AND confirmed = 0`, e.ID); err != nil {
+			return false, fmt.Errorf("confirming event %s: %w", e.ID, err)
+		}
+	}
+	return false, nil
+}
+
+// Recent
+// Explanation -> the latest events, for one wallet (any case) or all with wallet ""
+// Return -> up to limit events, newest first
+func (s *Store) Recent(ctx context.Context, wallet string, limit int) ([]Event, error) {
+	query := `SELECT payload, confirmed FROM events`
+	var args []any
+	if wallet != "" {
+		query += ` WHERE wallet = ? COLLATE NOCASE`
+		args = append(args, wallet)
+	}
+	query += ` ORDER BY received_at DESC, block_number DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("reading events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Event
+	for rows.Next() {
+		var payload string
+		var confirmed bool
+		if err := rows.Scan(&payload, &confirmed); err != nil {
+			return nil, fmt.Errorf("reading events: %w", err)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(payload), &e); err != nil {
+			return nil, fmt.Errorf("decoding stored event: %w", err)
+		}
+		e.Confirmed = confirmed
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreInsertOnce(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	e := Event{
+		ID:          TransferID("0xabc", 3),
+		Type:        TypeNFTIn,
+		Wallet:      "0x1111111111111111111111111111111111111111",
+		Source:      SourceMoralisStreams,
+		BlockNumber: 10,
+		ReceivedAt:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if inserted, err := store.Insert(ctx, e); err != nil || !inserted {
+		t.Fatalf("first Insert = %v, %v, want new", inserted, err)
+	}
+	// Moralis delivers the block again once it's confirmed
+	e.Confirmed = true
+	if inserted, err := store.Insert(ctx, e); err != nil || inserted {
+		t.Fatalf("repeat Insert = %v, %v, want not new", inserted, err)
+	}
+
+	got, err := store.Recent(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(got) != 1 || !got[0].Confirmed {
+		t.Errorf("stored = %+v, want the event once, confirmed", got)
+	}
+}
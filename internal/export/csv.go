@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// csvSink writes one table with a header row
+type csvSink struct {
+	file   *os.File
+	w      *csv.Writer
+	cols   []Column
+	opened bool
+}
+
+func newCSVSink(f *os.File) *csvSink {
+	return &csvSink{file: f, w: csv.NewWriter(f)}
+}
+
+func (s *csvSink) Table(name string, cols []Column) (TableWriter, error) {
+	if s.opened {
+		return nil, fmt.Errorf("%w: csv, can't add %s", ErrOneTable, name)
+	}
+	s.opened = true
+	s.cols = cols
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	if err := s.w.Write(header); err != nil {
+		return nil, fmt.Errorf("writing csv: %w", err)
+	}
+	return s, nil
+}
+
+func (s *csvSink) Write(values []any) error {
+	if err := checkRow(s.cols, values); err != nil {
+		return err
+	}
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v)
+	}
+	if err := s.w.Write(record); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+func (s *csvSink) Close() error {
+	s.w.Flush()
+	if err := s.w.Error(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return s.file.Close()
+}
+
+// formatValue renders a cell as text, nil is an empty cell
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,121 @@
+// Package export writes NFTs and wallet history to files analysts can open without touching
+// JSON: CSV, XLSX, Parquet and standalone SQLite databases.
+//
+// Every format goes through the same Sink, so a table has the same columns, in the same order,
+// whatever it's written as. Rows are written as they come, paginated results stream straight
+// to disk.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Formats
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatParquet = "parquet"
+	FormatSQLite  = "sqlite"
+)
+
+// Formats lists the supported formats, for help text
+var Formats = []string{FormatCSV, FormatXLSX, FormatParquet, FormatSQLite}
+
+// ErrOneTable is returned when a second table is started on a format that holds one per file
+var ErrOneTable = errors.New("format holds a single table per file")
+
+// Kind is a column's type, every format maps it to its closest native type
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindFloat
+	KindBool
+)
+
+// Column is one column of a table
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Sink is an export file, one or more tables written one after the other
+type Sink interface {
+	// Table starts a table, ending the previous one. Values passed to the returned writer's
+	// Write must line up with cols: string, int64, float64, bool, or nil for empty
+	Table(name string, cols []Column) (TableWriter, error)
+	// Close ends the last table and finishes the file
+	Close() error
+}
+
+// TableWriter writes the rows of one table
+type TableWriter interface {
+	Write(values []any) error
+}
+
+// ParseFormat checks a format name, "sqlite3" and "db" are taken for sqlite
+func ParseFormat(s string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(s)); f {
+	case FormatCSV, FormatXLSX, FormatParquet, FormatSQLite:
+		return f, nil
+	case "sqlite3", "db":
+		return FormatSQLite, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, want one of %s", s, strings.Join(Formats, ", "))
+	}
+}
+
+// FormatFromPath guesses the format from a file extension, "" if it can't
+func FormatFromPath(path string) string {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return ""
+	}
+	f, err := ParseFormat(path[i+1:])
+	if err != nil {
+		return ""
+	}
+	return f
+}
+
+// Create
+// Explanation -> creates the export file at path, replacing it if it exists
+// Return -> the sink, close it to finish the file
+func Create(format, path string) (Sink, error) {
+	switch format {
+	case FormatCSV:
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("creating export: %w", err)
+		}
+		return newCSVSink(f), nil
+	case FormatXLSX:
+		return newXLSXSink(path), nil
+	case FormatParquet:
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("creating export: %w", err)
+		}
+		return newParquetSink(f), nil
+	case FormatSQLite:
+		// a database left from an earlier export would get our tables added to its own
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("replacing export: %w", err)
+		}
+		return newSQLiteSink(path)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// checkRow guards writers against rows that don't match their columns
+func checkRow(cols []Column, values []any) error {
+	if len(values) != len(cols) {
+		return fmt.Errorf("row has %d values, table has %d columns", len(values), len(cols))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"cmd/internal/models"
+	"cmd/pkg/decimal"
+	"strings"
+)
+
+// Table names for wallet history, transfers has one row per NFT or token transfer
+const (
+	TableTransactions = "transactions"
+	TableTransfers    = "transfers"
+)
+
+// Transfer kinds in the transfers table
+const (
+	TransferNFT   = "nft"
+	TransferERC20 = "erc20"
+)
+
+// amounts are text, exact to the last wei, spreadsheets would round them through a float
+
+// TransactionColumns are the transactions table columns, in order
+var TransactionColumns = []Column{
+	{"hash", KindString},
+	{"block_number", KindInt},
+	{"block_timestamp", KindString},
+	{"from_address", KindString},
+	{"to_address", KindString},
+	{"value_ron", KindString},
+	{"transaction_fee_ron", KindString},
+	{"gas_price", KindString},
+	{"gas_used", KindInt},
+	{"status", KindInt}, // 1 success, 0 failed
+	{"method_label", KindString},
+	{"category", KindString},
+	{"summary", KindString},
+	{"possible_spam", KindBool},
+	{"nft_transfers", KindInt},
+	{"erc20_transfers", KindInt},
+}
+
+// TransferColumns are the transfers table columns, in order
+var TransferColumns = []Column{
+	{"hash", KindString},
+	{"log_index", KindInt},
+	{"block_timestamp", KindString},
+	{"kind", KindString},
+	{"direction", KindString},
+	{"token_address", KindString},
+	{"token_id", KindString}, // nft only
+	{"token_symbol", KindString},
+	{"from_address", KindString},
+	{"to_address", KindString},
+	{"amount", KindString}, // NFT count, or the token amount in whole tokens
+	{"contract_type", KindString},
+	{"possible_spam", KindBool},
+}
+
+// TransactionRow lays a transaction out in TransactionColumns
+func TransactionRow(tx models.Transaction) []any {
+	return []any{
+		tx.Hash,
+		integer(tx.BlockNumber),
+		text(tx.BlockTimestamp),
+		text(tx.FromAddress),
+		text(tx.ToAddress),
+		units(tx.Value, 18),
+		text(tx.TransactionFee),
+		text(tx.GasPrice),
+		integer(tx.ReceiptGasUsed),
+		integer(tx.ReceiptStatus),
+		text(deref(tx.MethodLabel)),
+		text(tx.Category),
+		text(tx.Summary),
+		tx.PossibleSpam,
+		int64(len(tx.NFTTransfers)),
+		int64(len(tx.ERC20Transfers)),
+	}
+}
+
+// TransferRows lays out a transaction's NFT and token transfers in TransferColumns
+func TransferRows(tx models.Transaction) [][]any {
+	rows := make([][]any, 0, len(tx.NFTTransfers)+len(tx.ERC20Transfers))
+	for _, t := range tx.NFTTransfers {
+		rows = append(rows, []any{
+			tx.Hash,
+			int64(t.LogIndex),
+			text(tx.BlockTimestamp),
+			TransferNFT,
+			text(t.Direction),
+			text(t.TokenAddress),
+			text(t.TokenID),
+			nil,
+			text(t.FromAddress),
+			text(t.ToAddress),
+			text(t.Amount),
+			text(t.ContractType),
+			t.PossibleSpam,
+		})
+	}
+	for _, t := range tx.ERC20Transfers {
+		amount := text(t.ValueFormatted)
+		if amount == nil {
+			amount = text(t.Value)
+		}
+		rows = append(rows, []any{
+			tx.Hash,
+			int64(t.LogIndex),
+			text(tx.BlockTimestamp),
+			TransferERC20,
+			text(t.Direction),
+			text(t.Address),
+			nil,
+			text(t.TokenSymbol),
+			text(t.FromAddress),
+			text(t.ToAddress),
+			amount,
+			nil,
+			t.PossibleSpam,
+		})
+	}
+	return rows
+}
+
+// units converts an integer amount in the smallest unit to whole tokens, as exact text
+func units(s string, decimals int) any {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	d, err := decimal.FromUnits(s, decimals)
+	if err != nil {
+		return nil
+	}
+	return d.String()
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
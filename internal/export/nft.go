@@ -0,0 +1,233 @@
+package export
+
+import (
+	"cmd/internal/models"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TableNFTs is the table name NFTs are written under
+const TableNFTs = "nfts"
+
+// traitPrefix starts the column of each flattened attribute
+const traitPrefix = "attr_"
+
+// nftColumns are the fixed NFT columns, in order. New columns go at the end so files made
+// by older versions line up with newer ones
+var nftColumns = []Column{
+	{"token_address", KindString},
+	{"token_id", KindString},
+	{"contract_type", KindString},
+	{"amount", KindInt},
+	{"collection", KindString},
+	{"label", KindString},
+	{"category", KindString},
+	{"token_name", KindString},
+	{"description", KindString},
+	{"symbol", KindString},
+	{"owner", KindString},
+	{"owner_name", KindString},
+	{"image", KindString},
+	{"token_uri", KindString},
+	{"rarity_rank", KindInt},
+	{"rarity_source", KindString},
+	{"rarity_label", KindString},
+	{"floor_price", KindFloat},
+	{"floor_price_currency", KindString},
+	{"floor_price_usd", KindFloat},
+	{"verified", KindBool},
+	{"possible_spam", KindBool},
+	{"block_number", KindInt},
+	{"block_number_minted", KindInt},
+	{"last_metadata_sync", KindString},
+	{"attributes", KindString}, // every attribute as JSON, including traits without a column
+}
+
+// NFTSchema is the NFT table for a set of traits: the fixed columns, then one attr_ column
+// per trait, sorted so the same traits always give the same columns
+type NFTSchema struct {
+	Columns []Column
+	traits  []string // trait name of each attr_ column, in column order
+}
+
+// NewNFTSchema builds the schema, traits can come from Traits or the user
+func NewNFTSchema(traits []string) *NFTSchema {
+	sorted := append([]string(nil), traits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i]) < strings.ToLower(sorted[j])
+	})
+
+	s := &NFTSchema{Columns: append([]Column(nil), nftColumns...)}
+	used := make(map[string]bool, len(s.Columns)+len(sorted))
+	for _, c := range s.Columns {
+		used[c.Name] = true
+	}
+	seen := make(map[string]bool)
+	for _, trait := range sorted {
+		key := strings.ToLower(strings.TrimSpace(trait))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// traits that clean up to the same name, e.g. "Eye Color" and "eye_color", get a suffix
+		name := traitPrefix + columnName(trait)
+		for n := 2; used[name]; n++ {
+			name = traitPrefix + columnName(trait) + "_" + strconv.Itoa(n)
+		}
+		used[name] = true
+
+		s.Columns = append(s.Columns, Column{Name: name, Kind: KindString})
+		s.traits = append(s.traits, trait)
+	}
+	return s
+}
+
+// Traits collects the attribute names used across nfts
+func Traits(nfts []models.NFT) []string {
+	seen := make(map[string]bool)
+	var traits []string
+	for _, nft := range nfts {
+		for name := range nft.Attributes {
+			if !seen[name] {
+				seen[name] = true
+				traits = append(traits, name)
+			}
+		}
+	}
+	sort.Strings(traits)
+	return traits
+}
+
+// Row lays an NFT out in the schema's columns
+func (s *NFTSchema) Row(nft models.NFT) []any {
+	var collection string
+	if nft.Collection != nil {
+		collection = nft.Collection.Name
+	}
+	if collection == "" {
+		collection = nft.Name
+	}
+
+	var attrs any
+	if len(nft.Attributes) > 0 {
+		if data, err := json.Marshal(nft.Attributes); err == nil {
+			attrs = string(data)
+		}
+	}
+
+	row := []any{
+		nft.TokenAddress,
+		nft.TokenID,
+		text(nft.ContractType),
+		integer(nft.Amount),
+		text(collection),
+		text(nft.Label),
+		text(nft.Category),
+		text(nft.TokenName),
+		text(nft.Description),
+		text(nft.Symbol),
+		text(nft.OwnerOf),
+		text(nft.OwnerName),
+		text(nft.Image),
+		text(nft.TokenURI),
+		intPtr(nft.RarityRank),
+		text(nft.RaritySource),
+		text(nft.RarityLabel),
+		float(nft.FloorPrice),
+		text(nft.FloorPriceCurrency),
+		float(nft.FloorPriceUSD),
+		nft.IsVerified,
+		nft.PossibleSpam,
+		integer(nft.BlockNumber),
+		integer(nft.BlockNumberMinted),
+		text(nft.LastMetadataSync),
+		attrs,
+	}
+
+	for _, trait := range s.traits {
+		row = append(row, attributeValue(nft.Attributes, trait))
+	}
+	return row
+}
+
+// attributeValue finds a trait case-insensitively, Moralis isn't consistent across tokens
+func attributeValue(attrs map[string]any, trait string) any {
+	v, ok := attrs[trait]
+	if !ok {
+		for name, value := range attrs {
+			if strings.EqualFold(name, trait) {
+				v, ok = value, true
+				break
+			}
+		}
+	}
+	if !ok || v == nil {
+		return nil
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// columnName turns a trait name into a column name: lowercase letters, digits and _
+func columnName(s string) string {
+	var b strings.Builder
+	lastUnderscore := true // no leading _
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastUnderscore = false
+		case !lastUnderscore:
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+	if name == "" {
+		return "trait"
+	}
+	return name
+}
+
+// text is nil for "", so empty cells are nulls in parquet and sqlite
+func text(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// integer parses a decimal API string, nil if it's empty or not a whole number that fits
+func integer(s string) any {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return n
+}
+
+// float parses a decimal API string, nil if it's empty or not a number
+func float(s string) any {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+func intPtr(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return int64(*n)
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rowsPerRowGroup bounds what the writer buffers before writing a row group out
+const rowsPerRowGroup = 50_000
+
+// parquetSink writes one table, every column optional so empty cells are nulls
+type parquetSink struct {
+	file    *os.File
+	writer  *parquet.Writer
+	cols    []Column
+	indexes []int // leaf column index of each of cols, parquet orders columns by name
+	row     parquet.Row
+}
+
+func newParquetSink(f *os.File) *parquetSink {
+	return &parquetSink{file: f}
+}
+
+func (s *parquetSink) Table(name string, cols []Column) (TableWriter, error) {
+	if s.writer != nil {
+		return nil, fmt.Errorf("%w: parquet, can't add %s", ErrOneTable, name)
+	}
+
+	group := make(parquet.Group, len(cols))
+	for _, c := range cols {
+		group[c.Name] = parquet.Optional(parquetNode(c.Kind))
+	}
+	schema := parquet.NewSchema(name, group)
+
+	indexes := make([]int, len(cols))
+	for i, c := range cols {
+		leaf, ok := schema.Lookup(c.Name)
+		if !ok {
+			return nil, fmt.Errorf("writing parquet: column %s missing from schema", c.Name)
+		}
+		indexes[i] = leaf.ColumnIndex
+	}
+
+	s.writer = parquet.NewWriter(s.file, schema,
+		parquet.Compression(&parquet.Snappy),
+		parquet.MaxRowsPerRowGroup(rowsPerRowGroup),
+	)
+	s.cols, s.indexes = cols, indexes
+	s.row = make(parquet.Row, len(cols))
+	return s, nil
+}
+
+func (s *parquetSink) Write(values []any) error {
+	if err := checkRow(s.cols, values); err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		col := s.indexes[i]
+		value, err := parquetValue(s.cols[i].Kind, v)
+		if err != nil {
+			return fmt.Errorf("writing parquet column %s: %w", s.cols[i].Name, err)
+		}
+		if value.IsNull() {
+			s.row[col] = value.Level(0, 0, col)
+		} else {
+			s.row[col] = value.Level(0, 1, col)
+		}
+	}
+
+	if _, err := s.writer.WriteRows([]parquet.Row{s.row}); err != nil {
+		return fmt.Errorf("writing parquet: %w", err)
+	}
+	return nil
+}
+
+func (s *parquetSink) Close() error {
+	if s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			s.file.Close()
+			return fmt.Errorf("writing parquet: %w", err)
+		}
+	}
+	return s.file.Close()
+}
+
+func parquetNode(k Kind) parquet.Node {
+	switch k {
+	case KindInt:
+		return parquet.Int(64)
+	case KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	default:
+		return parquet.String()
+	}
+}
+
+// parquetValue converts a cell to the column's physical type, nil is null
+func parquetValue(k Kind, v any) (parquet.Value, error) {
+	if v == nil {
+		return parquet.NullValue(), nil
+	}
+	switch k {
+	case KindInt:
+		if n, ok := v.(int64); ok {
+			return parquet.Int64Value(n), nil
+		}
+	case KindFloat:
+		if f, ok := v.(float64); ok {
+			return parquet.DoubleValue(f), nil
+		}
+	case KindBool:
+		if b, ok := v.(bool); ok {
+			return parquet.BooleanValue(b), nil
+		}
+	default:
+		if str, ok := v.(string); ok {
+			return parquet.ByteArrayValue([]byte(str)), nil
+		}
+	}
+	return parquet.Value{}, fmt.Errorf("unexpected %T", v)
+}
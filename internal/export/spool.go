@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Spool holds a table's rows in a temp file until the sink can take them, for tables whose
+// rows turn up while another table is still being written. Rows are JSON lines, read back
+// to the column kinds, so replayed values are what was written
+type Spool struct {
+	cols []Column
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	rows int
+}
+
+// NewSpool
+// Explanation -> creates a spool for a table with cols, backed by a temp file
+// Return -> the spool, Close it to remove the file
+func NewSpool(cols []Column) (*Spool, error) {
+	f, err := os.CreateTemp("", "export-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool: %w", err)
+	}
+	buf := bufio.NewWriter(f)
+	return &Spool{cols: cols, file: f, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+// Write adds a row, it takes the same values a TableWriter does
+func (s *Spool) Write(values []any) error {
+	if err := checkRow(s.cols, values); err != nil {
+		return err
+	}
+	if err := s.enc.Encode(values); err != nil {
+		return fmt.Errorf("writing spool: %w", err)
+	}
+	s.rows++
+	return nil
+}
+
+// Rows is the number of rows written so far
+func (s *Spool) Rows() int {
+	return s.rows
+}
+
+// Replay
+// Explanation -> writes every spooled row to w, in the order they were written
+// Return -> the first error from reading the spool or from w
+func (s *Spool) Replay(w TableWriter) error {
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("writing spool: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading spool: %w", err)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(s.file))
+	dec.UseNumber()
+	for {
+		var values []any
+		if err := dec.Decode(&values); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("reading spool: %w", err)
+		}
+		if err := checkRow(s.cols, values); err != nil {
+			return fmt.Errorf("reading spool: %w", err)
+		}
+		for i, v := range values {
+			n, ok := v.(json.Number)
+			if !ok {
+				continue
+			}
+			var err error
+			if s.cols[i].Kind == KindInt {
+				values[i], err = n.Int64()
+			} else {
+				values[i], err = n.Float64()
+			}
+			if err != nil {
+				return fmt.Errorf("reading spool, column %s: %w", s.cols[i].Name, err)
+			}
+		}
+		if err := w.Write(values); err != nil {
+			return err
+		}
+	}
+	// further writes go after the replayed rows
+	if _, err := s.file.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("reading spool: %w", err)
+	}
+	return nil
+}
+
+// Close removes the temp file
+func (s *Spool) Close() error {
+	err := s.file.Close()
+	if rerr := os.Remove(s.file.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// rowsWriter collects what it's given
+type rowsWriter struct {
+	rows [][]any
+}
+
+func (w *rowsWriter) Write(values []any) error {
+	w.rows = append(w.rows, values)
+	return nil
+}
+
+func TestSpoolReplay(t *testing.T) {
+	cols := []Column{{"hash", KindString}, {"log_index", KindInt}, {"price", KindFloat}, {"spam", KindBool}}
+	rows := [][]any{
+		{"0x1", int64(1), 1.5, false},
+		{"0x1", int64(9007199254740993), nil, true}, // past what a float64 holds exactly
+		{nil, int64(0), 2.0, nil},
+	}
+
+	s, err := NewSpool(cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := s.file.Name()
+	for _, row := range rows {
+		if err := s.Write(row); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := s.Write([]any{"short"}); err == nil {
+		t.Error("Write took a row with too few values")
+	}
+	if s.Rows() != len(rows) {
+		t.Errorf("Rows = %d, want %d", s.Rows(), len(rows))
+	}
+
+	var w rowsWriter
+	if err := s.Replay(&w); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !reflect.DeepEqual(w.rows, rows) {
+		t.Errorf("replayed %v, want %v", w.rows, rows)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file left behind: %v", err)
+	}
+}
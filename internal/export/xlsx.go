@@ -0,0 +1,112 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSink writes one sheet per table, through excelize's stream writer so rows go to a temp
+// file rather than memory. The workbook is assembled at Close
+type xlsxSink struct {
+	path   string
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	cols   []Column
+	row    int
+	sheets int
+}
+
+func newXLSXSink(path string) *xlsxSink {
+	return &xlsxSink{path: path, file: excelize.NewFile()}
+}
+
+func (s *xlsxSink) Table(name string, cols []Column) (TableWriter, error) {
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+
+	if len(name) > excelize.MaxSheetNameLength {
+		name = name[:excelize.MaxSheetNameLength]
+	}
+	// a new workbook comes with Sheet1, the first table takes it over
+	if s.sheets == 0 {
+		if err := s.file.SetSheetName("Sheet1", name); err != nil {
+			return nil, fmt.Errorf("writing xlsx: %w", err)
+		}
+	} else if _, err := s.file.NewSheet(name); err != nil {
+		return nil, fmt.Errorf("writing xlsx: %w", err)
+	}
+	s.sheets++
+
+	stream, err := s.file.NewStreamWriter(name)
+	if err != nil {
+		return nil, fmt.Errorf("writing xlsx: %w", err)
+	}
+	// keep the header in view while scrolling
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, fmt.Errorf("writing xlsx: %w", err)
+	}
+
+	header := make([]any, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return nil, fmt.Errorf("writing xlsx: %w", err)
+	}
+
+	s.stream, s.cols, s.row = stream, cols, 1
+	return s, nil
+}
+
+func (s *xlsxSink) Write(values []any) error {
+	if err := checkRow(s.cols, values); err != nil {
+		return err
+	}
+	if s.row >= excelize.TotalRows {
+		return fmt.Errorf("writing xlsx: sheets hold at most %d rows, use csv, parquet or sqlite", excelize.TotalRows)
+	}
+	s.row++
+
+	cells := make([]any, len(values))
+	for i, v := range values {
+		// Excel refuses longer cells, descriptions are the usual culprit
+		if str, ok := v.(string); ok && len(str) > excelize.TotalCellChars {
+			v = str[:excelize.TotalCellChars]
+		}
+		cells[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, s.row)
+	if err != nil {
+		return err
+	}
+	if err := s.stream.SetRow(cell, cells); err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	return nil
+}
+
+// flush ends the current sheet, if any
+func (s *xlsxSink) flush() error {
+	if s.stream == nil {
+		return nil
+	}
+	err := s.stream.Flush()
+	s.stream = nil
+	if err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	return nil
+}
+
+func (s *xlsxSink) Close() error {
+	defer s.file.Close()
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if err := s.file.SaveAs(s.path); err != nil {
+		return fmt.Errorf("saving xlsx: %w", err)
+	}
+	return nil
+}
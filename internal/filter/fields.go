@@ -0,0 +1,287 @@
+package filter
+
+import (
+	"cmd/internal/models"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// attrPrefix starts a metadata attribute field, attr.class is the "Class" trait
+const attrPrefix = "attr."
+
+// valueKind is what a value holds
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+)
+
+// value is a field or literal value, attributes can be any of the three
+type value struct {
+	kind valueKind
+	s    string
+	n    float64
+	b    bool
+}
+
+func stringValue(s string) value  { return value{kind: kindString, s: s} }
+func numberValue(n float64) value { return value{kind: kindNumber, n: n} }
+func boolValue(b bool) value      { return value{kind: kindBool, b: b} }
+
+// number reads the value as a number, metadata often sends numbers as strings
+func (v value) number() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.n, true
+	case kindString:
+		s := strings.TrimSpace(v.s)
+		if s == "" || strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func (v value) string() string {
+	switch v.kind {
+	case kindNumber:
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	default:
+		return v.s
+	}
+}
+
+func (v value) truthy() bool {
+	switch v.kind {
+	case kindNumber:
+		return v.n != 0
+	case kindBool:
+		return v.b
+	default:
+		return strings.TrimSpace(v.s) != "" && !strings.EqualFold(strings.TrimSpace(v.s), "false")
+	}
+}
+
+// fromInterface converts an attribute value from decoded JSON
+func fromInterface(raw interface{}) (value, bool) {
+	switch v := raw.(type) {
+	case string:
+		return stringValue(v), true
+	case float64:
+		return numberValue(v), true
+	case int:
+		return numberValue(float64(v)), true
+	case bool:
+		return boolValue(v), true
+	case nil:
+		return value{}, false
+	default:
+		return stringValue(fmt.Sprint(v)), true
+	}
+}
+
+// field reads one value off an NFT
+// Return -> false when the NFT doesn't have it
+type field interface {
+	get(nft models.NFT) (value, bool)
+}
+
+type fieldFunc func(nft models.NFT) (value, bool)
+
+func (f fieldFunc) get(nft models.NFT) (value, bool) { return f(nft) }
+
+// attrField is a metadata attribute, looked up by trait name
+type attrField string
+
+// get matches the trait exactly first, then ignoring case, spaces, _ and -
+func (a attrField) get(nft models.NFT) (value, bool) {
+	if raw, ok := nft.Attributes[string(a)]; ok {
+		return fromInterface(raw)
+	}
+
+	want := NormalizeKey(string(a))
+	for key, raw := range nft.Attributes {
+		if NormalizeKey(key) == want {
+			return fromInterface(raw)
+		}
+	}
+	return value{}, false
+}
+
+// NormalizeKey is how trait names are compared: lowercased, without spaces, _ or -, so
+// "Breed Count" matches breed_count
+func NormalizeKey(key string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// str, flag and optionalInt build fields from NFT accessors
+func str(get func(models.NFT) string) field {
+	return fieldFunc(func(nft models.NFT) (value, bool) {
+		s := get(nft)
+		return stringValue(s), s != ""
+	})
+}
+
+func flag(get func(models.NFT) bool) field {
+	return fieldFunc(func(nft models.NFT) (value, bool) {
+		return boolValue(get(nft)), true
+	})
+}
+
+func optionalInt(get func(models.NFT) *int) field {
+	return fieldFunc(func(nft models.NFT) (value, bool) {
+		n := get(nft)
+		if n == nil {
+			return value{}, false
+		}
+		return numberValue(float64(*n)), true
+	})
+}
+
+// axieStat reads one of the Axie's stats, false for non-Axies
+func axieStat(get func(*models.AxieStats) int) field {
+	return fieldFunc(func(nft models.NFT) (value, bool) {
+		if nft.Axie == nil || nft.Axie.Stats == nil {
+			return value{}, false
+		}
+		return numberValue(float64(get(nft.Axie.Stats))), true
+	})
+}
+
+// fields are the NFT fields a filter can use, besides attr.*
+var fields = map[string]field{
+	"token_id":      str(func(n models.NFT) string { return n.TokenID }),
+	"token_address": str(func(n models.NFT) string { return n.TokenAddress }),
+	"name":          str(func(n models.NFT) string { return n.Name }),
+	"token_name":    str(func(n models.NFT) string { return n.TokenName }),
+	"description":   str(func(n models.NFT) string { return n.Description }),
+	"symbol":        str(func(n models.NFT) string { return n.Symbol }),
+	"contract_type": str(func(n models.NFT) string { return n.ContractType }),
+	"amount":        str(func(n models.NFT) string { return n.Amount }),
+	"owner":         str(func(n models.NFT) string { return n.OwnerOf }),
+	"owner_name":    str(func(n models.NFT) string { return n.OwnerName }),
+	"floor_price":   str(func(n models.NFT) string { return n.FloorPrice }),
+	"rarity_label":  str(func(n models.NFT) string { return n.RarityLabel }),
+	"rarity_source": str(func(n models.NFT) string { return n.RaritySource }),
+	"rarity_rank":   optionalInt(func(n models.NFT) *int { return n.RarityRank }),
+	"rarity_percentage": fieldFunc(func(n models.NFT) (value, bool) {
+		if n.RarityPercentage == nil {
+			return value{}, false
+		}
+		return numberValue(*n.RarityPercentage), true
+	}),
+
+	"label":    str(func(n models.NFT) string { return n.Label }),
+	"category": str(func(n models.NFT) string { return n.Category }),
+	"tags":     str(func(n models.NFT) string { return strings.Join(n.Tags, ",") }),
+
+	"floor_price_usd":      str(func(n models.NFT) string { return n.FloorPriceUSD }),
+	"floor_price_currency": str(func(n models.NFT) string { return n.FloorPriceCurrency }),
+
+	"verified": flag(func(n models.NFT) bool { return n.IsVerified }),
+	"spam":     flag(func(n models.NFT) bool { return n.PossibleSpam }),
+	"collection.category": str(func(n models.NFT) string {
+		if n.Collection == nil {
+			return ""
+		}
+		return n.Collection.Category
+	}),
+
+	"axie.class": str(func(n models.NFT) string {
+		if n.Axie == nil {
+			return ""
+		}
+		return n.Axie.Class
+	}),
+	"axie.purity": optionalInt(func(n models.NFT) *int {
+		if n.Axie == nil {
+			return nil
+		}
+		return n.Axie.Purity
+	}),
+	"axie.breed_count": optionalInt(func(n models.NFT) *int {
+		if n.Axie == nil {
+			return nil
+		}
+		return n.Axie.BreedCount
+	}),
+	"axie.hp":     axieStat(func(s *models.AxieStats) int { return s.HP }),
+	"axie.speed":  axieStat(func(s *models.AxieStats) int { return s.Speed }),
+	"axie.skill":  axieStat(func(s *models.AxieStats) int { return s.Skill }),
+	"axie.morale": axieStat(func(s *models.AxieStats) int { return s.Morale }),
+}
+
+// lookupField resolves an identifier to a field, case-insensitively
+func lookupField(name string) (field, error) {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, attrPrefix) && len(name) > len(attrPrefix) {
+		return attrField(name[len(attrPrefix):]), nil
+	}
+	if f, ok := fields[lower]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown field %q, expected attr.<trait> or one of %s", name, strings.Join(FieldNames(), ", "))
+}
+
+// FieldNames lists the fields a filter can use besides attr.*, sorted
+func FieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Field is a named NFT value, for code that wants the same fields expressions use (sort, group by)
+type Field struct {
+	name string
+	f    field
+}
+
+// LookupField resolves a field name as an expression would, attr.<trait> included
+func LookupField(name string) (Field, error) {
+	f, err := lookupField(strings.TrimSpace(name))
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{name: strings.TrimSpace(name), f: f}, nil
+}
+
+// Name returns the field name as given
+func (f Field) Name() string {
+	return f.name
+}
+
+// String reads the field as text
+// Return -> false if the NFT doesn't have it
+func (f Field) String(nft models.NFT) (string, bool) {
+	v, ok := f.f.get(nft)
+	if !ok {
+		return "", false
+	}
+	return v.string(), true
+}
+
+// Number reads the field as a number, numeric strings included
+// Return -> false if the NFT doesn't have it or it isn't a number
+func (f Field) Number(nft models.NFT) (float64, bool) {
+	v, ok := f.f.get(nft)
+	if !ok {
+		return 0, false
+	}
+	return v.number()
+}
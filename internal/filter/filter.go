@@ -0,0 +1,314 @@
+// Package filter implements the small expression language used to filter NFT results
+//
+//	attr.class == "Beast" && rarity_rank < 1000 && verified
+//	(axie.purity >= 5 || attr["Breed Count"] == 0) && !spam
+//
+// Operators are ==, !=, <, <=, >, >=, &&, || and !, with the usual precedence.
+// Comparisons are numeric when both sides are numbers or numeric strings,
+// otherwise == and != compare strings case-insensitively.
+// A comparison against a value the NFT doesn't have is false, a bare field is
+// true when it's set (true, non-zero, non-empty), so !attr.mystic finds the ones without.
+package filter
+
+import (
+	"cmd/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSyntax is wrapped by every parse error
+var ErrSyntax = errors.New("filter syntax error")
+
+func syntaxError(pos int, msg string) error {
+	return fmt.Errorf("%w at %d: %s", ErrSyntax, pos, msg)
+}
+
+// Expr is a parsed filter expression, safe for concurrent use
+type Expr struct {
+	source string
+	root   node
+}
+
+// Parse
+// Explanation -> parses a filter expression, unknown fields are rejected here rather than
+// silently never matching
+// Return -> nil if expr is blank, so callers can pass user input straight through
+func Parse(expr string) (*Expr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, syntaxError(t.pos, fmt.Sprintf("unexpected %q", t.text))
+	}
+
+	return &Expr{source: expr, root: root}, nil
+}
+
+// String returns the expression as it was written
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.source
+}
+
+// Match reports whether nft passes the expression, a nil expression matches everything
+func (e *Expr) Match(nft models.NFT) bool {
+	if e == nil {
+		return true
+	}
+	return e.root.eval(nft)
+}
+
+// Apply returns the NFTs that match, in order
+func (e *Expr) Apply(nfts []models.NFT) []models.NFT {
+	if e == nil {
+		return nfts
+	}
+
+	out := nfts[:0:0]
+	for _, nft := range nfts {
+		if e.Match(nft) {
+			out = append(out, nft)
+		}
+	}
+	return out
+}
+
+// node is a boolean expression
+type node interface {
+	eval(nft models.NFT) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(nft models.NFT) bool { return n.left.eval(nft) && n.right.eval(nft) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(nft models.NFT) bool { return n.left.eval(nft) || n.right.eval(nft) }
+
+type notNode struct{ inner node }
+
+func (n notNode) eval(nft models.NFT) bool { return !n.inner.eval(nft) }
+
+// truthyNode is a bare field, e.g. verified
+type truthyNode struct{ field field }
+
+func (n truthyNode) eval(nft models.NFT) bool {
+	v, ok := n.field.get(nft)
+	return ok && v.truthy()
+}
+
+// compareNode is field <op> literal
+type compareNode struct {
+	field field
+	op    tokenKind
+	lit   value
+}
+
+func (n compareNode) eval(nft models.NFT) bool {
+	v, ok := n.field.get(nft)
+	if !ok {
+		return false
+	}
+
+	if a, okA := v.number(); okA {
+		if b, okB := n.lit.number(); okB {
+			return compareOrdered(a, b, n.op)
+		}
+	}
+
+	// not both numeric, only equality makes sense
+	eq := strings.EqualFold(v.string(), n.lit.string())
+	switch n.op {
+	case tokEq:
+		return eq
+	case tokNe:
+		return !eq
+	default:
+		return false
+	}
+}
+
+func compareOrdered(a, b float64, op tokenKind) bool {
+	switch op {
+	case tokEq:
+		return a == b
+	case tokNe:
+		return a != b
+	case tokLt:
+		return a < b
+	case tokLe:
+		return a <= b
+	case tokGt:
+		return a > b
+	case tokGe:
+		return a >= b
+	}
+	return false
+}
+
+// parser is a recursive descent parser over the lexed tokens
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | primary
+//	primary = "(" or ")" | field [ cmp literal ]
+//	field   = ident | "attr" "[" string "]"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, syntaxError(closing.pos, "missing )")
+		}
+		return inner, nil
+
+	case tokIdent:
+		f, err := p.parseField(t)
+		if err != nil {
+			return nil, err
+		}
+
+		op := p.peek()
+		switch op.kind {
+		case tokEq, tokNe, tokLt, tokLe, tokGt, tokGe:
+			p.next()
+		default:
+			return truthyNode{f}, nil
+		}
+
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{field: f, op: op.kind, lit: lit}, nil
+
+	case tokEOF:
+		return nil, syntaxError(t.pos, "unexpected end of expression")
+	default:
+		return nil, syntaxError(t.pos, fmt.Sprintf("expected a field, got %q", t.text))
+	}
+}
+
+// parseField resolves an identifier, attr["Trait Name"] takes traits with spaces in them
+func (p *parser) parseField(t token) (field, error) {
+	name := t.text
+	if strings.EqualFold(name, attrPrefix[:len(attrPrefix)-1]) && p.peek().kind == tokLBrack {
+		p.next()
+		key := p.next()
+		if key.kind != tokString {
+			return nil, syntaxError(key.pos, `expected a quoted trait name, e.g. attr["Breed Count"]`)
+		}
+		if closing := p.next(); closing.kind != tokRBrack {
+			return nil, syntaxError(closing.pos, "missing ]")
+		}
+		return attrField(key.text), nil
+	}
+
+	f, err := lookupField(name)
+	if err != nil {
+		return nil, syntaxError(t.pos, err.Error())
+	}
+	return f, nil
+}
+
+func (p *parser) parseLiteral() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return stringValue(t.text), nil
+	case tokNumber:
+		v := stringValue(t.text)
+		if _, ok := v.number(); !ok {
+			return value{}, syntaxError(t.pos, fmt.Sprintf("bad number %q", t.text))
+		}
+		return v, nil
+	case tokTrue:
+		return boolValue(true), nil
+	case tokFalse:
+		return boolValue(false), nil
+	case tokIdent:
+		return value{}, syntaxError(t.pos, fmt.Sprintf("expected a value, strings need quotes: %q", t.text))
+	default:
+		return value{}, syntaxError(t.pos, "expected a value")
+	}
+}
@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"cmd/internal/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func intp(n int) *int { return &n }
+
+var (
+	beast = models.NFT{
+		TokenID:    "1",
+		Name:       "Axie",
+		IsVerified: true,
+		RarityRank: intp(120),
+		Attributes: map[string]interface{}{"Class": "Beast", "Breed Count": 0.0, "mystic": "2", "hp": "41"},
+		Axie:       &models.AxieInfo{Class: "Beast", Purity: intp(6)},
+	}
+	spamToken = models.NFT{
+		TokenID:      "2",
+		Name:         "Free Claim",
+		PossibleSpam: true,
+		Attributes:   map[string]interface{}{"Class": "Plant"},
+	}
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr  string
+		beast bool
+		spam  bool
+	}{
+		// && binds tighter than ||, both left to right
+		{`verified || spam && name == "none"`, true, false},
+		{`(verified || spam) && name == "none"`, false, false},
+		{`spam || verified && rarity_rank < 200`, true, true},
+		{`spam && !verified || verified`, true, true},
+		// ! binds tighter than &&
+		{`!verified && !spam`, false, false},
+		{`!(verified && spam)`, true, true},
+		{`!!verified`, true, false},
+
+		// numeric compares, numeric strings included
+		{`rarity_rank < 1000`, true, false},
+		{`rarity_rank >= 120 && rarity_rank <= 120`, true, false},
+		{`attr.hp > 40`, true, false},
+		{`attr["Breed Count"] == 0`, true, false},
+		{`attr.breed_count = 0`, true, false},
+		{`axie.purity >= 5`, true, false},
+
+		// string equality ignores case, a missing value never compares true
+		{`attr.class == "beast"`, true, false},
+		{`attr.CLASS != 'Beast'`, false, true},
+		{`rarity_rank != 5`, true, false},
+		{`name > "A"`, false, false},
+
+		// bare fields are set or not
+		{`attr.mystic`, true, false},
+		{`!attr.mystic`, false, true},
+		{`axie.class`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := e.Match(beast); got != tt.beast {
+				t.Errorf("Match(beast) = %v, want %v", got, tt.beast)
+			}
+			if got := e.Match(spamToken); got != tt.spam {
+				t.Errorf("Match(spam) = %v, want %v", got, tt.spam)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  string // "at N" in the message
+	}{
+		{`verified &&`, "at 11"},
+		{`(verified`, "at 9"},
+		{`verified)`, "at 8"},
+		{`verified spam`, "at 9"},
+		{`rarity_rank <`, "at 13"},
+		{`rarity_rank < high`, "at 14"},
+		{`rarity_rank < 1.2.3`, "at 14"},
+		{`colour == "red"`, "at 0"},
+		{`attr[class] == 1`, "at 5"},
+		{`attr["class" == 1`, "at 13"},
+		{`== 1`, "at 0"},
+		{`!`, "at 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse = %v, %v, want an ErrSyntax", e, err)
+			}
+			if !strings.Contains(err.Error(), tt.pos) {
+				t.Errorf("error %q, want it %s", err, tt.pos)
+			}
+		})
+	}
+}
+
+func TestBlankExpression(t *testing.T) {
+	e, err := Parse("  ")
+	if err != nil || e != nil {
+		t.Fatalf("Parse(blank) = %v, %v, want nil, nil", e, err)
+	}
+	if !e.Match(spamToken) || e.String() != "" {
+		t.Error("a nil expression should match everything")
+	}
+	nfts := []models.NFT{beast, spamToken}
+	if got := e.Apply(nfts); len(got) != 2 {
+		t.Errorf("Apply = %d NFTs, want 2", len(got))
+	}
+
+	e, _ = Parse("!spam")
+	if got := e.Apply(nfts); len(got) != 1 || got[0].TokenID != "1" {
+		t.Errorf("Apply(!spam) = %+v", got)
+	}
+}
@@ -0,0 +1,172 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind is the kind of a lexed token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokTrue
+	tokFalse
+	tokAnd    // &&
+	tokOr     // ||
+	tokNot    // !
+	tokLParen // (
+	tokRParen // )
+	tokLBrack // [
+	tokRBrack // ]
+	tokEq     // ==
+	tokNe     // !=
+	tokLt     // <
+	tokLe     // <=
+	tokGt     // >
+	tokGe     // >=
+)
+
+type token struct {
+	kind tokenKind
+	text string // identifier name, unquoted string or number as written
+	pos  int    // byte offset in the expression, for error messages
+}
+
+// lex splits an expression into tokens, ending with tokEOF
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			s, n, err := lexString(expr[i:])
+			if err != nil {
+				return nil, syntaxError(i, err.Error())
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: i})
+			i += n
+
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			start := i
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: expr[start:i], pos: start})
+
+		case isIdentStart(rune(c)):
+			start := i
+			for i < len(expr) && isIdentPart(rune(expr[i])) {
+				i++
+			}
+			word := expr[start:i]
+			kind := tokIdent
+			switch strings.ToLower(word) {
+			case "true":
+				kind = tokTrue
+			case "false":
+				kind = tokFalse
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start})
+
+		default:
+			kind, n := lexOperator(expr[i:])
+			if n == 0 {
+				return nil, syntaxError(i, fmt.Sprintf("unexpected %q", c))
+			}
+			tokens = append(tokens, token{kind: kind, text: expr[i : i+n], pos: i})
+			i += n
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+// lexOperator matches the longest operator at the start of s
+// Return -> the kind and its length, 0 if there's no operator
+func lexOperator(s string) (tokenKind, int) {
+	if len(s) >= 2 {
+		switch s[:2] {
+		case "&&":
+			return tokAnd, 2
+		case "||":
+			return tokOr, 2
+		case "==":
+			return tokEq, 2
+		case "!=":
+			return tokNe, 2
+		case "<=":
+			return tokLe, 2
+		case ">=":
+			return tokGe, 2
+		}
+	}
+
+	switch s[0] {
+	case '!':
+		return tokNot, 1
+	case '(':
+		return tokLParen, 1
+	case ')':
+		return tokRParen, 1
+	case '[':
+		return tokLBrack, 1
+	case ']':
+		return tokRBrack, 1
+	case '<':
+		return tokLt, 1
+	case '>':
+		return tokGt, 1
+	case '=':
+		// a single = is almost certainly meant as ==
+		return tokEq, 1
+	}
+	return tokEOF, 0
+}
+
+// lexString reads a quoted string, single or double quoted, with Go escapes
+// Return -> the unquoted value and how many bytes the literal took
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			lit := s[:i+1]
+			if quote == '\'' {
+				// strconv only does single quotes for runes, swap to double
+				lit = `"` + strings.ReplaceAll(strings.ReplaceAll(lit[1:i], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			v, err := strconv.Unquote(lit)
+			if err != nil {
+				return "", 0, fmt.Errorf("bad string literal %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// identifiers may contain dots, attr.class is lexed as one identifier
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
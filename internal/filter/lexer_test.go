@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		expr  string
+		kinds []tokenKind
+		texts []string
+	}{
+		{
+			expr:  `attr.class == "Beast" && rarity_rank <= 10`,
+			kinds: []tokenKind{tokIdent, tokEq, tokString, tokAnd, tokIdent, tokLe, tokNumber, tokEOF},
+			texts: []string{"attr.class", "==", "Beast", "&&", "rarity_rank", "<=", "10", ""},
+		},
+		{
+			expr:  `!(spam||verified)`,
+			kinds: []tokenKind{tokNot, tokLParen, tokIdent, tokOr, tokIdent, tokRParen, tokEOF},
+			texts: []string{"!", "(", "spam", "||", "verified", ")", ""},
+		},
+		{
+			expr:  `attr['Breed Count'] != -1.5`,
+			kinds: []tokenKind{tokIdent, tokLBrack, tokString, tokRBrack, tokNe, tokNumber, tokEOF},
+			texts: []string{"attr", "[", "Breed Count", "]", "!=", "-1.5", ""},
+		},
+		{
+			expr:  `a>=1 b<2 c>3 d=TRUE e==False`,
+			kinds: []tokenKind{tokIdent, tokGe, tokNumber, tokIdent, tokLt, tokNumber, tokIdent, tokGt, tokNumber, tokIdent, tokEq, tokTrue, tokIdent, tokEq, tokFalse, tokEOF},
+		},
+		{
+			expr:  `"say \"hi\"" 'it\'s'`,
+			kinds: []tokenKind{tokString, tokString, tokEOF},
+			texts: []string{`say "hi"`, "it's", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			tokens, err := lex(tt.expr)
+			if err != nil {
+				t.Fatalf("lex: %v", err)
+			}
+			if len(tokens) != len(tt.kinds) {
+				t.Fatalf("tokens = %+v, want %d", tokens, len(tt.kinds))
+			}
+			for i, tok := range tokens {
+				if tok.kind != tt.kinds[i] {
+					t.Errorf("token %d %q kind = %d, want %d", i, tok.text, tok.kind, tt.kinds[i])
+				}
+				if tt.texts != nil && tok.text != tt.texts[i] {
+					t.Errorf("token %d text = %q, want %q", i, tok.text, tt.texts[i])
+				}
+			}
+			if last := tokens[len(tokens)-1]; last.pos != len(tt.expr) {
+				t.Errorf("EOF at %d, want %d", last.pos, len(tt.expr))
+			}
+		})
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	for _, expr := range []string{`name == "open`, `name == 'open`, `a & b`, `a | b`, `price == $5`, `"bad \q escape"`} {
+		if _, err := lex(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("lex(%q) = %v, want an ErrSyntax", expr, err)
+		}
+	}
+}
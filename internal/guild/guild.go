@@ -0,0 +1,399 @@
+// Package guild keeps the guild's scholars, the Ronin accounts Axies are lent to, and which
+// team Axies each one should hold, in a JSON file shared by the CLI and the HTTP API
+package guild
+
+import (
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadEvery is how often reads check whether the file changed, e.g. edited by the CLI
+// while the server is running
+const reloadEvery = time.Second
+
+// DefaultManagerShare is the manager's cut when a scholar is added without one, percent
+const DefaultManagerShare = 30
+
+// Errors callers can check for
+var (
+	ErrNotFound      = errors.New("scholar not found")
+	ErrDuplicate     = errors.New("scholar already exists")
+	ErrInvalidShare  = errors.New("manager share must be between 0 and 100")
+	ErrInvalidAxieID = errors.New("invalid axie id")
+)
+
+// Scholar is one scholar account and the Axies assigned to it
+type Scholar struct {
+	Name          string    `json:"name"`
+	Address       string    `json:"address"`                  // the scholar account the Axies sit in, checksummed 0x
+	ManagerShare  int       `json:"manager_share"`            // percent of earnings the manager keeps
+	PayoutAddress string    `json:"payout_address,omitempty"` // the scholar's own wallet, where their share is paid
+	Axies         []string  `json:"axies"`                    // assigned Axie token ids, sorted
+	AddedAt       time.Time `json:"added_at"`
+}
+
+// file is the guild file's layout, an object so it can grow
+type file struct {
+	Scholars []Scholar `json:"scholars"`
+}
+
+// Store struct is the guild file, safe for concurrent use
+type Store struct {
+	path string
+
+	mu        sync.Mutex
+	scholars  map[address.Address]Scholar
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// Load
+// Explanation -> reads the guild file, a missing file is a guild with no scholars
+// Return -> the store, an error for unreadable files or bad entries in them
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, scholars: make(map[address.Address]Scholar)}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload reads the file if it changed since the last read, caller holds mu (or owns s)
+func (s *Store) reload() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.scholars = make(map[address.Address]Scholar)
+		s.modTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading guild: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading guild: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parsing guild %s: %w", s.path, err)
+	}
+
+	scholars := make(map[address.Address]Scholar, len(f.Scholars))
+	for _, sc := range f.Scholars {
+		addr, err := address.Parse(sc.Address)
+		if err != nil {
+			return fmt.Errorf("guild %s, scholar %s: %w", s.path, sc.Name, err)
+		}
+		// a hand edited share over 100 would pay the scholar a negative amount
+		if sc.ManagerShare < 0 || sc.ManagerShare > 100 {
+			return fmt.Errorf("guild %s, scholar %s: %w, got %d", s.path, sc.Name, ErrInvalidShare, sc.ManagerShare)
+		}
+		sc.Address = addr.Hex()
+		scholars[addr] = sc
+	}
+
+	s.scholars = scholars
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// refresh reloads at most once per reloadEvery, a failed reload keeps the old scholars
+func (s *Store) refresh() {
+	if time.Since(s.checkedAt) < reloadEvery {
+		return
+	}
+	s.checkedAt = time.Now()
+	_ = s.reload()
+}
+
+// List returns every scholar, sorted by name
+func (s *Store) List() []Scholar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+
+	list := make([]Scholar, 0, len(s.scholars))
+	for _, sc := range s.scholars {
+		list = append(list, sc)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// Get finds a scholar by name (any case) or address (any format)
+func (s *Store) Get(key string) (Scholar, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+
+	addr, ok := s.find(key)
+	if !ok {
+		return Scholar{}, false
+	}
+	return s.scholars[addr], true
+}
+
+// find resolves a name or address to the scholar's key, caller holds mu
+func (s *Store) find(key string) (address.Address, bool) {
+	if addr, err := address.Parse(key); err == nil {
+		_, ok := s.scholars[addr]
+		return addr, ok
+	}
+	key = strings.TrimSpace(key)
+	for addr, sc := range s.scholars {
+		if strings.EqualFold(sc.Name, key) {
+			return addr, true
+		}
+	}
+	return address.Address{}, false
+}
+
+// Add
+// Explanation -> adds a scholar, or updates the name and share of the one at that address,
+// keeping its Axies. A share below 0 means DefaultManagerShare
+// Return -> the scholar as stored, ErrDuplicate if another scholar has the name
+func (s *Store) Add(name, addr string, managerShare int) (Scholar, error) {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return Scholar{}, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Scholar{}, errors.New("scholar name is required")
+	}
+	if managerShare < 0 {
+		managerShare = DefaultManagerShare
+	}
+	if managerShare > 100 {
+		return Scholar{}, fmt.Errorf("%w, got %d", ErrInvalidShare, managerShare)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Scholar{}, err
+	}
+
+	// names are how people refer to scholars, they have to be unique
+	if other, ok := s.find(name); ok && other != parsed {
+		return Scholar{}, fmt.Errorf("%w: %s is %s", ErrDuplicate, name, other.Hex())
+	}
+
+	sc, ok := s.scholars[parsed]
+	if !ok {
+		sc = Scholar{Address: parsed.Hex(), Axies: []string{}, AddedAt: time.Now().UTC()}
+	}
+	sc.Name, sc.ManagerShare = name, managerShare
+	s.scholars[parsed] = sc
+	return sc, s.save()
+}
+
+// SetPayoutAddress
+// Explanation -> sets the wallet a scholar's share is paid to, "" clears it
+// Return -> the scholar as stored
+func (s *Store) SetPayoutAddress(key, payout string) (Scholar, error) {
+	if payout != "" {
+		addr, err := address.Parse(payout)
+		if err != nil {
+			return Scholar{}, err
+		}
+		payout = addr.Hex()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Scholar{}, err
+	}
+
+	addr, ok := s.find(key)
+	if !ok {
+		return Scholar{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	sc := s.scholars[addr]
+	sc.PayoutAddress = payout
+	s.scholars[addr] = sc
+	return sc, s.save()
+}
+
+// Remove
+// Explanation -> removes a scholar and their assignments
+// Return -> ErrNotFound for an unknown name or address
+func (s *Store) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return err
+	}
+
+	addr, ok := s.find(key)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	delete(s.scholars, addr)
+	return s.save()
+}
+
+// Assign
+// Explanation -> assigns Axies to a scholar. An Axie belongs to one scholar at a time, one
+// already assigned elsewhere is moved
+// Return -> the scholar as stored
+func (s *Store) Assign(key string, axieIDs []string) (Scholar, error) {
+	ids, err := cleanIDs(axieIDs)
+	if err != nil {
+		return Scholar{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return Scholar{}, err
+	}
+
+	addr, ok := s.find(key)
+	if !ok {
+		return Scholar{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	for other, sc := range s.scholars {
+		if other != addr {
+			sc.Axies = without(sc.Axies, ids)
+			s.scholars[other] = sc
+		}
+	}
+	sc := s.scholars[addr]
+	sc.Axies = sortIDs(append(without(sc.Axies, ids), ids...))
+	s.scholars[addr] = sc
+	return sc, s.save()
+}
+
+// Unassign
+// Explanation -> takes Axies off whichever scholars they're assigned to, e.g. when they go
+// back to the team wallet
+// Return -> how many assignments were removed
+func (s *Store) Unassign(axieIDs []string) (int, error) {
+	ids, err := cleanIDs(axieIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for addr, sc := range s.scholars {
+		kept := without(sc.Axies, ids)
+		removed += len(sc.Axies) - len(kept)
+		sc.Axies = kept
+		s.scholars[addr] = sc
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.save()
+}
+
+// save writes the file, scholars sorted by name, via a temp file, caller holds mu
+func (s *Store) save() error {
+	f := file{Scholars: make([]Scholar, 0, len(s.scholars))}
+	for _, sc := range s.scholars {
+		f.Scholars = append(f.Scholars, sc)
+	}
+	sort.Slice(f.Scholars, func(i, j int) bool {
+		return strings.ToLower(f.Scholars[i].Name) < strings.ToLower(f.Scholars[j].Name)
+	})
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding guild: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("saving guild: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving guild: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving guild: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving guild: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("saving guild: %w", err)
+	}
+
+	// our own write shouldn't trigger a reload
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// cleanIDs checks Axie ids are plain numbers and drops duplicates
+func cleanIDs(ids []string) ([]string, error) {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimLeft(strings.TrimSpace(id), "#")
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return nil, fmt.Errorf("%w %q", ErrInvalidAxieID, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: none given", ErrInvalidAxieID)
+	}
+	return out, nil
+}
+
+// without returns ids minus drop, in a new slice
+func without(ids, drop []string) []string {
+	skip := make(map[string]bool, len(drop))
+	for _, id := range drop {
+		skip[id] = true
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !skip[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// sortIDs sorts token ids numerically, they're all digits
+func sortIDs(ids []string) []string {
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
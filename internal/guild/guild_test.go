@@ -0,0 +1,113 @@
+package guild
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	annAddr = "0x1111111111111111111111111111111111111111"
+	bobAddr = "0x2222222222222222222222222222222222222222"
+)
+
+func writeGuild(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "guild.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadChecksManagerShare(t *testing.T) {
+	tests := []struct {
+		name  string
+		share string
+		ok    bool
+	}{
+		{"none", "0", true},
+		{"all", "100", true},
+		{"over 100", "150", false},
+		{"negative", "-5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeGuild(t, `{"scholars":[{"name":"ann","address":"ronin:`+annAddr[2:]+`","manager_share":`+tt.share+`,"axies":[]}]}`)
+			s, err := Load(path)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if sc, ok := s.Get("ANN"); !ok || sc.Address != annAddr {
+					t.Errorf("Get = %+v, %v", sc, ok)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidShare) || !strings.Contains(err.Error(), "ann") {
+				t.Errorf("Load = %v, want ErrInvalidShare naming ann", err)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guild.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+
+	if sc, err := s.Add("ann", annAddr, -1); err != nil || sc.ManagerShare != DefaultManagerShare {
+		t.Fatalf("Add = %+v, %v, want the default share", sc, err)
+	}
+	if _, err := s.Add("bob", bobAddr, 101); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("Add with 101%% = %v, want ErrInvalidShare", err)
+	}
+	if _, err := s.Add("Ann", bobAddr, 40); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Add of a taken name = %v, want ErrDuplicate", err)
+	}
+	if _, err := s.Add("bob", bobAddr, 40); err != nil {
+		t.Fatalf("Add bob: %v", err)
+	}
+
+	// an Axie belongs to one scholar, assigning it again moves it
+	if _, err := s.Assign("ann", []string{"10", "#2", "2"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if _, err := s.Assign(bobAddr, []string{"10"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if _, err := s.Assign("ann", []string{"x1"}); !errors.Is(err, ErrInvalidAxieID) {
+		t.Errorf("Assign of a bad id = %v, want ErrInvalidAxieID", err)
+	}
+	if _, err := s.Assign("carl", []string{"1"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Assign to nobody = %v, want ErrNotFound", err)
+	}
+
+	// a second store on the same file sees it all
+	other, err := Load(path)
+	if err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	ann, _ := other.Get("ann")
+	bob, _ := other.Get("ronin:" + bobAddr[2:])
+	if strings.Join(ann.Axies, ",") != "2" || strings.Join(bob.Axies, ",") != "10" || bob.ManagerShare != 40 {
+		t.Errorf("ann = %+v, bob = %+v", ann, bob)
+	}
+
+	if n, err := other.Unassign([]string{"2", "10", "99"}); err != nil || n != 2 {
+		t.Errorf("Unassign = %d, %v, want 2", n, err)
+	}
+	if err := other.Remove("bob"); err != nil {
+		t.Errorf("Remove: %v", err)
+	}
+	if err := other.Remove("bob"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove again = %v, want ErrNotFound", err)
+	}
+	if list := other.List(); len(list) != 1 || list[0].Name != "ann" {
+		t.Errorf("List = %+v, want only ann", list)
+	}
+}
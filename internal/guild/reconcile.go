@@ -0,0 +1,119 @@
+package guild
+
+import (
+	"cmd/pkg/address"
+	"time"
+)
+
+// Discrepancy kinds
+const (
+	// KindMissing is an assigned Axie that's in no scholar account, sold or sent out
+	KindMissing = "missing"
+	// KindMisplaced is an assigned Axie sitting in another scholar's account
+	KindMisplaced = "misplaced"
+	// KindUnassigned is an Axie in a scholar account that isn't assigned to anyone
+	KindUnassigned = "unassigned"
+)
+
+// Discrepancy is one Axie where the guild file and the chain disagree
+type Discrepancy struct {
+	Kind       string `json:"kind"`
+	AxieID     string `json:"axie_id"`
+	Scholar    string `json:"scholar"`           // who it's assigned to, or whose account it's in if unassigned
+	HeldBy     string `json:"held_by,omitempty"` // scholar whose account has it, for misplaced
+	AssignedTo string `json:"assigned_to,omitempty"`
+}
+
+// ScholarStatus is one scholar's reconciliation
+type ScholarStatus struct {
+	Name     string   `json:"name"`
+	Address  string   `json:"address"`
+	Assigned int      `json:"assigned"`
+	Held     []string `json:"held"` // Axie ids in the account
+	OK       bool     `json:"ok"`
+	Error    string   `json:"error,omitempty"` // holdings couldn't be fetched, nothing checked
+}
+
+// Report is the result of checking every scholar's assignments against their accounts
+type Report struct {
+	CheckedAt     time.Time       `json:"checked_at"`
+	Scholars      []ScholarStatus `json:"scholars"`
+	Discrepancies []Discrepancy   `json:"discrepancies"`
+}
+
+// Reconcile
+// Explanation -> compares assignments with holdings, the Axie ids found in each scholar's
+// account keyed by address (any format). Scholars missing from holdings are skipped with their
+// error from failed, their Axies aren't reported missing since nobody looked
+// Return -> the report, discrepancies by scholar in file order
+func Reconcile(scholars []Scholar, holdings map[string][]string, failed map[string]error) *Report {
+	r := &Report{CheckedAt: time.Now().UTC(), Discrepancies: []Discrepancy{}}
+
+	held := make(map[address.Address][]string, len(holdings))
+	for key, ids := range holdings {
+		if addr, err := address.Parse(key); err == nil {
+			held[addr] = ids
+		}
+	}
+	errs := make(map[address.Address]error, len(failed))
+	for key, err := range failed {
+		if addr, perr := address.Parse(key); perr == nil {
+			errs[addr] = err
+		}
+	}
+
+	holder := make(map[string]Scholar) // axie id -> scholar whose account has it
+	assignee := make(map[string]Scholar)
+	for _, sc := range scholars {
+		addr, _ := address.Parse(sc.Address)
+		for _, id := range held[addr] {
+			holder[id] = sc
+		}
+		for _, id := range sc.Axies {
+			assignee[id] = sc
+		}
+	}
+
+	for _, sc := range scholars {
+		addr, _ := address.Parse(sc.Address)
+		status := ScholarStatus{
+			Name:     sc.Name,
+			Address:  sc.Address,
+			Assigned: len(sc.Axies),
+			Held:     sortIDs(append([]string{}, held[addr]...)),
+			OK:       true,
+		}
+		if err, ok := errs[addr]; ok {
+			status.OK, status.Error = false, err.Error()
+			r.Scholars = append(r.Scholars, status)
+			continue
+		}
+
+		for _, id := range sc.Axies {
+			h, ok := holder[id]
+			switch {
+			case ok && h.Address == sc.Address:
+				continue
+			case ok:
+				r.Discrepancies = append(r.Discrepancies, Discrepancy{
+					Kind: KindMisplaced, AxieID: id, Scholar: sc.Name, HeldBy: h.Name, AssignedTo: sc.Name,
+				})
+			default:
+				r.Discrepancies = append(r.Discrepancies, Discrepancy{
+					Kind: KindMissing, AxieID: id, Scholar: sc.Name, AssignedTo: sc.Name,
+				})
+			}
+			status.OK = false
+		}
+		for _, id := range status.Held {
+			if _, ok := assignee[id]; !ok {
+				r.Discrepancies = append(r.Discrepancies, Discrepancy{
+					Kind: KindUnassigned, AxieID: id, Scholar: sc.Name, HeldBy: sc.Name,
+				})
+				status.OK = false
+			}
+		}
+		r.Scholars = append(r.Scholars, status)
+	}
+	return r
+}
@@ -0,0 +1,60 @@
+package guild
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	const carlAddr = "0x3333333333333333333333333333333333333333"
+	scholars := []Scholar{
+		{Name: "ann", Address: annAddr, Axies: []string{"1", "2", "3"}},
+		{Name: "bob", Address: bobAddr, Axies: []string{"4"}},
+		{Name: "carl", Address: carlAddr, Axies: []string{"5"}},
+	}
+	holdings := map[string][]string{
+		"ronin:" + annAddr[2:]: {"1", "4"},  // 4 is bob's
+		bobAddr:                {"9", "20"}, // neither is assigned
+	}
+	failed := map[string]error{carlAddr: errors.New("timeout")}
+
+	r := Reconcile(scholars, holdings, failed)
+
+	want := []Discrepancy{
+		{Kind: KindMissing, AxieID: "2", Scholar: "ann", AssignedTo: "ann"},
+		{Kind: KindMissing, AxieID: "3", Scholar: "ann", AssignedTo: "ann"},
+		{Kind: KindMisplaced, AxieID: "4", Scholar: "bob", HeldBy: "ann", AssignedTo: "bob"},
+		{Kind: KindUnassigned, AxieID: "9", Scholar: "bob", HeldBy: "bob"},
+		{Kind: KindUnassigned, AxieID: "20", Scholar: "bob", HeldBy: "bob"},
+	}
+	if len(r.Discrepancies) != len(want) {
+		t.Fatalf("discrepancies = %+v, want %d", r.Discrepancies, len(want))
+	}
+	for i, d := range want {
+		if r.Discrepancies[i] != d {
+			t.Errorf("discrepancy %d = %+v, want %+v", i, r.Discrepancies[i], d)
+		}
+	}
+
+	if len(r.Scholars) != 3 {
+		t.Fatalf("scholars = %+v", r.Scholars)
+	}
+	// carl wasn't checked, 5 isn't reported missing
+	if carl := r.Scholars[2]; carl.OK || carl.Error != "timeout" {
+		t.Errorf("carl = %+v, want the fetch error", carl)
+	}
+	if ann := r.Scholars[0]; ann.OK || ann.Assigned != 3 || len(ann.Held) != 2 {
+		t.Errorf("ann = %+v", ann)
+	}
+}
+
+func TestReconcileAllInPlace(t *testing.T) {
+	scholars := []Scholar{{Name: "ann", Address: annAddr, Axies: []string{"1", "2"}}}
+	r := Reconcile(scholars, map[string][]string{annAddr: {"2", "1"}}, nil)
+	if len(r.Discrepancies) != 0 || !r.Scholars[0].OK {
+		t.Errorf("report = %+v, want everything in place", r)
+	}
+	if held := r.Scholars[0].Held; held[0] != "1" || held[1] != "2" {
+		t.Errorf("held = %v, want sorted", held)
+	}
+}
@@ -0,0 +1,320 @@
+package media
+
+import (
+	"bytes"
+	"cmd/internal/models"
+	"cmd/internal/tokenmeta"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Options
+const (
+	DefaultWorkers   = 4
+	DefaultMaxBytes  = 20 << 20 // animated GIFs get big, anything over this isn't a profile picture
+	DefaultThumbSize = 256
+	DefaultTimeout   = 30 * time.Second
+)
+
+// Storage areas inside the library directory
+const (
+	areaObjects = "objects"
+	areaThumbs  = "thumbs"
+)
+
+// ErrNoImage is returned for NFTs without an image URL
+var ErrNoImage = errors.New("no image")
+
+// ErrNotImage is returned when the downloaded content isn't an image format we keep
+var ErrNotImage = errors.New("not an image")
+
+// extensions are the image types we keep, by sniffed MIME type
+var extensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/bmp":     ".bmp",
+	"image/svg+xml": ".svg",
+}
+
+// Options tune the downloader, zero values use the defaults
+type Options struct {
+	Workers   int
+	MaxBytes  int64
+	ThumbSize int // longest side of thumbnails, in pixels
+	Timeout   time.Duration
+	Gateways  tokenmeta.Gateways
+}
+
+// Downloader struct fetches NFT images into a Library
+type Downloader struct {
+	library    *Library
+	httpClient *http.Client
+	gateways   tokenmeta.Gateways
+	workers    int
+	maxBytes   int64
+	thumbSize  int
+	logger     *logger.Logger
+}
+
+// NewDownloader func creates a downloader that stores into library
+func NewDownloader(library *Library, opts Options, log *logger.Logger) *Downloader {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.ThumbSize <= 0 {
+		opts.ThumbSize = DefaultThumbSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Downloader{
+		library:    library,
+		httpClient: tokenmeta.NewHTTPClient(opts.Gateways, opts.Timeout),
+		gateways:   opts.Gateways,
+		workers:    opts.Workers,
+		maxBytes:   opts.MaxBytes,
+		thumbSize:  opts.ThumbSize,
+		logger:     log.WithGroup("media"),
+	}
+}
+
+// Library returns the library the downloader stores into
+func (d *Downloader) Library() *Library {
+	return d.library
+}
+
+// Failure is a token whose media couldn't be downloaded
+type Failure struct {
+	TokenAddress string `json:"token_address"`
+	TokenID      string `json:"token_id"`
+	Source       string `json:"source,omitempty"`
+	Error        string `json:"error"`
+}
+
+// Result is the outcome of a Download
+type Result struct {
+	Downloaded int       `json:"downloaded"`
+	Cached     int       `json:"cached"` // already in the library from the same source
+	Entries    []Entry   `json:"entries"`
+	Failures   []Failure `json:"failures,omitempty"`
+}
+
+// Download
+// Explanation -> downloads the images of nfts with a bounded worker pool, tokens already
+// stored from the same source URL are skipped. The manifest is saved once at the end
+// Return -> what happened to each token, an error only if the manifest couldn't be saved
+func (d *Downloader) Download(ctx context.Context, nfts []models.NFT) (*Result, error) {
+	var (
+		mu     sync.Mutex
+		result = &Result{}
+		jobs   = make(chan models.NFT)
+		wg     sync.WaitGroup
+	)
+
+	for range min(d.workers, max(len(nfts), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nft := range jobs {
+				entry, cached, err := d.fetch(ctx, nft)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failures = append(result.Failures, Failure{
+						TokenAddress: nft.TokenAddress,
+						TokenID:      nft.TokenID,
+						Source:       SourceURL(nft),
+						Error:        err.Error(),
+					})
+				case cached:
+					result.Cached++
+					result.Entries = append(result.Entries, entry)
+				default:
+					result.Downloaded++
+					result.Entries = append(result.Entries, entry)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, nft := range nfts {
+		select {
+		case jobs <- nft:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	d.logger.InfoContext(ctx, "Media download finished",
+		"nfts", len(nfts),
+		"downloaded", result.Downloaded,
+		"cached", result.Cached,
+		"failed", len(result.Failures),
+	)
+
+	if result.Downloaded > 0 {
+		if err := d.library.Save(); err != nil {
+			return result, err
+		}
+	}
+	return result, ctx.Err()
+}
+
+// SourceURL picks the best image for an NFT: Moralis' own high resolution copy when it has
+// one (it's on a CDN, faster and more reliable than most token hosts), then the original
+// media URL, then the metadata image
+func SourceURL(nft models.NFT) string {
+	if m := nft.Media; m != nil {
+		if c := m.MediaCollection; c != nil {
+			for _, size := range []string{c.High.URL, c.Medium.URL, c.Low.URL} {
+				if size != "" {
+					return size
+				}
+			}
+		}
+		if m.OriginalMediaURL != "" {
+			return m.OriginalMediaURL
+		}
+	}
+	return strings.TrimSpace(nft.Image)
+}
+
+// fetch downloads and stores one token's image
+// Return -> the entry, and true if it was already in the library
+func (d *Downloader) fetch(ctx context.Context, nft models.NFT) (Entry, bool, error) {
+	source := SourceURL(nft)
+	if source == "" {
+		return Entry{}, false, ErrNoImage
+	}
+	if existing, ok := d.library.Lookup(nft.TokenAddress, nft.TokenID); ok &&
+		existing.Source == source && d.library.Exists(existing) {
+		return existing, true, nil
+	}
+
+	data, err := d.get(ctx, source)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	mime := sniff(data)
+	ext, ok := extensions[mime]
+	if !ok {
+		return Entry{}, false, fmt.Errorf("%w: %s", ErrNotImage, mime)
+	}
+
+	hash, rel, err := d.library.put(areaObjects, data, ext)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	entry := Entry{
+		TokenAddress: nft.TokenAddress,
+		TokenID:      nft.TokenID,
+		Source:       source,
+		SHA256:       hash,
+		MIME:         mime,
+		Size:         int64(len(data)),
+		Path:         rel,
+		FetchedAt:    time.Now().UTC(),
+	}
+
+	// a missing thumbnail isn't a failed download, the original is still there
+	thumb, thumbExt, width, height, err := thumbnail(data, d.thumbSize)
+	entry.Width, entry.Height = width, height
+	switch {
+	case err == nil:
+		if entry.Thumbnail, err = d.library.putAs(areaThumbs, fmt.Sprintf("%s_%d", hash, d.thumbSize), thumb, thumbExt); err != nil {
+			return Entry{}, false, err
+		}
+	case !errors.Is(err, errNotResizable):
+		d.logger.DebugContext(ctx, "No thumbnail",
+			"token_address", nft.TokenAddress,
+			"token_id", nft.TokenID,
+			"error", err,
+		)
+	}
+
+	if err := d.library.record(entry); err != nil {
+		return Entry{}, false, err
+	}
+	return entry, false, nil
+}
+
+// get reads a data: URI or downloads the URL through the gateways, at most maxBytes of it
+func (d *Downloader) get(ctx context.Context, source string) ([]byte, error) {
+	if tokenmeta.IsDataURI(source) {
+		_, data, err := tokenmeta.DecodeDataURI(source)
+		if err == nil && int64(len(data)) > d.maxBytes {
+			return nil, fmt.Errorf("image is over %d bytes", d.maxBytes)
+		}
+		return data, err
+	}
+
+	target, err := d.gateways.HTTPURL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", target, resp.StatusCode)
+	}
+	if resp.ContentLength > d.maxBytes {
+		return nil, fmt.Errorf("image at %s is %d bytes, over %d", target, resp.ContentLength, d.maxBytes)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, d.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", target, err)
+	}
+	if int64(len(data)) > d.maxBytes {
+		return nil, fmt.Errorf("image at %s is over %d bytes", target, d.maxBytes)
+	}
+	return data, nil
+}
+
+// sniff works out the content type from the bytes, servers' Content-Type headers are often
+// wrong (IPFS gateways love application/octet-stream). SVG is text, so it's looked for by hand
+func sniff(data []byte) string {
+	mime := http.DetectContentType(data)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+
+	if strings.HasPrefix(mime, "text/") {
+		head := data[:min(len(data), 1024)]
+		if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+			return "image/svg+xml"
+		}
+	}
+	return mime
+}
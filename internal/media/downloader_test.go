@@ -0,0 +1,137 @@
+package media
+
+import (
+	"cmd/internal"
+	"cmd/internal/models"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func nft(id, image string) models.NFT {
+	return models.NFT{TokenAddress: axies, TokenID: id, Image: image}
+}
+
+// newTestDownloader stores into a fresh library, fetching from the test server directly: the
+// real client refuses loopback addresses, see tokenmeta.NewHTTPClient
+func newTestDownloader(t *testing.T, maxBytes int64) (*Downloader, *httptest.Server, *atomic.Int32) {
+	t.Helper()
+	red := pngImage(300, 300, true)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/red.png", "/same.png":
+			w.Header().Set("Content-Type", "application/octet-stream") // sniffed, not trusted
+			w.Write(red)
+		case "/logo.svg":
+			w.Write([]byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`))
+		case "/page.html":
+			w.Write([]byte("<html><body>not found</body></html>"))
+		case "/big.png":
+			w.Write(append(red, make([]byte, maxBytes)...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	library, err := OpenLibrary(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenLibrary: %v", err)
+	}
+	d := NewDownloader(library, Options{Workers: 3, MaxBytes: maxBytes}, logger.NewWithLevel(slog.LevelError+4))
+	d.httpClient = srv.Client()
+	return d, srv, &hits
+}
+
+func TestDownload(t *testing.T) {
+	d, srv, hits := newTestDownloader(t, 1<<20)
+	svg := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`))
+	nfts := []models.NFT{
+		nft("1", srv.URL+"/red.png"),
+		nft("2", srv.URL+"/same.png"),
+		nft("3", srv.URL+"/logo.svg"),
+		nft("4", svg),
+		nft("5", srv.URL+"/page.html"),
+		nft("6", srv.URL+"/missing.png"),
+		nft("7", srv.URL+"/big.png"),
+		nft("8", ""),
+	}
+
+	result, err := d.Download(context.Background(), nfts)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if result.Downloaded != 4 || result.Cached != 0 {
+		t.Errorf("downloaded %d, cached %d, want 4 and 0", result.Downloaded, result.Cached)
+	}
+	failed := make(map[string]string)
+	for _, f := range result.Failures {
+		failed[f.TokenID] = f.Error
+	}
+	for id, want := range map[string]string{"5": "not an image", "6": "status 404", "7": "over", "8": ErrNoImage.Error()} {
+		if !strings.Contains(failed[id], want) {
+			t.Errorf("token %s failure = %q, want %q", id, failed[id], want)
+		}
+	}
+
+	lib := d.Library()
+	one, _ := lib.Lookup(axies, "1")
+	two, _ := lib.Lookup(axies, "2")
+	if one.Path == "" || one.Path != two.Path || one.MIME != "image/png" || one.Width != 300 {
+		t.Errorf("tokens 1 and 2 = %+v and %+v, want one stored PNG", one, two)
+	}
+	if one.Thumbnail == "" || !strings.HasSuffix(one.Thumbnail, ".jpg") {
+		t.Errorf("thumbnail = %q, want a JPEG", one.Thumbnail)
+	}
+	objects, _ := filepath.Glob(filepath.Join(lib.Dir(), areaObjects, "*", "*"))
+	if len(objects) != 3 {
+		t.Errorf("%d objects stored, want 3: the PNG once and two SVGs", len(objects))
+	}
+	if logo, _ := lib.Lookup(axies, "3"); logo.MIME != "image/svg+xml" || logo.Thumbnail != "" {
+		t.Errorf("svg = %+v, want stored without a thumbnail", logo)
+	}
+	if _, err := os.Stat(filepath.Join(lib.Dir(), manifestName)); err != nil {
+		t.Errorf("manifest not saved: %v", err)
+	}
+
+	// a second run finds everything from the same source already there
+	before := hits.Load()
+	again, err := d.Download(context.Background(), nfts[:4])
+	if err != nil {
+		t.Fatalf("second Download: %v", err)
+	}
+	if again.Cached != 4 || again.Downloaded != 0 || hits.Load() != before {
+		t.Errorf("second run downloaded %d, cached %d, %d requests, want all cached", again.Downloaded, again.Cached, hits.Load()-before)
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	high := models.NFT{Image: "ipfs://meta", Media: &models.Media{
+		OriginalMediaURL: "https://host/original.png",
+		MediaCollection:  &internal.MediaCollection{High: internal.MediaSize{URL: "https://cdn/high.png"}, Low: internal.MediaSize{URL: "https://cdn/low.png"}},
+	}}
+	original := models.NFT{Image: "ipfs://meta", Media: &models.Media{OriginalMediaURL: "https://host/original.png"}}
+	for _, tt := range []struct {
+		nft  models.NFT
+		want string
+	}{
+		{high, "https://cdn/high.png"},
+		{original, "https://host/original.png"},
+		{models.NFT{Image: " ipfs://meta "}, "ipfs://meta"},
+		{models.NFT{}, ""},
+	} {
+		if got := SourceURL(tt.nft); got != tt.want {
+			t.Errorf("SourceURL = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package media
+
+import (
+	"cmd/pkg/address"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestName is the manifest file in the library directory
+const manifestName = "manifest.json"
+
+// Entry is one token's downloaded media, paths are relative to the library directory
+type Entry struct {
+	TokenAddress string    `json:"token_address"`
+	TokenID      string    `json:"token_id"`
+	Source       string    `json:"source"` // URL it was downloaded from, before gateway rewriting
+	SHA256       string    `json:"sha256"`
+	MIME         string    `json:"mime"`
+	Size         int64     `json:"size"`
+	Path         string    `json:"path"`
+	Thumbnail    string    `json:"thumbnail,omitempty"` // empty for formats we can't resize, e.g. SVG
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// manifest is the JSON layout of manifest.json
+type manifest struct {
+	Tokens map[string]Entry `json:"tokens"`
+}
+
+// Library struct is the on-disk media store: files named by their SHA-256 so an image shared
+// by many tokens is kept once, and a manifest mapping tokens to files. Safe for concurrent use
+type Library struct {
+	dir string
+
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// OpenLibrary
+// Explanation -> opens, or creates, a library in dir
+// Return -> the library, an error if dir can't be created or the manifest doesn't parse
+func OpenLibrary(dir string) (*Library, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating media dir: %w", err)
+	}
+
+	l := &Library{dir: dir, entries: make(map[string]Entry)}
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading media manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing media manifest: %w", err)
+	}
+	if m.Tokens != nil {
+		l.entries = m.Tokens
+	}
+	return l, nil
+}
+
+// Dir is the library directory
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// tokenKey is the manifest key, addresses in any format map to the same token
+func tokenKey(tokenAddress, tokenID string) (string, error) {
+	addr, err := address.Parse(tokenAddress)
+	if err != nil {
+		return "", err
+	}
+	return addr.Hex() + "/" + strings.TrimSpace(tokenID), nil
+}
+
+// Lookup returns a token's entry, false if it hasn't been downloaded
+func (l *Library) Lookup(tokenAddress, tokenID string) (Entry, bool) {
+	key, err := tokenKey(tokenAddress, tokenID)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	return e, ok
+}
+
+// Entries returns the manifest, sorted by token
+func (l *Library) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]Entry, 0, len(l.entries))
+	for _, e := range l.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TokenAddress != list[j].TokenAddress {
+			return list[i].TokenAddress < list[j].TokenAddress
+		}
+		return list[i].TokenID < list[j].TokenID
+	})
+	return list
+}
+
+// Path turns a path from an entry into one that can be opened
+func (l *Library) Path(rel string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(rel))
+}
+
+// Exists reports whether an entry's file is still on disk
+func (l *Library) Exists(e Entry) bool {
+	if e.Path == "" {
+		return false
+	}
+	_, err := os.Stat(l.Path(e.Path))
+	return err == nil
+}
+
+// put stores data under its hash in area (objects or thumbs), writing only if it's new
+// Return -> the hex hash and the path relative to the library
+func (l *Library) put(area string, data []byte, ext string) (string, string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	rel, err := l.putAs(area, hash, data, ext)
+	return hash, rel, err
+}
+
+// putAs stores data as area/<first two of name>/<name><ext>, name is a hex hash
+func (l *Library) putAs(area, name string, data []byte, ext string) (string, error) {
+	rel := area + "/" + name[:2] + "/" + name + ext
+	path := l.Path(rel)
+	if _, err := os.Stat(path); err == nil {
+		return rel, nil
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return "", fmt.Errorf("storing media: %w", err)
+	}
+	return rel, nil
+}
+
+// record adds or replaces a token's entry, Save writes it out
+func (l *Library) record(e Entry) error {
+	key, err := tokenKey(e.TokenAddress, e.TokenID)
+	if err != nil {
+		return err
+	}
+	e.TokenAddress = key[:strings.IndexByte(key, '/')]
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[key] = e
+	return nil
+}
+
+// Save writes the manifest
+func (l *Library) Save() error {
+	l.mu.Lock()
+	data, err := json.MarshalIndent(manifest{Tokens: l.entries}, "", "  ")
+	l.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding media manifest: %w", err)
+	}
+
+	if err := writeAtomic(filepath.Join(l.dir, manifestName), append(data, '\n')); err != nil {
+		return fmt.Errorf("saving media manifest: %w", err)
+	}
+	return nil
+}
+
+// writeAtomic writes via a temp file in the same directory, readers never see half a file
+func writeAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
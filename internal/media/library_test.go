@@ -0,0 +1,80 @@
+package media
+
+import (
+	"cmd/pkg/address"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const axies = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+
+func TestLibraryRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "media")
+	l, err := OpenLibrary(dir)
+	if err != nil {
+		t.Fatalf("OpenLibrary: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("library dir not created: %v", err)
+	}
+
+	// the same bytes are stored once, under their hash
+	hash, rel, err := l.put(areaObjects, []byte("image"), ".png")
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	_, again, err := l.put(areaObjects, []byte("image"), ".png")
+	if err != nil || again != rel {
+		t.Errorf("put again = %s, %v, want %s", again, err, rel)
+	}
+	if want := "objects/" + hash[:2] + "/" + hash + ".png"; rel != want {
+		t.Errorf("path = %s, want %s", rel, want)
+	}
+
+	e := Entry{TokenAddress: "ronin:" + axies[2:], TokenID: " 7 ", Source: "ipfs://x", SHA256: hash, Path: rel, FetchedAt: time.Now().UTC()}
+	if err := l.record(e); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := l.record(Entry{TokenAddress: "not an address"}); err == nil {
+		t.Error("record accepted a bad token address")
+	}
+	if err := l.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reopened, err := OpenLibrary(dir)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	// any address format finds the token, stored checksummed
+	got, ok := reopened.Lookup(axies, "7")
+	if !ok || got.Source != "ipfs://x" || got.TokenAddress != address.MustParse(axies).Hex() {
+		t.Fatalf("Lookup = %+v, %v", got, ok)
+	}
+	if !reopened.Exists(got) {
+		t.Error("stored file reported missing")
+	}
+	if entries := reopened.Entries(); len(entries) != 1 {
+		t.Errorf("Entries = %d, want 1", len(entries))
+	}
+
+	os.Remove(reopened.Path(rel))
+	if reopened.Exists(got) {
+		t.Error("deleted file reported present")
+	}
+	if _, ok := reopened.Lookup(axies, "8"); ok {
+		t.Error("Lookup found a token never stored")
+	}
+}
+
+func TestOpenLibraryBadManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, manifestName), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenLibrary(dir); err == nil {
+		t.Error("OpenLibrary accepted a broken manifest")
+	}
+}
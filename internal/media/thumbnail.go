@@ -0,0 +1,90 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the decoder
+	"image/jpeg"
+	"image/png"
+)
+
+// maxPixels guards against decompression bombs, a tiny file can claim a huge image
+const maxPixels = 64 << 20
+
+// errNotResizable is returned for formats the standard library can't decode, e.g. SVG or WebP
+var errNotResizable = errors.New("image format can't be resized")
+
+// thumbnail
+// Explanation -> decodes a PNG, JPEG or GIF (first frame) and scales it so its longest side
+// is at most maxSide, averaging the source pixels under each output pixel. Opaque images come
+// out as JPEG, ones with transparency as PNG
+// Return -> the encoded thumbnail, its extension, and the original width and height
+func thumbnail(data []byte, maxSide int) ([]byte, string, int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", 0, 0, errNotResizable
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", cfg.Width, cfg.Height, fmt.Errorf("image is %dx%d, too big to resize", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", cfg.Width, cfg.Height, fmt.Errorf("decoding image: %w", err)
+	}
+
+	dst := scaleDown(src, maxSide)
+	var buf bytes.Buffer
+	if dst.Opaque() {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), ".jpg", cfg.Width, cfg.Height, err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), ".png", cfg.Width, cfg.Height, err
+}
+
+// scaleDown box-filters src to fit in maxSide x maxSide, images already that small are copied
+// at their own size. Pixel art stays crisp enough at thumbnail sizes, photos stay smooth
+func scaleDown(src image.Image, maxSide int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	nw, nh := w, h
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			nw, nh = maxSide, max(1, h*maxSide/w)
+		} else {
+			nw, nh = max(1, w*maxSide/h), maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		sy0 := b.Min.Y + y*h/nh
+		sy1 := max(sy0+1, b.Min.Y+(y+1)*h/nh)
+		for x := 0; x < nw; x++ {
+			sx0 := b.Min.X + x*w/nw
+			sx1 := max(sx0+1, b.Min.X+(x+1)*w/nw)
+
+			// RGBA() is premultiplied 16 bit, so a plain average handles transparency
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,104 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// pngImage is a w x h PNG, opaque red or half transparent
+func pngImage(w, h int, opaque bool) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	c := color.NRGBA{R: 255, A: 255}
+	if !opaque {
+		c.A = 128
+	}
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		ext           string
+		width, height int // of the original
+		thumbW        int
+		thumbH        int
+	}{
+		{"wide opaque", pngImage(400, 200, true), ".jpg", 400, 200, 256, 128},
+		{"tall transparent", pngImage(100, 512, false), ".png", 100, 512, 50, 256},
+		{"already small", pngImage(64, 32, true), ".jpg", 64, 32, 64, 32},
+		{"one pixel high", pngImage(1000, 1, true), ".jpg", 1000, 1, 256, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, ext, w, h, err := thumbnail(tt.data, 256)
+			if err != nil {
+				t.Fatalf("thumbnail: %v", err)
+			}
+			if ext != tt.ext || w != tt.width || h != tt.height {
+				t.Errorf("thumbnail = %s of %dx%d, want %s of %dx%d", ext, w, h, tt.ext, tt.width, tt.height)
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("decoding thumbnail: %v", err)
+			}
+			if "."+format != tt.ext && !(format == "jpeg" && tt.ext == ".jpg") {
+				t.Errorf("thumbnail is %s, want %s", format, tt.ext)
+			}
+			if cfg.Width != tt.thumbW || cfg.Height != tt.thumbH {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.thumbW, tt.thumbH)
+			}
+		})
+	}
+}
+
+func TestThumbnailNotResizable(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`),
+		"text": []byte("hello"),
+	} {
+		if _, _, _, _, err := thumbnail(data, 256); !errors.Is(err, errNotResizable) {
+			t.Errorf("%s: err = %v, want errNotResizable", name, err)
+		}
+	}
+}
+
+func TestThumbnailDecompressionBomb(t *testing.T) {
+	// a tiny PNG whose header claims 100000 x 100000 pixels
+	data := pngImage(1, 1, true)
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, _, w, h, err := thumbnail(data, 256)
+	if err == nil || errors.Is(err, errNotResizable) {
+		t.Fatalf("err = %v, want too big to resize", err)
+	}
+	if w != 100000 || h != 100000 {
+		t.Errorf("size = %dx%d, want the claimed one", w, h)
+	}
+}
+
+func TestScaleDownAverages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{A: 255})
+	src.SetRGBA(1, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+
+	dst := scaleDown(src, 1)
+	if got := dst.RGBAAt(0, 0); got.R != 127 || got.G != 127 || got.B != 127 || got.A != 255 {
+		t.Errorf("black and white averaged to %v, want mid grey", got)
+	}
+}
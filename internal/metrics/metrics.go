@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+const namespace = "axs"
+
+// registry holds only our metrics (plus Go runtime ones), not the global default registry
+var registry = prometheus.NewRegistry()
+
+var (
+	// Moralis API
+	moralisRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "moralis",
+		Name:      "requests_total",
+		Help:      "Moralis API requests by endpoint and HTTP status (error for transport failures).",
+	}, []string{"endpoint", "status"})
+
+	moralisRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "moralis",
+		Name:      "request_duration_seconds",
+		Help:      "Moralis API request latency by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	moralisRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "moralis",
+		Name:      "retries_total",
+		Help:      "Moralis API requests retried, by endpoint.",
+	}, []string{"endpoint"})
+
+	moralisComputeUnits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "moralis",
+		Name:      "compute_units_total",
+		Help:      "Moralis compute units spent, from the x-request-weight response header.",
+	}, []string{"endpoint"})
+
+	rateLimitWaits = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "moralis",
+		Name:      "rate_limit_wait_seconds",
+		Help:      "Time spent waiting on the client rate limiter before a request.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	})
+
+	// Caches
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Cache lookups by cache name and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	// Service
+	spamFiltered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "nft",
+		Name:      "spam_filtered_total",
+		Help:      "NFTs dropped as spam.",
+	})
+
+	serviceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "operation_duration_seconds",
+		Help:      "Service operation latency, including API calls and conversion.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+
+	// Chain stream
+	streamEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "events_total",
+		Help:      "Events delivered from the node subscription, by kind (head, nft or erc20).",
+	}, []string{"kind"})
+
+	streamReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "reconnects_total",
+		Help:      "Times the node WebSocket dropped and was redialled.",
+	})
+
+	streamHead = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "head_block",
+		Help:      "Highest block the stream has processed.",
+	})
+
+	// Wallet events
+	eventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "published_total",
+		Help:      "Wallet events fanned out to watchers, by type.",
+	}, []string{"type"})
+
+	eventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "dropped_total",
+		Help:      "Wallet events a watcher missed because it had fallen behind.",
+	})
+
+	// Live push
+	pushClients = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "push",
+		Name:      "clients",
+		Help:      "Clients connected for live events, by transport (sse or websocket).",
+	}, []string{"transport"})
+
+	pushOverflows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "push",
+		Name:      "overflow_disconnects_total",
+		Help:      "Live event clients cut off for falling too far behind, by transport.",
+	}, []string{"transport"})
+)
+
+func init() {
+	registry.MustRegister(
+		moralisRequests,
+		moralisRequestDuration,
+		moralisRetries,
+		moralisComputeUnits,
+		rateLimitWaits,
+		cacheLookups,
+		spamFiltered,
+		serviceDuration,
+		streamEvents,
+		streamReconnects,
+		streamHead,
+		eventsPublished,
+		eventsDropped,
+		pushClients,
+		pushOverflows,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveMoralisRequest records a finished Moralis call
+// status is the HTTP status code, 0 if the request never got a response
+func ObserveMoralisRequest(endpoint string, status int, duration time.Duration) {
+	statusLabel := "error"
+	if status > 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+
+	moralisRequests.WithLabelValues(endpoint, statusLabel).Inc()
+	moralisRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+// ObserveComputeUnits records the compute units Moralis charged for a call
+func ObserveComputeUnits(endpoint string, units float64) {
+	moralisComputeUnits.WithLabelValues(endpoint).Add(units)
+}
+
+// IncRetry records a retried Moralis call
+func IncRetry(endpoint string) {
+	moralisRetries.WithLabelValues(endpoint).Inc()
+}
+
+// ObserveRateLimitWait records time spent blocked on the rate limiter
+func ObserveRateLimitWait(wait time.Duration) {
+	rateLimitWaits.Observe(wait.Seconds())
+}
+
+// ObserveCache records a cache lookup
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// AddSpamFiltered records NFTs dropped as spam
+func AddSpamFiltered(n int) {
+	spamFiltered.Add(float64(n))
+}
+
+// ObserveService records how long a service operation took, outcome is ok or error
+func ObserveService(operation string, err error, duration time.Duration) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	serviceDuration.WithLabelValues(operation, outcome).Observe(duration.Seconds())
+}
+
+// IncStreamEvent records an event delivered by the chain stream
+func IncStreamEvent(kind string) {
+	streamEvents.WithLabelValues(kind).Inc()
+}
+
+// IncStreamReconnect records the stream's connection dropping
+func IncStreamReconnect() {
+	streamReconnects.Inc()
+}
+
+// SetStreamHead records the highest block the stream has processed
+func SetStreamHead(block uint64) {
+	streamHead.Set(float64(block))
+}
+
+// IncEventPublished records a wallet event fanned out to watchers
+func IncEventPublished(eventType string) {
+	eventsPublished.WithLabelValues(eventType).Inc()
+}
+
+// IncEventDropped records a watcher missing an event
+func IncEventDropped() {
+	eventsDropped.Inc()
+}
+
+// AddPushClient records a live events client connecting (+1) or leaving (-1)
+func AddPushClient(transport string, delta int) {
+	pushClients.WithLabelValues(transport).Add(float64(delta))
+}
+
+// IncPushOverflow records a live events client cut off for being too slow
+func IncPushOverflow(transport string) {
+	pushOverflows.WithLabelValues(transport).Inc()
+}
+
+// Handler serves the metrics in Prometheus text format, for /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// WriteText
+// Explanation -> dumps our metrics in Prometheus text format, used at the end of a CLI run
+// Go runtime and process metrics are left out, they're noise for a one-off run
+// Return -> error if gathering or writing fails
+func WriteText(w io.Writer) error {
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), namespace+"_") {
+			continue
+		}
+		if _, err := expfmt.MetricFamilyToText(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
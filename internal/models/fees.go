@@ -0,0 +1,35 @@
+package models
+
+import "cmd/pkg/decimal"
+
+// FeeReport is the gas a wallet paid over a period, failed transactions kept apart since
+// they bought nothing
+type FeeReport struct {
+	Wallet   string `json:"wallet"`
+	FromDate string `json:"from_date,omitempty"`
+	ToDate   string `json:"to_date,omitempty"`
+
+	Transactions int             `json:"transactions"` // sent by the wallet, failed included
+	Failed       int             `json:"failed"`
+	TotalRON     decimal.Decimal `json:"total_ron"`
+	TotalUSD     decimal.Decimal `json:"total_usd"`
+	FailedRON    decimal.Decimal `json:"failed_ron"`
+	FailedUSD    decimal.Decimal `json:"failed_usd"`
+	Unpriced     int             `json:"unpriced,omitempty"` // transactions with no RON price for their day, not in the USD totals
+
+	ByMethod   []FeeBucket `json:"by_method"`
+	ByContract []FeeBucket `json:"by_contract"`
+	ByMonth    []FeeBucket `json:"by_month"` // oldest first, YYYY-MM
+}
+
+// FeeBucket is the fees for one method label, contract or month
+type FeeBucket struct {
+	Key       string          `json:"key"`
+	Label     string          `json:"label,omitempty"` // contract name when Moralis knows it
+	Count     int             `json:"count"`
+	Failed    int             `json:"failed,omitempty"`
+	RON       decimal.Decimal `json:"ron"`
+	USD       decimal.Decimal `json:"usd"`
+	FailedRON decimal.Decimal `json:"failed_ron"`
+	FailedUSD decimal.Decimal `json:"failed_usd"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "cmd/internal"
+
 // NFT represents a single NFT with the data we care about
 type NFT struct {
 	TokenID      string                 `json:"token_id"`
@@ -12,6 +14,98 @@ type NFT struct {
 	PossibleSpam bool                   `json:"possible_spam"`
 	Attributes   map[string]interface{} `json:"attributes"`
 	RarityRank   *int                   `json:"rarity_rank,omitempty"`
+
+	// added later, all omitempty so older consumers see the same output
+	TokenName         string      `json:"token_name,omitempty"`    // name from the token metadata, Name is the collection
+	ContractType      string      `json:"contract_type,omitempty"` // ERC721 or ERC1155
+	Amount            string      `json:"amount,omitempty"`        // balance held, > 1 only for ERC1155
+	OwnerOf           string      `json:"owner_of,omitempty"`
+	OwnerName         string      `json:"owner_name,omitempty"` // .ron name of the owner, if looked up
+	Symbol            string      `json:"symbol,omitempty"`
+	TokenURI          string      `json:"token_uri,omitempty"`
+	AnimationURL      string      `json:"animation_url,omitempty"`
+	ExternalLink      string      `json:"external_link,omitempty"`
+	BlockNumber       string      `json:"block_number,omitempty"`
+	BlockNumberMinted string      `json:"block_number_minted,omitempty"`
+	MinterAddress     string      `json:"minter_address,omitempty"`
+	LastMetadataSync  string      `json:"last_metadata_sync,omitempty"`
+	RarityPercentage  *float64    `json:"rarity_percentage,omitempty"`
+	RarityLabel       string      `json:"rarity_label,omitempty"`
+	RaritySource      string      `json:"rarity_source,omitempty"` // where RarityRank came from, moralis or local
+	Collection        *Collection `json:"collection,omitempty"`
+	Axie              *AxieInfo   `json:"axie,omitempty"` // only for tokens of the Axie contract
+
+	// from the collections registry, for tracked collections only
+	Label    string   `json:"label,omitempty"` // friendly collection name
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// why the spam classifier decided what it did, only when spam is flagged rather than dropped
+	SpamReasons []string `json:"spam_reasons,omitempty"`
+
+	// only filled when prices are requested, FloorPrice is in FloorPriceCurrency units
+	FloorPriceCurrency string `json:"floor_price_currency,omitempty"` // e.g. ron or eth
+	FloorPriceUSD      string `json:"floor_price_usd,omitempty"`
+
+	// Moralis' resized copies of the image, only when media items are requested
+	Media *Media `json:"media,omitempty"`
+}
+
+// Contract types Moralis reports
+const (
+	ContractTypeERC721  = "ERC721"
+	ContractTypeERC1155 = "ERC1155"
+)
+
+// Where NFT.RarityRank came from
+const (
+	RaritySourceMoralis = "moralis"
+	RaritySourceLocal   = "local" // computed by internal/rarity from the whole collection
+)
+
+// IsERC1155 reports whether the NFT is a semi-fungible token, where Amount matters
+func (n NFT) IsERC1155() bool {
+	return n.ContractType == ContractTypeERC1155
+}
+
+// Collection is the collection level info Moralis attaches to every token
+type Collection struct {
+	Name              string `json:"name,omitempty"`
+	Logo              string `json:"logo,omitempty"`
+	BannerImage       string `json:"banner_image,omitempty"`
+	Category          string `json:"category,omitempty"`
+	ProjectURL        string `json:"project_url,omitempty"`
+	WikiURL           string `json:"wiki_url,omitempty"`
+	DiscordURL        string `json:"discord_url,omitempty"`
+	TelegramURL       string `json:"telegram_url,omitempty"`
+	TwitterUsername   string `json:"twitter_username,omitempty"`
+	InstagramUsername string `json:"instagram_username,omitempty"`
+}
+
+// AxieInfo is what we know about an Axie, from its genes and metadata
+type AxieInfo struct {
+	Class      string              `json:"class,omitempty"`
+	Parts      map[string]AxiePart `json:"parts,omitempty"`  // keyed by eyes, ears, mouth, horn, back, tail
+	Purity     *int                `json:"purity,omitempty"` // dominant parts matching the class, 0 to 6
+	BreedCount *int                `json:"breed_count,omitempty"`
+	Stats      *AxieStats          `json:"stats,omitempty"`
+	Genes      string              `json:"genes,omitempty"`
+}
+
+// AxiePart is one body part, D is what the Axie shows, R1 and R2 are recessive
+type AxiePart struct {
+	Class string `json:"class,omitempty"` // class of the dominant gene
+	D     string `json:"d"`
+	R1    string `json:"r1,omitempty"`
+	R2    string `json:"r2,omitempty"`
+}
+
+// AxieStats are the battle stats
+type AxieStats struct {
+	HP     int `json:"hp"`
+	Speed  int `json:"speed"`
+	Skill  int `json:"skill"`
+	Morale int `json:"morale"`
 }
 
 // TokenRequest is what we send to get specific NFTs
@@ -26,18 +120,30 @@ type QueryParams struct {
 	Cursor        *string `json:"cursor"`
 	ExcludeSpam   bool    `json:"exclude_spam"`
 	IncludePrices bool    `json:"include_prices"`
+	MediaItems    bool    `json:"media_items"` // Moralis' resized image URLs, see NFT.Media
+
+	// applied by the service after fetching, Moralis knows nothing about these
+	AxieClass string   `json:"axie_class,omitempty"`
+	AxieParts []string `json:"axie_parts,omitempty"` // Type:Name, e.g. Mouth:Risky Fish
+	Filter    string   `json:"filter,omitempty"`     // expression, see internal/filter
+	SpamMode  string   `json:"spam_mode,omitempty"`  // drop, flag or only, "" for the service default
+
+	AllCollections bool `json:"all_collections,omitempty"` // include collections not in the registry
 }
 
-// APIResponse is what Moralis sends back for wallet queries
+// APIResponse is what Moralis sends back for wallet and collection queries
 type APIResponse struct {
 	Status   string       `json:"status"`
 	Page     int          `json:"page"`
 	PageSize int          `json:"page_size"`
+	Cursor   string       `json:"cursor"` // next page, empty on the last one
+	Total    *int         `json:"total"`  // items across every page, null when Moralis didn't count
 	Result   []RawNFTData `json:"result"`
 }
 
 // RawNFTData struct is the raw data from the Moralis API
 // what you feel is necessary
+// nested types come from internal/structs.go, which describes the full response
 type RawNFTData struct {
 	TokenID            string              `json:"token_id"`
 	TokenAddress       string              `json:"token_address"`
@@ -49,17 +155,65 @@ type RawNFTData struct {
 	NormalizedMetadata *NormalizedMetadata `json:"normalized_metadata"`
 	RarityRank         *int                `json:"rarity_rank,omitempty"`
 	Symbol             string              `json:"symbol"`
-	// ... other fields from the Moralis API data
-}
 
-type NormalizedMetadata struct {
-	Name        string       `json:"name"`
-	Description *string      `json:"description,omitempty"`
-	Image       string       `json:"image"`
-	Attributes  *[]Attribute `json:"attributes,omitempty"`
+	//
+
+	Amount            string   `json:"amount"`
+	ContractType      string   `json:"contract_type"`
+	TokenURI          string   `json:"token_uri"`
+	TokenHash         string   `json:"token_hash"`
+	BlockNumber       string   `json:"block_number"`
+	BlockNumberMinted *string  `json:"block_number_minted,omitempty"`
+	MinterAddress     *string  `json:"minter_address,omitempty"`
+	LastMetadataSync  string   `json:"last_metadata_sync"`
+	LastTokenUriSync  string   `json:"last_token_uri_sync"`
+	RarityPercentage  *float64 `json:"rarity_percentage,omitempty"`
+	RarityLabel       *string  `json:"rarity_label,omitempty"`
+
+	//
+
+	FloorPriceUSD      *string             `json:"floor_price_usd,omitempty"`
+	FloorPriceCurrency *string             `json:"floor_price_currency,omitempty"`
+	ListPrice          *internal.ListPrice `json:"list_price,omitempty"`
+	Media              *Media              `json:"media,omitempty"`
+
+	// collection info
+	CollectionLogo        string `json:"collection_logo"`
+	CollectionBannerImage string `json:"collection_banner_image"`
+	CollectionCategory    string `json:"collection_category"`
+	ProjectURL            string `json:"project_url"`
+	WikiURL               string `json:"wiki_url"`
+	DiscordURL            string `json:"discord_url"`
+	TelegramURL           string `json:"telegram_url"`
+	TwitterUsername       string `json:"twitter_username"`
+	InstagramUsername     string `json:"instagram_username"`
 }
 
-type Attribute struct {
-	TraitType string `json:"trait_type"`
-	Value     any    `json:"value"`
+// Collection pulls the collection level fields out of the raw data
+// Return -> nil if Moralis sent nothing beyond the name
+func (r RawNFTData) Collection() *Collection {
+	c := Collection{
+		Name:              r.Name,
+		Logo:              r.CollectionLogo,
+		BannerImage:       r.CollectionBannerImage,
+		Category:          r.CollectionCategory,
+		ProjectURL:        r.ProjectURL,
+		WikiURL:           r.WikiURL,
+		DiscordURL:        r.DiscordURL,
+		TelegramURL:       r.TelegramURL,
+		TwitterUsername:   r.TwitterUsername,
+		InstagramUsername: r.InstagramUsername,
+	}
+
+	if c == (Collection{Name: r.Name}) {
+		return nil
+	}
+	return &c
 }
+
+// NormalizedMetadata and Attribute are the fuller versions from internal/structs.go
+type (
+	NormalizedMetadata = internal.NormalizedMetadata
+	Attribute          = internal.Attribute
+	Media              = internal.Media
+)
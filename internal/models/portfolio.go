@@ -0,0 +1,28 @@
+package models
+
+import "cmd/pkg/decimal"
+
+// Portfolio is a wallet's NFTs valued at their collection floor prices
+type Portfolio struct {
+	Wallet      string            `json:"wallet"`
+	Collections []CollectionValue `json:"collections"`
+	NFTs        int               `json:"nfts"`     // tokens held, ERC1155 amounts counted
+	Unpriced    int               `json:"unpriced"` // tokens with no usable floor price, not in the totals
+	TotalRON    decimal.Decimal   `json:"total_ron"`
+	TotalUSD    decimal.Decimal   `json:"total_usd"`
+
+	// USD prices used for conversions, by token symbol
+	Prices map[string]decimal.Decimal `json:"prices,omitempty"`
+}
+
+// CollectionValue is one collection's share of a portfolio
+type CollectionValue struct {
+	TokenAddress string          `json:"token_address"`
+	Name         string          `json:"name"`
+	Count        int             `json:"count"`
+	Unpriced     int             `json:"unpriced,omitempty"`
+	FloorPrice   string          `json:"floor_price,omitempty"`
+	Currency     string          `json:"floor_price_currency,omitempty"`
+	ValueRON     decimal.Decimal `json:"value_ron"`
+	ValueUSD     decimal.Decimal `json:"value_usd"`
+}
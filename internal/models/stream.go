@@ -0,0 +1,85 @@
+package models
+
+// RoninChainID is Ronin mainnet as Moralis Streams writes it, hex
+const RoninChainID = "0x7e4"
+
+// StreamConfig is a Moralis stream to create
+type StreamConfig struct {
+	WebhookURL          string   `json:"webhookUrl"`
+	Description         string   `json:"description"`
+	Tag                 string   `json:"tag"`
+	ChainIDs            []string `json:"chainIds"`
+	IncludeNativeTxs    bool     `json:"includeNativeTxs"`
+	IncludeContractLogs bool     `json:"includeContractLogs"` // needed for erc20Transfers and nftTransfers
+	IncludeInternalTxs  bool     `json:"includeInternalTxs"`
+	AllAddresses        bool     `json:"allAddresses"`
+}
+
+// Stream is a stream registered with Moralis
+type Stream struct {
+	ID                string   `json:"id"`
+	WebhookURL        string   `json:"webhookUrl"`
+	Description       string   `json:"description"`
+	Tag               string   `json:"tag"`
+	ChainIDs          []string `json:"chainIds"`
+	Status            string   `json:"status"` // active, paused, error or terminated
+	StatusMessage     string   `json:"statusMessage"`
+	AmountOfAddresses int      `json:"amountOfAddresses"`
+}
+
+// StreamsPage is one page of GET /streams/evm
+type StreamsPage struct {
+	Result []Stream `json:"result"`
+	Cursor string   `json:"cursor"`
+	Total  int      `json:"total"`
+}
+
+// StreamWebhook is the body Moralis posts for every block a stream matches, once unconfirmed
+// and again once confirmed. Numbers are decimal strings
+type StreamWebhook struct {
+	Confirmed bool   `json:"confirmed"`
+	ChainID   string `json:"chainId"`
+	StreamID  string `json:"streamId"`
+	Tag       string `json:"tag"`
+	Retries   int    `json:"retries"`
+	Block     struct {
+		Number    string `json:"number"`
+		Hash      string `json:"hash"`
+		Timestamp string `json:"timestamp"` // unix seconds
+	} `json:"block"`
+	ERC20Transfers []WebhookERC20Transfer `json:"erc20Transfers"`
+	NFTTransfers   []WebhookNFTTransfer   `json:"nftTransfers"`
+}
+
+// WebhookERC20Transfer is a decoded ERC-20 Transfer log in a stream webhook
+type WebhookERC20Transfer struct {
+	TransactionHash   string   `json:"transactionHash"`
+	LogIndex          string   `json:"logIndex"`
+	Contract          string   `json:"contract"`
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	Value             string   `json:"value"` // base units
+	TokenName         string   `json:"tokenName"`
+	TokenSymbol       string   `json:"tokenSymbol"`
+	TokenDecimals     string   `json:"tokenDecimals"`
+	ValueWithDecimals string   `json:"valueWithDecimals"`
+	PossibleSpam      bool     `json:"possibleSpam"`
+	TriggeredBy       []string `json:"triggered_by"` // the stream's addresses involved
+}
+
+// WebhookNFTTransfer is a decoded ERC-721/1155 transfer in a stream webhook
+type WebhookNFTTransfer struct {
+	TransactionHash   string   `json:"transactionHash"`
+	LogIndex          string   `json:"logIndex"`
+	Contract          string   `json:"contract"`
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	TokenID           string   `json:"tokenId"`
+	Amount            string   `json:"amount"`
+	Operator          string   `json:"operator"`
+	TokenName         string   `json:"tokenName"`
+	TokenSymbol       string   `json:"tokenSymbol"`
+	TokenContractType string   `json:"tokenContractType"`
+	PossibleSpam      bool     `json:"possibleSpam"`
+	TriggeredBy       []string `json:"triggered_by"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "cmd/internal"
+
+// Transaction is one entry of a wallet's history, with the NFT and token transfers it made
+// the full Moralis shape lives in internal/structs.go
+type (
+	Transaction    = internal.Transactions
+	NFTTransfer    = internal.NFTTransfer
+	ERC20Transfer  = internal.ERC20Transfer
+	NativeTransfer = internal.NativeTransfer
+)
+
+// Transaction categories Moralis assigns, the ones we act on
+const (
+	CategorySend        = "send"
+	CategoryReceive     = "receive"
+	CategoryNFTSend     = "nft send"
+	CategoryNFTReceive  = "nft receive"
+	CategoryNFTPurchase = "nft purchase"
+	CategoryNFTSale     = "nft sale"
+	CategoryTokenSwap   = "token swap"
+	CategoryAirdrop     = "airdrop"
+	CategoryMint        = "mint"
+	CategoryContract    = "contract interaction"
+)
+
+// HistoryParams are the options for a wallet history request
+type HistoryParams struct {
+	Limit    int    `json:"limit"`  // per page, Moralis caps it at 100
+	Cursor   string `json:"cursor"` // "" for the first page
+	FromDate string `json:"from_date,omitempty"`
+	ToDate   string `json:"to_date,omitempty"`
+	Order    string `json:"order,omitempty"` // ASC or DESC (default), by block
+	MaxPages int    `json:"max_pages,omitempty"`
+}
+
+// HistoryResponse is what Moralis sends back for wallet history
+type HistoryResponse struct {
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Cursor   string        `json:"cursor"` // next page, empty on the last one
+	Result   []Transaction `json:"result"`
+}
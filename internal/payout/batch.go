@@ -0,0 +1,161 @@
+package payout
+
+import (
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ChainID is Ronin mainnet
+const ChainID = "2020"
+
+// Function selectors, keccak of the signature
+var (
+	transferSelector  = ronin.Selector("transfer(address,uint256)")
+	multiSendSelector = ronin.Selector("multiSend(bytes)")
+)
+
+// Call is one unsigned contract call
+type Call struct {
+	To    string `json:"to"`
+	Value string `json:"value"` // wei, always 0, payouts are token transfers
+	Data  string `json:"data"`  // 0x calldata
+
+	// Safe's Transaction Builder reads these, null means decode data
+	ContractMethod       any `json:"contractMethod"`
+	ContractInputsValues any `json:"contractInputsValues"`
+}
+
+// BatchMeta describes the batch for whoever reviews it
+type BatchMeta struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	CreatedFromSafeAddress string `json:"createdFromSafeAddress,omitempty"`
+}
+
+// Batch is the payments as unsigned calls, laid out as a Safe Transaction Builder file so it
+// can be imported as is. MultiSend has the same calls packed into one multiSend(bytes) call,
+// for senders that batch through a MultiSendCallOnly contract themselves
+type Batch struct {
+	Version      string    `json:"version"`
+	ChainID      string    `json:"chainId"`
+	CreatedAt    int64     `json:"createdAt"` // unix ms
+	Meta         BatchMeta `json:"meta"`
+	Transactions []Call    `json:"transactions"`
+	MultiSend    *Call     `json:"multiSend,omitempty"`
+}
+
+// BatchOptions are the addresses a batch refers to, both optional
+type BatchOptions struct {
+	Payer     string // the wallet or Safe paying, only recorded in meta
+	MultiSend string // MultiSendCallOnly contract, "" leaves MultiSend out
+}
+
+// NewBatch
+// Explanation -> encodes every payment as an ERC-20 transfer call from the payer
+// Return -> the batch, an error for payments with bad addresses
+func NewBatch(p *Plan, opts BatchOptions) (*Batch, error) {
+	b := &Batch{
+		Version:   "1.0",
+		ChainID:   ChainID,
+		CreatedAt: p.CreatedAt.UnixMilli(),
+		Meta: BatchMeta{
+			Name:        "Scholar payouts " + period(p),
+			Description: fmt.Sprintf("%d payments to %d scholars, unsigned, review before submitting", len(p.Payments), scholars(p)),
+		},
+		Transactions: make([]Call, 0, len(p.Payments)),
+	}
+	if opts.Payer != "" {
+		payer, err := address.Parse(opts.Payer)
+		if err != nil {
+			return nil, fmt.Errorf("payer: %w", err)
+		}
+		b.Meta.CreatedFromSafeAddress = payer.Hex()
+	}
+
+	var packed []byte
+	for _, pay := range p.Payments {
+		token, err := address.Parse(pay.Token.Address)
+		if err != nil {
+			return nil, fmt.Errorf("payment to %s: %w", pay.Scholar, err)
+		}
+		to, err := address.Parse(pay.To)
+		if err != nil {
+			return nil, fmt.Errorf("payment to %s: %w", pay.Scholar, err)
+		}
+		data := TransferCalldata(to, pay.Units)
+		b.Transactions = append(b.Transactions, Call{
+			To:    token.Hex(),
+			Value: "0",
+			Data:  "0x" + hex.EncodeToString(data),
+		})
+		packed = append(packed, packCall(token, data)...)
+	}
+
+	if opts.MultiSend != "" && len(packed) > 0 {
+		ms, err := address.Parse(opts.MultiSend)
+		if err != nil {
+			return nil, fmt.Errorf("multisend: %w", err)
+		}
+		b.MultiSend = &Call{
+			To:    ms.Hex(),
+			Value: "0",
+			Data:  "0x" + hex.EncodeToString(multiSendCalldata(packed)),
+		}
+	}
+	return b, nil
+}
+
+// TransferCalldata is the calldata for ERC-20 transfer(to, units)
+func TransferCalldata(to address.Address, units *big.Int) []byte {
+	return ronin.EncodeCall(transferSelector, ronin.EncodeAddress(to), ronin.EncodeUint(units))
+}
+
+// packCall is one call in MultiSend's packed format: operation (0, call), to, value, data
+// length and data, with no padding between them
+func packCall(to address.Address, data []byte) []byte {
+	out := make([]byte, 0, 1+20+32+32+len(data))
+	out = append(out, 0)
+	out = append(out, to[:]...)
+	out = append(out, ronin.EncodeUint(new(big.Int))...)
+	out = append(out, ronin.EncodeUint(big.NewInt(int64(len(data))))...)
+	return append(out, data...)
+}
+
+// multiSendCalldata ABI encodes multiSend(bytes): the offset to the bytes, their length, then
+// the bytes padded to a whole word
+func multiSendCalldata(packed []byte) []byte {
+	out := ronin.EncodeCall(multiSendSelector,
+		ronin.EncodeUint(big.NewInt(32)),
+		ronin.EncodeUint(big.NewInt(int64(len(packed)))),
+	)
+	out = append(out, packed...)
+	if rem := len(packed) % 32; rem != 0 {
+		out = append(out, make([]byte, 32-rem)...)
+	}
+	return out
+}
+
+func period(p *Plan) string {
+	switch {
+	case p.FromDate != "" && p.ToDate != "":
+		return p.FromDate + " to " + p.ToDate
+	case p.FromDate != "":
+		return "from " + p.FromDate
+	case p.ToDate != "":
+		return "to " + p.ToDate
+	default:
+		return p.CreatedAt.Format(time.DateOnly)
+	}
+}
+
+func scholars(p *Plan) int {
+	seen := make(map[string]bool)
+	for _, pay := range p.Payments {
+		seen[pay.Scholar] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,75 @@
+package payout
+
+import (
+	"cmd/pkg/address"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var (
+	scholarAddr = address.MustParse("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	axsAddr     = address.MustParse("0x97a9107c1793bc407d6f527b77e7fff4d812bece")
+)
+
+// word is n as a 32-byte big-endian hex word
+func word(n int64) string {
+	h := big.NewInt(n).Text(16)
+	return strings.Repeat("0", 64-len(h)) + h
+}
+
+func TestTransferCalldata(t *testing.T) {
+	oneAXS, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tests := []struct {
+		name  string
+		units *big.Int
+		want  string
+	}{
+		{
+			name:  "one AXS",
+			units: oneAXS,
+			want: "a9059cbb" +
+				"0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed" +
+				"0000000000000000000000000000000000000000000000000de0b6b3a7640000",
+		},
+		{
+			name:  "zero",
+			units: new(big.Int),
+			want: "a9059cbb" +
+				"0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed" +
+				word(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hex.EncodeToString(TransferCalldata(scholarAddr, tt.units)); got != tt.want {
+				t.Errorf("calldata =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiSendCalldata(t *testing.T) {
+	packed := packCall(axsAddr, []byte{0xde, 0xad})
+	wantPacked := "00" + // operation, call
+		"97a9107c1793bc407d6f527b77e7fff4d812bece" + // to, 20 bytes, unpadded
+		word(0) + // value
+		word(2) + // data length
+		"dead"
+	if got := hex.EncodeToString(packed); got != wantPacked {
+		t.Fatalf("packCall =\n%s\nwant\n%s", got, wantPacked)
+	}
+
+	// 87 packed bytes are padded with 9 zero bytes to 96
+	want := "8d80ff0a" + word(32) + word(87) + wantPacked + strings.Repeat("00", 9)
+	if got := hex.EncodeToString(multiSendCalldata(packed)); got != want {
+		t.Errorf("multiSendCalldata =\n%s\nwant\n%s", got, want)
+	}
+
+	// a whole number of words needs no padding
+	exact := make([]byte, 64)
+	if got := len(multiSendCalldata(exact)); got != 4+32+32+64 {
+		t.Errorf("multiSendCalldata of 64 bytes is %d long, want %d", got, 4+32+32+64)
+	}
+}
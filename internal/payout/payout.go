@@ -0,0 +1,199 @@
+// Package payout splits scholar earnings between scholar and manager and builds the transfers
+// that pay scholars their share. It never signs anything: the output is an unsigned batch for a
+// wallet or multisig to review and submit
+package payout
+
+import (
+	"cmd/internal/guild"
+	"cmd/pkg/address"
+	"cmd/pkg/decimal"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Token is an ERC-20 scholars earn
+type Token struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Decimals int    `json:"decimals"`
+}
+
+// Tokens are the game rewards on Ronin, SLP has no decimals
+var Tokens = []Token{
+	{Symbol: "AXS", Address: "0x97a9107c1793bc407d6f527b77e7fff4d812bece", Decimals: 18},
+	{Symbol: "SLP", Address: "0xa8754b9fa15fc18bb59458815510e40a12cd2014", Decimals: 0},
+}
+
+// TokenByAddress finds an earnings token by contract, in any address format
+func TokenByAddress(contract string) (Token, bool) {
+	addr, err := address.Parse(contract)
+	if err != nil {
+		return Token{}, false
+	}
+	for _, t := range Tokens {
+		if address.MustParse(t.Address) == addr {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
+
+// Earning is what one scholar account received of one token in the period
+type Earning struct {
+	Scholar   string   `json:"scholar"`
+	Address   string   `json:"address"`
+	Token     Token    `json:"token"`
+	Units     *big.Int `json:"units"` // base units, exact
+	Transfers int      `json:"transfers"`
+}
+
+// Line is one scholar's split of one token
+type Line struct {
+	Scholar       string          `json:"scholar"`
+	Address       string          `json:"address"`
+	PayoutAddress string          `json:"payout_address,omitempty"`
+	Token         string          `json:"token"`
+	ManagerShare  int             `json:"manager_share"` // percent
+	Earned        decimal.Decimal `json:"earned"`
+	ScholarAmount decimal.Decimal `json:"scholar_amount"`
+	ManagerAmount decimal.Decimal `json:"manager_amount"`
+
+	scholarUnits *big.Int
+	token        Token
+}
+
+// Payment is one transfer of a scholar's share
+type Payment struct {
+	Scholar string          `json:"scholar"`
+	To      string          `json:"to"`
+	Token   Token           `json:"token"`
+	Units   *big.Int        `json:"units"`
+	Amount  decimal.Decimal `json:"amount"`
+}
+
+// Plan is who gets paid what for a period, Skipped are scholars with a share but nowhere to pay it
+type Plan struct {
+	FromDate  string    `json:"from_date,omitempty"`
+	ToDate    string    `json:"to_date,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Lines     []Line    `json:"lines"`
+	Payments  []Payment `json:"payments"`
+	Skipped   []string  `json:"skipped,omitempty"`
+
+	// totals by token symbol
+	Earned  map[string]decimal.Decimal `json:"earned"`
+	Scholar map[string]decimal.Decimal `json:"scholar"`
+	Manager map[string]decimal.Decimal `json:"manager"`
+}
+
+// Split
+// Explanation -> splits units by the manager's percent share, in whole base units. The
+// scholar's part rounds down, any dust stays with the manager
+// Return -> the scholar's and the manager's units
+func Split(units *big.Int, managerShare int) (*big.Int, *big.Int) {
+	scholarPct := big.NewInt(int64(100 - managerShare))
+	scholar := new(big.Int).Mul(units, scholarPct)
+	scholar.Quo(scholar, big.NewInt(100))
+	return scholar, new(big.Int).Sub(units, scholar)
+}
+
+// NewPlan
+// Explanation -> splits every earning by its scholar's manager share and lists the payments,
+// one per scholar and token, to the scholar's payout address
+// Return -> the plan, an error for earnings of scholars not in the list
+func NewPlan(scholars []guild.Scholar, earnings []Earning, fromDate, toDate string) (*Plan, error) {
+	byAddr := make(map[address.Address]guild.Scholar, len(scholars))
+	for _, sc := range scholars {
+		addr, err := address.Parse(sc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("scholar %s: %w", sc.Name, err)
+		}
+		byAddr[addr] = sc
+	}
+
+	p := &Plan{
+		FromDate:  fromDate,
+		ToDate:    toDate,
+		CreatedAt: time.Now().UTC(),
+		Lines:     []Line{},
+		Payments:  []Payment{},
+		Earned:    make(map[string]decimal.Decimal),
+		Scholar:   make(map[string]decimal.Decimal),
+		Manager:   make(map[string]decimal.Decimal),
+	}
+	skipped := make(map[string]bool)
+
+	for _, e := range earnings {
+		addr, err := address.Parse(e.Address)
+		if err != nil {
+			return nil, fmt.Errorf("earning for %s: %w", e.Scholar, err)
+		}
+		sc, ok := byAddr[addr]
+		if !ok {
+			return nil, fmt.Errorf("earning for %s: %w", e.Address, guild.ErrNotFound)
+		}
+		if e.Units == nil || e.Units.Sign() <= 0 {
+			continue
+		}
+
+		scholarUnits, managerUnits := Split(e.Units, sc.ManagerShare)
+		line := Line{
+			Scholar:       sc.Name,
+			Address:       sc.Address,
+			PayoutAddress: sc.PayoutAddress,
+			Token:         e.Token.Symbol,
+			ManagerShare:  sc.ManagerShare,
+			Earned:        amount(e.Units, e.Token),
+			ScholarAmount: amount(scholarUnits, e.Token),
+			ManagerAmount: amount(managerUnits, e.Token),
+			scholarUnits:  scholarUnits,
+			token:         e.Token,
+		}
+		p.Lines = append(p.Lines, line)
+
+		sym := e.Token.Symbol
+		p.Earned[sym] = p.Earned[sym].Add(line.Earned)
+		p.Scholar[sym] = p.Scholar[sym].Add(line.ScholarAmount)
+		p.Manager[sym] = p.Manager[sym].Add(line.ManagerAmount)
+	}
+
+	sort.SliceStable(p.Lines, func(i, j int) bool {
+		if !strings.EqualFold(p.Lines[i].Scholar, p.Lines[j].Scholar) {
+			return strings.ToLower(p.Lines[i].Scholar) < strings.ToLower(p.Lines[j].Scholar)
+		}
+		return p.Lines[i].Token < p.Lines[j].Token
+	})
+
+	for _, line := range p.Lines {
+		if line.scholarUnits.Sign() <= 0 {
+			continue
+		}
+		if line.PayoutAddress == "" {
+			if !skipped[line.Scholar] {
+				skipped[line.Scholar] = true
+				p.Skipped = append(p.Skipped, line.Scholar)
+			}
+			continue
+		}
+		p.Payments = append(p.Payments, Payment{
+			Scholar: line.Scholar,
+			To:      line.PayoutAddress,
+			Token:   line.token,
+			Units:   line.scholarUnits,
+			Amount:  line.ScholarAmount,
+		})
+	}
+	return p, nil
+}
+
+// amount is units in whole tokens
+func amount(units *big.Int, t Token) decimal.Decimal {
+	d, err := decimal.FromUnits(units.String(), t.Decimals)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
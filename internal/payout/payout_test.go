@@ -0,0 +1,74 @@
+package payout
+
+import (
+	"cmd/internal/guild"
+	"math/big"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		units        int64
+		managerShare int
+		scholar      int64
+		manager      int64
+	}{
+		{"manager takes nothing", 1001, 0, 1001, 0},
+		{"manager takes everything", 1001, 100, 0, 1001},
+		{"even split of odd units", 7, 50, 3, 4},
+		{"dust to the manager", 101, 30, 70, 31},
+		{"one unit", 1, 1, 0, 1},
+		{"nothing earned", 0, 40, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scholar, manager := Split(big.NewInt(tt.units), tt.managerShare)
+			if scholar.Int64() != tt.scholar || manager.Int64() != tt.manager {
+				t.Errorf("Split(%d, %d%%) = %s, %s, want %d, %d",
+					tt.units, tt.managerShare, scholar, manager, tt.scholar, tt.manager)
+			}
+			if sum := new(big.Int).Add(scholar, manager); sum.Int64() != tt.units {
+				t.Errorf("shares add up to %s, want %d", sum, tt.units)
+			}
+		})
+	}
+}
+
+func TestNewPlanPaysOnlyScholarShares(t *testing.T) {
+	slp := Tokens[1]
+	scholars := []guild.Scholar{
+		{Name: "ann", Address: "0x1111111111111111111111111111111111111111", ManagerShare: 100, PayoutAddress: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{Name: "bob", Address: "0x2222222222222222222222222222222222222222", ManagerShare: 30, PayoutAddress: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+		{Name: "cat", Address: "0x3333333333333333333333333333333333333333", ManagerShare: 0},
+	}
+	earnings := []Earning{
+		{Scholar: "ann", Address: scholars[0].Address, Token: slp, Units: big.NewInt(500)},
+		{Scholar: "bob", Address: scholars[1].Address, Token: slp, Units: big.NewInt(101)},
+		{Scholar: "cat", Address: "ronin:3333333333333333333333333333333333333333", Token: slp, Units: big.NewInt(9)},
+	}
+	p, err := NewPlan(scholars, earnings, "", "")
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+
+	// ann's share is nothing, cat has nowhere to be paid
+	if len(p.Payments) != 1 || p.Payments[0].Scholar != "bob" || p.Payments[0].Units.Int64() != 70 {
+		t.Errorf("payments = %+v, want bob's 70", p.Payments)
+	}
+	if len(p.Skipped) != 1 || p.Skipped[0] != "cat" {
+		t.Errorf("skipped = %v, want [cat]", p.Skipped)
+	}
+	if got := p.Manager["SLP"].String(); got != "531" {
+		t.Errorf("manager total = %s, want 531", got)
+	}
+	if got := p.Scholar["SLP"].String(); got != "79" {
+		t.Errorf("scholar total = %s, want 79", got)
+	}
+
+	// earnings of an account that isn't a scholar are an error
+	stray := append(earnings, Earning{Scholar: "dan", Address: "0x4444444444444444444444444444444444444444", Token: slp, Units: big.NewInt(1)})
+	if _, err := NewPlan(scholars, stray, "", ""); err == nil {
+		t.Error("NewPlan accepted earnings of an unknown account")
+	}
+}
@@ -0,0 +1,148 @@
+package price
+
+import (
+	"cmd/internal/cache"
+	"cmd/pkg/decimal"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCoinGeckoURL is the public CoinGecko API
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3"
+
+// DefaultCacheTTL is how long fetched prices are kept, CoinGecko only updates every minute or so
+const DefaultCacheTTL = 5 * time.Minute
+
+// coinGeckoIDs maps our symbols to CoinGecko coin ids
+var coinGeckoIDs = map[string]string{
+	RON:  "ronin",
+	AXS:  "axie-infinity",
+	SLP:  "smooth-love-potion",
+	WETH: "weth",
+}
+
+// CoinGecko struct fetches USD prices from the CoinGecko simple price API
+// all supported tokens are fetched in one call and cached together
+type CoinGecko struct {
+	httpClient *http.Client
+	baseURL    string
+	cache      *cache.TTL[string, decimal.Decimal]
+	history    *cache.TTL[string, decimal.Decimal] // symbol@dd-mm-yyyy, see USDPriceAt
+	mu         sync.Mutex                          // one refresh at a time, so a cold cache isn't fetched N times
+	logger     *logger.Logger
+}
+
+// NewCoinGecko func creates a provider, baseURL may be empty for the public API
+// ttl <= 0 uses DefaultCacheTTL
+func NewCoinGecko(baseURL string, ttl time.Duration, log *logger.Logger) *CoinGecko {
+	if baseURL == "" {
+		baseURL = DefaultCoinGeckoURL
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &CoinGecko{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		cache:      cache.NewTTL[string, decimal.Decimal]("token_prices", ttl),
+		history:    cache.NewTTL[string, decimal.Decimal]("token_price_history", historyCacheTTL),
+		logger:     log.WithGroup("coingecko"),
+	}
+}
+
+// USDPrice
+// Explanation -> returns the cached price, refreshing every supported token on a miss
+// Return -> the price, ErrUnknownSymbol for tokens outside Symbols
+func (c *CoinGecko) USDPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	symbol = NormalizeSymbol(symbol)
+	if _, ok := coinGeckoIDs[symbol]; !ok {
+		return decimal.Zero, fmt.Errorf("%w %s", ErrUnknownSymbol, symbol)
+	}
+
+	if d, ok := c.cache.Get(symbol); ok {
+		return d, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// someone else may have refreshed while we waited
+	if d, ok := c.cache.Get(symbol); ok {
+		return d, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return decimal.Zero, err
+	}
+	if d, ok := c.cache.Get(symbol); ok {
+		return d, nil
+	}
+	return decimal.Zero, fmt.Errorf("%w %s: missing from CoinGecko response", ErrUnknownSymbol, symbol)
+}
+
+// refresh fetches every supported token's price into the cache
+func (c *CoinGecko) refresh(ctx context.Context) error {
+	start := time.Now()
+
+	ids := make([]string, 0, len(coinGeckoIDs))
+	for _, id := range coinGeckoIDs {
+		ids = append(ids, id)
+	}
+
+	query := neturl.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", "usd")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("creating price request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("price API returned status %d", resp.StatusCode)
+	}
+
+	// numbers are decoded as json.Number so they never pass through a float
+	var body map[string]map[string]json.Number
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return fmt.Errorf("parsing prices: %w", err)
+	}
+
+	for symbol, id := range coinGeckoIDs {
+		usd, ok := body[id]["usd"]
+		if !ok {
+			continue
+		}
+		d, err := decimal.Parse(usd.String())
+		if err != nil {
+			c.logger.WarnContext(ctx, "Bad price from CoinGecko",
+				"symbol", symbol,
+				"error", err,
+			)
+			continue
+		}
+		c.cache.Set(symbol, d)
+	}
+
+	c.logger.DebugContext(ctx, "Refreshed token prices",
+		"tokens", len(body),
+		"duration", time.Since(start),
+	)
+	return nil
+}
@@ -0,0 +1,127 @@
+package price
+
+import (
+	"cmd/pkg/decimal"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+// historyCacheTTL is how long a day's price is kept, past days never change
+const historyCacheTTL = 24 * time.Hour
+
+// Historical gives the USD price of one whole token at a point in time, daily granularity
+// is enough for tax lots
+type Historical interface {
+	USDPriceAt(ctx context.Context, symbol string, at time.Time) (decimal.Decimal, error)
+}
+
+// HistoricalOf
+// Explanation -> the part of p that can price past dates. A fallback keeps only its members
+// with history, so a PRICE_OVERRIDES entry, today's price, never stands in for a past one.
+// Static deliberately has no USDPriceAt for the same reason
+// Return -> the historical provider, false if p has no history at all
+func HistoricalOf(p Provider) (Historical, bool) {
+	f, ok := p.(fallback)
+	if !ok {
+		h, ok := p.(Historical)
+		return h, ok
+	}
+	var withHistory fallback
+	for _, member := range f {
+		if _, ok := member.(Historical); ok {
+			withHistory = append(withHistory, member)
+		}
+	}
+	if len(withHistory) == 0 {
+		return nil, false
+	}
+	return withHistory, true
+}
+
+// USDPriceAt asks each provider that has history in turn
+func (f fallback) USDPriceAt(ctx context.Context, symbol string, at time.Time) (decimal.Decimal, error) {
+	var errs []error
+	for _, p := range f {
+		h, ok := p.(Historical)
+		if !ok {
+			continue
+		}
+		d, err := h.USDPriceAt(ctx, symbol, at)
+		if err == nil {
+			return d, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return decimal.Zero, fmt.Errorf("%w %s: no historical prices", ErrUnknownSymbol, symbol)
+	}
+	return decimal.Zero, errors.Join(errs...)
+}
+
+// USDPriceAt
+// Explanation -> returns the token's USD price on at's UTC day, from CoinGecko's coin history
+// API, cached per day
+// Return -> the price, ErrUnknownSymbol for tokens outside Symbols or days CoinGecko has none for
+func (c *CoinGecko) USDPriceAt(ctx context.Context, symbol string, at time.Time) (decimal.Decimal, error) {
+	symbol = NormalizeSymbol(symbol)
+	id, ok := coinGeckoIDs[symbol]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w %s", ErrUnknownSymbol, symbol)
+	}
+
+	day := at.UTC().Format("02-01-2006") // CoinGecko wants dd-mm-yyyy
+	key := symbol + "@" + day
+	if d, ok := c.history.Get(key); ok {
+		return d, nil
+	}
+
+	query := neturl.Values{}
+	query.Set("date", day)
+	query.Set("localization", "false")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/coins/"+id+"/history?"+query.Encode(), nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("creating price history request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("fetching price history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("price history API returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		MarketData *struct {
+			CurrentPrice map[string]json.Number `json:"current_price"`
+		} `json:"market_data"`
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return decimal.Zero, fmt.Errorf("parsing price history: %w", err)
+	}
+	// no market_data means the coin wasn't trading yet
+	if body.MarketData == nil {
+		return decimal.Zero, fmt.Errorf("%w %s on %s", ErrUnknownSymbol, symbol, day)
+	}
+	usd, ok := body.MarketData.CurrentPrice["usd"]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w %s on %s", ErrUnknownSymbol, symbol, day)
+	}
+	d, err := decimal.Parse(usd.String())
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("parsing price history: %w", err)
+	}
+
+	c.history.Set(key, d)
+	return d, nil
+}
@@ -0,0 +1,59 @@
+package price
+
+import (
+	"cmd/pkg/decimal"
+	"context"
+	"testing"
+	"time"
+)
+
+// dated prices a symbol by day, like CoinGecko's history
+type dated map[string]string // dd-mm-yyyy -> price
+
+func (d dated) USDPrice(context.Context, string) (decimal.Decimal, error) {
+	return decimal.MustParse("9"), nil
+}
+
+func (d dated) USDPriceAt(_ context.Context, symbol string, at time.Time) (decimal.Decimal, error) {
+	if v, ok := d[at.UTC().Format("02-01-2006")]; ok {
+		return decimal.MustParse(v), nil
+	}
+	return decimal.Zero, ErrUnknownSymbol
+}
+
+func TestHistoricalIgnoresSpotOverride(t *testing.T) {
+	ctx := context.Background()
+	overrides := Static{RON: decimal.MustParse("5")}
+	history := dated{"01-03-2024": "2.5"}
+	p := Fallback(overrides, history)
+
+	// today's price still comes from the override
+	if d, err := p.USDPrice(ctx, RON); err != nil || d.String() != "5" {
+		t.Errorf("USDPrice = %s, %v, want the override 5", d, err)
+	}
+
+	h, ok := HistoricalOf(p)
+	if !ok {
+		t.Fatal("HistoricalOf found no history")
+	}
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if d, err := h.USDPriceAt(ctx, RON, at); err != nil || d.String() != "2.5" {
+		t.Errorf("USDPriceAt = %s, %v, want the day's 2.5", d, err)
+	}
+	// a day history doesn't have is an error, not the override
+	if d, err := h.USDPriceAt(ctx, RON, at.AddDate(0, 0, 1)); err == nil {
+		t.Errorf("USDPriceAt on a missing day = %s, want an error", d)
+	}
+}
+
+func TestHistoricalOfOverridesOnly(t *testing.T) {
+	if _, ok := HistoricalOf(Fallback(Static{RON: decimal.MustParse("5")})); ok {
+		t.Error("overrides alone reported as having history")
+	}
+	if _, ok := HistoricalOf(Static{RON: decimal.MustParse("5")}); ok {
+		t.Error("Static reported as having history")
+	}
+	if _, ok := HistoricalOf(nil); ok {
+		t.Error("nil reported as having history")
+	}
+}
@@ -0,0 +1,130 @@
+package price
+
+import (
+	"cmd/pkg/decimal"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tokens we price, the ones Axie holders care about
+const (
+	RON  = "RON"
+	AXS  = "AXS"
+	SLP  = "SLP"
+	WETH = "WETH"
+)
+
+// Symbols lists the supported tokens
+var Symbols = []string{RON, AXS, SLP, WETH}
+
+// ErrUnknownSymbol is returned for tokens a provider has no price for
+var ErrUnknownSymbol = errors.New("no price for token")
+
+// Provider gives the USD price of one whole token
+type Provider interface {
+	USDPrice(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// NormalizeSymbol maps what APIs call a token to one of Symbols
+// wrapped and unwrapped are the same price, ETH on Ronin is always WETH
+func NormalizeSymbol(s string) string {
+	switch sym := strings.ToUpper(strings.TrimSpace(s)); sym {
+	case "ETH":
+		return WETH
+	case "WRON":
+		return RON
+	default:
+		return sym
+	}
+}
+
+// Static is a fixed price list, e.g. from PRICE_OVERRIDES, for offline use and tests
+type Static map[string]decimal.Decimal
+
+// ParseStatic
+// Explanation -> reads "RON=2.10,AXS=6.5" into a price list
+// Return -> an empty list for an empty string
+func ParseStatic(s string) (Static, error) {
+	prices := make(Static)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		sym, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("price %q should look like SYMBOL=price", pair)
+		}
+		d, err := decimal.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("price for %s: %w", sym, err)
+		}
+		prices[NormalizeSymbol(sym)] = d
+	}
+	return prices, nil
+}
+
+// USDPrice looks the symbol up in the list
+func (s Static) USDPrice(_ context.Context, symbol string) (decimal.Decimal, error) {
+	if d, ok := s[NormalizeSymbol(symbol)]; ok {
+		return d, nil
+	}
+	return decimal.Zero, fmt.Errorf("%w %s", ErrUnknownSymbol, symbol)
+}
+
+// fallback tries providers in order
+type fallback []Provider
+
+// Fallback returns a provider that asks each of providers in turn, nil ones are skipped
+// e.g. Fallback(overrides, coingecko) so a manual price beats the live one
+func Fallback(providers ...Provider) Provider {
+	var out fallback
+	for _, p := range providers {
+		if p != nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// USDPrice returns the first price found, or all the errors if none had one
+func (f fallback) USDPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	var errs []error
+	for _, p := range f {
+		d, err := p.USDPrice(ctx, symbol)
+		if err == nil {
+			return d, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return decimal.Zero, fmt.Errorf("%w %s", ErrUnknownSymbol, symbol)
+	}
+	return decimal.Zero, errors.Join(errs...)
+}
+
+// Convert
+// Explanation -> converts amount of token from into token to, through their USD prices
+// Return -> the converted amount
+func Convert(ctx context.Context, p Provider, amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	from, to = NormalizeSymbol(from), NormalizeSymbol(to)
+	if from == to {
+		return amount, nil
+	}
+
+	fromUSD, err := p.USDPrice(ctx, from)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	toUSD, err := p.USDPrice(ctx, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	converted, ok := amount.Mul(fromUSD).Div(toUSD)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w %s: price is zero", ErrUnknownSymbol, to)
+	}
+	return converted, nil
+}
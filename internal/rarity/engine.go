@@ -0,0 +1,252 @@
+package rarity
+
+import (
+	"cmd/internal/cache"
+	"cmd/internal/models"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Options
+const (
+	DefaultMaxTokens = 10000
+	DefaultTTL       = 24 * time.Hour
+	DefaultPageSize  = 100 // the most Moralis returns per page
+)
+
+// ErrTooLarge is returned for collections with more tokens than Options.MaxTokens
+// fetching them would cost more compute units than a rank is worth
+var ErrTooLarge = errors.New("collection too large for local rarity")
+
+// ErrNoTokens is returned for collections Moralis lists no tokens for
+var ErrNoTokens = errors.New("collection has no tokens")
+
+// Fetcher gets a page of a collection's tokens, with the collection's token count when the
+// provider knows it (0 otherwise). client.MoralisClient implements it
+type Fetcher interface {
+	GetContractNFTs(ctx context.Context, contractAddr, cursor string, limit int) ([]models.RawNFTData, string, int, error)
+}
+
+// Options tune the engine, zero values use the defaults
+type Options struct {
+	Method    string        // MethodInformation (default) or MethodStatistical
+	MaxTokens int           // bigger collections are skipped
+	TTL       time.Duration // stored results older than this are recomputed
+	PageSize  int
+}
+
+// Engine struct computes and stores rarity for whole collections on demand
+type Engine struct {
+	fetcher Fetcher
+	store   Store
+	opts    Options
+	skipped *cache.TTL[string, string] // contracts that can't be ranked, and why, see permanent
+	locks   sync.Map                   // contract -> *sync.Mutex, one computation per collection
+	logger  *logger.Logger
+}
+
+// NewEngine func creates an engine, store may be nil to keep results in memory only
+func NewEngine(fetcher Fetcher, store Store, opts Options, log *logger.Logger) (*Engine, error) {
+	method, err := ParseMethod(opts.Method)
+	if err != nil {
+		return nil, err
+	}
+	opts.Method = method
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultMaxTokens
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return &Engine{
+		fetcher: fetcher,
+		store:   store,
+		opts:    opts,
+		// a collection that couldn't be ranked is tried again sooner than results are refreshed
+		skipped: cache.NewTTL[string, string]("rarity_skipped", opts.TTL/4),
+		logger:  log.WithGroup("rarity"),
+	}, nil
+}
+
+// Collection
+// Explanation -> returns the stored result for a collection, computing it if it's missing,
+// stale, or was computed with another method
+// Return -> the result, ErrTooLarge for collections over MaxTokens
+func (e *Engine) Collection(ctx context.Context, contractAddr string) (*Result, error) {
+	addr, err := address.Parse(contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("contract address: %w", err)
+	}
+	contract := addr.Lower()
+
+	if reason, ok := e.skipped.Get(contract); ok {
+		return nil, fmt.Errorf("skipping %s: %s", contract, reason)
+	}
+
+	lock, _ := e.locks.LoadOrStore(contract, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if r, err := e.store.Load(contract); err == nil && e.fresh(r) {
+		return r, nil
+	} else if err != nil && !errors.Is(err, ErrNotStored) {
+		e.logger.WarnContext(ctx, "Stored rarity unreadable, recomputing",
+			"token_address", contract,
+			"error", err,
+		)
+	}
+
+	r, err := e.compute(ctx, addr)
+	if err != nil {
+		if permanent(err) {
+			e.skipped.Set(contract, err.Error())
+		}
+		return nil, err
+	}
+
+	if err := e.store.Save(r); err != nil {
+		// still usable for this run
+		e.logger.WarnContext(ctx, "Failed to store rarity",
+			"token_address", contract,
+			"error", err,
+		)
+	}
+	return r, nil
+}
+
+// permanent reports whether a failed computation would fail the same way if retried now.
+// Only those are remembered: a cancelled request, a rate limit that outlasted the retries or
+// a Moralis outage must not turn rarity off for the collection for hours
+func permanent(err error) bool {
+	return errors.Is(err, ErrTooLarge) || errors.Is(err, ErrNoTokens)
+}
+
+func (e *Engine) fresh(r *Result) bool {
+	return r.Method == e.opts.Method && time.Since(r.ComputedAt) < e.opts.TTL
+}
+
+// compute fetches every page of the collection and scores it. A collection over MaxTokens
+// is given up on at the first page when the provider reports its size, otherwise as soon
+// as the pages fetched go over
+func (e *Engine) compute(ctx context.Context, addr address.Address) (*Result, error) {
+	start := time.Now()
+	contract := addr.Lower()
+
+	var tokens []Token
+	cursor := ""
+	for {
+		page, next, total, err := e.fetcher.GetContractNFTs(ctx, addr.Hex(), cursor, e.opts.PageSize)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", contract, err)
+		}
+		if total > e.opts.MaxTokens {
+			return nil, fmt.Errorf("%w: %s has %d tokens, more than %d", ErrTooLarge, contract, total, e.opts.MaxTokens)
+		}
+		for _, raw := range page {
+			tokens = append(tokens, tokenFromRaw(raw))
+		}
+		if len(tokens) > e.opts.MaxTokens {
+			return nil, fmt.Errorf("%w: %s has more than %d tokens", ErrTooLarge, contract, e.opts.MaxTokens)
+		}
+		if next == "" || len(page) == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	r := Compute(contract, tokens, e.opts.Method)
+	if r == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoTokens, contract)
+	}
+
+	e.logger.InfoContext(ctx, "Computed collection rarity",
+		"token_address", contract,
+		"tokens", r.Tokens,
+		"trait_types", len(r.Traits),
+		"method", r.Method,
+		"duration", time.Since(start),
+	)
+	return r, nil
+}
+
+// Fill
+// Explanation -> sets RarityRank, and RaritySource to local, on NFTs the provider sent no
+// rank for. failures are logged and leave the rank empty, they never fail the request
+// Return -> how many NFTs were filled
+func (e *Engine) Fill(ctx context.Context, nfts []models.NFT) int {
+	results := make(map[string]*Result)
+	filled := 0
+	for i := range nfts {
+		if nfts[i].RarityRank != nil {
+			continue
+		}
+
+		key := strings.ToLower(nfts[i].TokenAddress)
+		r, seen := results[key]
+		if !seen {
+			var err error
+			r, err = e.Collection(ctx, nfts[i].TokenAddress)
+			if err != nil {
+				e.logger.DebugContext(ctx, "No local rarity",
+					"token_address", nfts[i].TokenAddress,
+					"error", err,
+				)
+			}
+			results[key] = r
+		}
+		if r == nil {
+			continue
+		}
+
+		score, ok := r.Scores[nfts[i].TokenID]
+		if !ok {
+			continue
+		}
+		rank := score.Rank
+		nfts[i].RarityRank = &rank
+		nfts[i].RaritySource = models.RaritySourceLocal
+		filled++
+	}
+	return filled
+}
+
+// numericDisplayTypes are OpenSea display types for numbers (levels, boosts, dates),
+// they're stats rather than traits and every value would be unique
+var numericDisplayTypes = map[string]bool{
+	"number":           true,
+	"boost_number":     true,
+	"boost_percentage": true,
+	"date":             true,
+}
+
+// tokenFromRaw pulls the traits out of the normalized metadata
+func tokenFromRaw(raw models.RawNFTData) Token {
+	t := Token{TokenID: raw.TokenID, Traits: make(map[string]string)}
+	if raw.NormalizedMetadata == nil || raw.NormalizedMetadata.Attributes == nil {
+		return t
+	}
+
+	for _, attr := range *raw.NormalizedMetadata.Attributes {
+		if attr.DisplayType != nil && numericDisplayTypes[strings.ToLower(*attr.DisplayType)] {
+			continue
+		}
+		if attr.Value == nil {
+			continue
+		}
+		t.Traits[attr.TraitType] = fmt.Sprint(attr.Value)
+	}
+	return t
+}
@@ -0,0 +1,195 @@
+package rarity
+
+import (
+	"cmd/internal/models"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const contract = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+
+// fakeFetcher serves tokens in pages, cursors are the next token's index
+type fakeFetcher struct {
+	tokens []models.RawNFTData
+	total  int // reported with every page, 0 for unknown
+	calls  int
+}
+
+func (f *fakeFetcher) GetContractNFTs(ctx context.Context, contractAddr, cursor string, limit int) ([]models.RawNFTData, string, int, error) {
+	f.calls++
+	start, _ := strconv.Atoi(cursor)
+	end := min(start+limit, len(f.tokens))
+	next := ""
+	if end < len(f.tokens) {
+		next = strconv.Itoa(end)
+	}
+	return f.tokens[start:end], next, f.total, nil
+}
+
+// hats makes n tokens, token 0 wears a crown and the rest a cap
+func hats(n int) []models.RawNFTData {
+	tokens := make([]models.RawNFTData, n)
+	for i := range tokens {
+		hat := "cap"
+		if i == 0 {
+			hat = "crown"
+		}
+		attrs := []models.Attribute{{TraitType: "Hat", Value: hat}}
+		tokens[i] = models.RawNFTData{
+			TokenID:            strconv.Itoa(i),
+			NormalizedMetadata: &models.NormalizedMetadata{Attributes: &attrs},
+		}
+	}
+	return tokens
+}
+
+func newTestEngine(t *testing.T, f Fetcher, store Store, opts Options) *Engine {
+	t.Helper()
+	e, err := NewEngine(f, store, opts, logger.NewWithLevel(slog.LevelError+4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEngineTooLarge(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		calls int
+	}{
+		// the first page's count is enough to give up
+		{"reported", 7, 1},
+		// otherwise paging stops as soon as it's over
+		{"unreported", 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeFetcher{tokens: hats(7), total: tt.total}
+			e := newTestEngine(t, f, nil, Options{MaxTokens: 3, PageSize: 2})
+
+			if _, err := e.Collection(context.Background(), contract); !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("Collection = %v, want ErrTooLarge", err)
+			}
+			if f.calls != tt.calls {
+				t.Errorf("fetched %d pages, want %d", f.calls, tt.calls)
+			}
+
+			// remembered, asking again costs nothing
+			if _, err := e.Collection(context.Background(), contract); err == nil {
+				t.Error("second Collection succeeded")
+			}
+			if f.calls != tt.calls {
+				t.Errorf("fetched %d pages after asking again, want %d", f.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestEngineNoTokens(t *testing.T) {
+	f := &fakeFetcher{}
+	e := newTestEngine(t, f, nil, Options{})
+
+	if _, err := e.Collection(context.Background(), contract); !errors.Is(err, ErrNoTokens) {
+		t.Fatalf("Collection = %v, want ErrNoTokens", err)
+	}
+
+	nfts := []models.NFT{{TokenAddress: contract, TokenID: "1"}}
+	if n := e.Fill(context.Background(), nfts); n != 0 || nfts[0].RarityRank != nil {
+		t.Errorf("Fill = %d, rank %v, want nothing filled", n, nfts[0].RarityRank)
+	}
+	if f.calls != 1 {
+		t.Errorf("fetched %d pages, want 1", f.calls)
+	}
+}
+
+func TestEngineTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		method   string
+		computes bool
+	}{
+		{"fresh", time.Hour, MethodInformation, false},
+		{"stale", 3 * time.Hour, MethodInformation, true},
+		{"other method", time.Hour, MethodStatistical, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			stored := &Result{
+				Contract:   contract,
+				Method:     tt.method,
+				Tokens:     1,
+				ComputedAt: time.Now().Add(-tt.age),
+				Scores:     map[string]Score{"0": {Rank: 42}},
+			}
+			if err := store.Save(stored); err != nil {
+				t.Fatal(err)
+			}
+
+			f := &fakeFetcher{tokens: hats(3)}
+			e := newTestEngine(t, f, store, Options{TTL: 2 * time.Hour})
+			r, err := e.Collection(context.Background(), contract)
+			if err != nil {
+				t.Fatalf("Collection: %v", err)
+			}
+
+			if computed := f.calls > 0; computed != tt.computes {
+				t.Fatalf("computed = %v, want %v", computed, tt.computes)
+			}
+			if !tt.computes {
+				if r != stored {
+					t.Errorf("got %+v, want the stored result", r)
+				}
+				return
+			}
+			if r.Tokens != 3 || r.Method != MethodInformation {
+				t.Errorf("got %+v, want 3 tokens by information", r)
+			}
+			// the new result replaced the old one
+			if saved, _ := store.Load(contract); saved != r {
+				t.Errorf("stored %+v, want the new result", saved)
+			}
+		})
+	}
+}
+
+func TestEngineFill(t *testing.T) {
+	f := &fakeFetcher{tokens: hats(3)}
+	e := newTestEngine(t, f, nil, Options{PageSize: 2})
+
+	moralisRank := 9
+	nfts := []models.NFT{
+		{TokenAddress: contract, TokenID: "0"},
+		{TokenAddress: contract, TokenID: "1", RarityRank: &moralisRank, RaritySource: models.RaritySourceMoralis},
+		{TokenAddress: contract, TokenID: "2"},
+		{TokenAddress: contract, TokenID: "77"}, // not in the collection
+		{TokenAddress: "not an address", TokenID: "0"},
+	}
+	if n := e.Fill(context.Background(), nfts); n != 2 {
+		t.Errorf("Fill = %d, want 2", n)
+	}
+
+	for i, want := range []struct {
+		rank   int
+		source string
+	}{{1, models.RaritySourceLocal}, {9, models.RaritySourceMoralis}, {2, models.RaritySourceLocal}, {0, ""}, {0, ""}} {
+		got := 0
+		if nfts[i].RarityRank != nil {
+			got = *nfts[i].RarityRank
+		}
+		if got != want.rank || nfts[i].RaritySource != want.source {
+			t.Errorf("nft %d: rank %d from %q, want %d from %q", i, got, nfts[i].RaritySource, want.rank, want.source)
+		}
+	}
+	// one collection, two pages, fetched once for every NFT in it
+	if f.calls != 2 {
+		t.Errorf("fetched %d pages, want 2", f.calls)
+	}
+}
@@ -0,0 +1,177 @@
+package rarity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scoring methods
+const (
+	// MethodInformation ranks by information content, the sum of -log2(p) over every trait
+	// rare traits count for a lot, common ones for almost nothing (what OpenRarity uses)
+	MethodInformation = "information"
+	// MethodStatistical ranks by the product of trait probabilities, the chance of rolling
+	// exactly this token's traits
+	MethodStatistical = "statistical"
+)
+
+// noneValue stands in for a trait type a token doesn't have, having no hat is a trait too
+const noneValue = "(none)"
+
+// ParseMethod checks a scoring method name, "" means MethodInformation
+func ParseMethod(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "", MethodInformation:
+		return MethodInformation, nil
+	case MethodStatistical:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown rarity method %q, expected information or statistical", s)
+	}
+}
+
+// Token is one token's traits, by trait type
+type Token struct {
+	TokenID string
+	Traits  map[string]string
+}
+
+// Score is one token's rarity within its collection
+type Score struct {
+	Rank               int     `json:"rank"` // 1 is the rarest, ties share a rank
+	InformationContent float64 `json:"information_content"`
+	Statistical        float64 `json:"statistical"` // probability, smaller is rarer
+}
+
+// Result is a computed collection, what the store keeps
+type Result struct {
+	Contract   string                    `json:"contract"` // lowercase 0x address
+	Method     string                    `json:"method"`
+	Tokens     int                       `json:"tokens"`
+	ComputedAt time.Time                 `json:"computed_at"`
+	Traits     map[string]map[string]int `json:"traits"` // trait type -> value -> count
+	Scores     map[string]Score          `json:"scores"` // by token ID
+}
+
+// Compute
+// Explanation -> counts trait frequencies across tokens and scores and ranks every token
+// trait types and values are compared case-insensitively
+// Return -> the result, nil for no tokens
+func Compute(contract string, tokens []Token, method string) *Result {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// count values per trait type, types are collected first so (none) can be counted
+	types := make(map[string]bool)
+	for _, t := range tokens {
+		for typ := range normalizeTraits(t.Traits) {
+			types[typ] = true
+		}
+	}
+
+	counts := make(map[string]map[string]int, len(types))
+	for typ := range types {
+		counts[typ] = make(map[string]int)
+	}
+	normalized := make([]map[string]string, len(tokens))
+	for i, t := range tokens {
+		traits := normalizeTraits(t.Traits)
+		for typ := range types {
+			value, ok := traits[typ]
+			if !ok {
+				value = noneValue
+			}
+			counts[typ][value]++
+		}
+		normalized[i] = traits
+	}
+
+	total := float64(len(tokens))
+	scores := make(map[string]Score, len(tokens))
+	for i, t := range tokens {
+		score := Score{Statistical: 1}
+		for typ := range types {
+			value, ok := normalized[i][typ]
+			if !ok {
+				value = noneValue
+			}
+			p := float64(counts[typ][value]) / total
+			score.InformationContent += -math.Log2(p)
+			score.Statistical *= p
+		}
+		scores[t.TokenID] = score
+	}
+
+	rank(scores, method)
+
+	return &Result{
+		Contract:   contract,
+		Method:     method,
+		Tokens:     len(tokens),
+		ComputedAt: time.Now().UTC(),
+		Traits:     counts,
+		Scores:     scores,
+	}
+}
+
+// rank fills Rank, rarest first by the method's score, equal scores share a rank (1, 1, 3)
+func rank(scores map[string]Score, method string) {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+
+	// rarer sorts first, token ID breaks ties so the order is stable
+	rarer := func(a, b Score) int {
+		if method == MethodStatistical {
+			return compareFloat(b.Statistical, a.Statistical)
+		}
+		return compareFloat(a.InformationContent, b.InformationContent)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if c := rarer(scores[ids[i]], scores[ids[j]]); c != 0 {
+			return c > 0
+		}
+		return ids[i] < ids[j]
+	})
+
+	for i, id := range ids {
+		s := scores[id]
+		s.Rank = i + 1
+		if i > 0 && rarer(scores[ids[i-1]], s) == 0 {
+			s.Rank = scores[ids[i-1]].Rank
+		}
+		scores[id] = s
+	}
+}
+
+// compareFloat compares with a little slack, sums of logs differ in the last bits by order
+func compareFloat(a, b float64) int {
+	const epsilon = 1e-9
+	switch {
+	case math.Abs(a-b) <= epsilon*math.Max(1, math.Max(math.Abs(a), math.Abs(b))):
+		return 0
+	case a > b:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// normalizeTraits lowercases and trims types and values, dropping empty ones
+func normalizeTraits(traits map[string]string) map[string]string {
+	out := make(map[string]string, len(traits))
+	for typ, value := range traits {
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if typ == "" || value == "" {
+			continue
+		}
+		out[typ] = value
+	}
+	return out
+}
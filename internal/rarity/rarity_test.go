@@ -0,0 +1,30 @@
+package rarity
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	if r := Compute(contract, nil, MethodInformation); r != nil {
+		t.Errorf("Compute of no tokens = %+v, want nil", r)
+	}
+
+	tokens := []Token{
+		{TokenID: "1", Traits: map[string]string{"Hat": "Crown", "Eyes": "blue"}},
+		{TokenID: "2", Traits: map[string]string{"hat": "cap ", "Eyes": "blue"}},
+		{TokenID: "3", Traits: map[string]string{"Hat": "CAP", "Eyes": "blue"}},
+		{TokenID: "4", Traits: map[string]string{"Eyes": "Blue"}}, // no hat is a trait too
+	}
+	for _, method := range []string{MethodInformation, MethodStatistical} {
+		t.Run(method, func(t *testing.T) {
+			r := Compute(contract, tokens, method)
+			if r.Tokens != 4 || r.Traits["hat"]["cap"] != 2 || r.Traits["hat"][noneValue] != 1 || r.Traits["eyes"]["blue"] != 4 {
+				t.Errorf("traits = %v", r.Traits)
+			}
+			// crown and no hat are both 1 in 4, the caps share the next rank
+			for id, want := range map[string]int{"1": 1, "4": 1, "2": 3, "3": 3} {
+				if got := r.Scores[id].Rank; got != want {
+					t.Errorf("token %s rank = %d, want %d", id, got, want)
+				}
+			}
+		})
+	}
+}
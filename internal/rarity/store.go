@@ -0,0 +1,112 @@
+package rarity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotStored is returned by Load for collections that haven't been computed
+var ErrNotStored = errors.New("rarity not computed")
+
+// Store keeps computed results between runs
+type Store interface {
+	Load(contract string) (*Result, error)
+	Save(r *Result) error
+}
+
+// MemoryStore keeps results for the life of the process
+type MemoryStore struct {
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+// NewMemoryStore func creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{results: make(map[string]*Result)}
+}
+
+// Load returns the stored result, or ErrNotStored
+func (s *MemoryStore) Load(contract string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.results[contract]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNotStored, contract)
+	}
+	return r, nil
+}
+
+// Save stores r under its contract
+func (s *MemoryStore) Save(r *Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[r.Contract] = r
+	return nil
+}
+
+// FileStore keeps one JSON file per collection in a directory, so a collection is only
+// fetched once for however many runs of the CLI
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore func creates the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating rarity dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(contract string) string {
+	return filepath.Join(s.dir, contract+".json")
+}
+
+// Load reads the collection's file, ErrNotStored if there isn't one
+func (s *FileStore) Load(contract string) (*Result, error) {
+	data, err := os.ReadFile(s.path(contract))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s", ErrNotStored, contract)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading rarity for %s: %w", contract, err)
+	}
+
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing rarity for %s: %w", contract, err)
+	}
+	return &r, nil
+}
+
+// Save writes the collection's file, via a temp file so readers never see half of it
+func (s *FileStore) Save(r *Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding rarity for %s: %w", r.Contract, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, r.Contract+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving rarity for %s: %w", r.Contract, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving rarity for %s: %w", r.Contract, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving rarity for %s: %w", r.Contract, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(r.Contract)); err != nil {
+		return fmt.Errorf("saving rarity for %s: %w", r.Contract, err)
+	}
+	return nil
+}
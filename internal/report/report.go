@@ -0,0 +1,478 @@
+package report
+
+import (
+	"cmd/internal/collections"
+	"cmd/internal/guild"
+	"cmd/internal/media"
+	"cmd/internal/models"
+	"cmd/internal/payout"
+	"cmd/internal/service"
+	"cmd/internal/stream"
+	"cmd/internal/tax"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// ParseFormat checks an -output value
+func ParseFormat(s string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(s)); f {
+	case FormatTable, FormatJSON:
+		return f, nil
+	case "":
+		return FormatTable, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q, expected table or json", s)
+	}
+}
+
+// WriteNFTs renders a list of NFTs
+func WriteNFTs(w io.Writer, nfts []models.NFT, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, nfts)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOKEN ADDRESS\tTOKEN ID\tNAME\tRARITY RANK\tFLOOR PRICE")
+	for _, nft := range nfts {
+		name := nft.TokenName
+		if name == "" {
+			name = nft.Name
+		}
+		rank := "-"
+		if nft.RarityRank != nil {
+			rank = strconv.Itoa(*nft.RarityRank)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			nft.TokenAddress, nft.TokenID, name, rank, orDash(nft.FloorPrice))
+	}
+	return tw.Flush()
+}
+
+// WriteOwnedNFTs renders NFTs merged from several wallets, with the wallet each is in
+func WriteOwnedNFTs(w io.Writer, nfts []models.NFT, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, nfts)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "OWNER\tTOKEN ADDRESS\tTOKEN ID\tNAME\tRARITY RANK\tFLOOR PRICE")
+	for _, nft := range nfts {
+		owner := nft.OwnerOf
+		if nft.OwnerName != "" {
+			owner = nft.OwnerName
+		}
+		name := nft.TokenName
+		if name == "" {
+			name = nft.Name
+		}
+		rank := "-"
+		if nft.RarityRank != nil {
+			rank = strconv.Itoa(*nft.RarityRank)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			orDash(owner), nft.TokenAddress, nft.TokenID, name, rank, orDash(nft.FloorPrice))
+	}
+	return tw.Flush()
+}
+
+// WriteBatchErrors renders the wallets of a batch that failed, always as a table since it
+// goes to stderr next to the results
+func WriteBatchErrors(w io.Writer, results []service.WalletResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WALLET\tERROR")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(tw, "%s\t%s\n", r.Wallet, r.Err)
+		}
+	}
+	return tw.Flush()
+}
+
+// groupsJSON is the JSON shape of a group by result
+type groupsJSON struct {
+	GroupBy     string          `json:"group_by,omitempty"`
+	Aggregation string          `json:"aggregation"`
+	Groups      []service.Group `json:"groups"`
+}
+
+// WriteGroups renders a group by / aggregation result, by is the -group-by value
+func WriteGroups(w io.Writer, groups []service.Group, by string, agg service.Aggregation, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, groupsJSON{GroupBy: by, Aggregation: agg.String(), Groups: groups})
+	}
+
+	header := strings.ToUpper(by)
+	if header == "" {
+		header = "GROUP"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if agg.Func == service.AggCount {
+		fmt.Fprintf(tw, "%s\tLABEL\tCOUNT\n", header)
+		for _, g := range groups {
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", g.Key, orDash(g.Label), g.Count)
+		}
+	} else {
+		fmt.Fprintf(tw, "%s\tLABEL\tCOUNT\t%s\n", header, strings.ToUpper(agg.String()))
+		for _, g := range groups {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", g.Key, orDash(g.Label), g.Count, orDash(g.Value))
+		}
+	}
+	return tw.Flush()
+}
+
+// WritePortfolio renders a valued portfolio, one row per collection then the totals
+func WritePortfolio(w io.Writer, p *models.Portfolio, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, p)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTION\tTOKEN ADDRESS\tCOUNT\tFLOOR\tVALUE RON\tVALUE USD")
+	for _, c := range p.Collections {
+		floor := orDash(c.FloorPrice)
+		if c.FloorPrice != "" && c.Currency != "" {
+			floor += " " + strings.ToUpper(c.Currency)
+		}
+		count := strconv.Itoa(c.Count)
+		if c.Unpriced > 0 {
+			count += fmt.Sprintf(" (%d unpriced)", c.Unpriced)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			orDash(c.Name), c.TokenAddress, count, floor, c.ValueRON.StringFixed(2), c.ValueUSD.StringFixed(2))
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%d\t\t%s\t%s\n", p.NFTs, p.TotalRON.StringFixed(2), p.TotalUSD.StringFixed(2))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if p.Unpriced > 0 {
+		fmt.Fprintf(w, "\n%d NFTs have no floor price and aren't in the totals\n", p.Unpriced)
+	}
+	return nil
+}
+
+// spamReportEntry is one row of the spam report in JSON
+type spamReportEntry struct {
+	TokenAddress string   `json:"token_address"`
+	TokenID      string   `json:"token_id"`
+	Name         string   `json:"name"`
+	Spam         bool     `json:"spam"`
+	Reasons      []string `json:"reasons"`
+}
+
+// WriteSpamReport renders why each NFT was or wasn't classified as spam
+// nfts need to come from a flag mode request, dropped ones and reasons are missing otherwise
+func WriteSpamReport(w io.Writer, nfts []models.NFT, format string) error {
+	if format == FormatJSON {
+		entries := make([]spamReportEntry, 0, len(nfts))
+		for _, nft := range nfts {
+			entries = append(entries, spamReportEntry{
+				TokenAddress: nft.TokenAddress,
+				TokenID:      nft.TokenID,
+				Name:         nft.Name,
+				Spam:         nft.PossibleSpam,
+				Reasons:      nft.SpamReasons,
+			})
+		}
+		return writeJSON(w, entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOKEN ADDRESS\tTOKEN ID\tNAME\tSPAM\tREASONS")
+	spamCount := 0
+	for _, nft := range nfts {
+		verdict := "no"
+		if nft.PossibleSpam {
+			verdict = "YES"
+			spamCount++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			nft.TokenAddress, nft.TokenID, orDash(nft.Name), verdict, orDash(strings.Join(nft.SpamReasons, "; ")))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d of %d classified as spam\n", spamCount, len(nfts))
+	return nil
+}
+
+// WriteCollections renders the collections registry
+func WriteCollections(w io.Writer, list []collections.Entry, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, list)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCATEGORY\tADDRESS\tTAGS")
+	for _, e := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, orDash(e.Category), e.Address, orDash(strings.Join(e.Tags, ",")))
+	}
+	return tw.Flush()
+}
+
+// WriteMediaResult renders a media download: one row per token, then the failures
+func WriteMediaResult(w io.Writer, result *media.Result, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, result)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOKEN\tID\tTYPE\tSIZE\tPATH\tTHUMBNAIL")
+	for _, e := range result.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.TokenAddress, e.TokenID, e.MIME, e.Size, e.Path, orDash(e.Thumbnail))
+	}
+	for _, f := range result.Failures {
+		fmt.Fprintf(tw, "%s\t%s\tfailed\t-\t%s\t-\n", f.TokenAddress, f.TokenID, f.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d downloaded, %d already stored, %d failed\n",
+		result.Downloaded, result.Cached, len(result.Failures))
+	return nil
+}
+
+// WriteScholars renders the guild's scholars, one row each
+func WriteScholars(w io.Writer, scholars []guild.Scholar, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, scholars)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tADDRESS\tMANAGER SHARE\tAXIES")
+	for _, sc := range scholars {
+		axies := "-"
+		if len(sc.Axies) > 0 {
+			axies = strings.Join(sc.Axies, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d%%\t%s\n", sc.Name, sc.Address, sc.ManagerShare, axies)
+	}
+	return tw.Flush()
+}
+
+// WriteReconciliation renders each scholar's status, then every discrepancy
+func WriteReconciliation(w io.Writer, r *guild.Report, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, r)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCHOLAR\tADDRESS\tASSIGNED\tHELD\tSTATUS")
+	for _, sc := range r.Scholars {
+		status := "ok"
+		switch {
+		case sc.Error != "":
+			status = "error: " + sc.Error
+		case !sc.OK:
+			status = "check"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", sc.Name, sc.Address, sc.Assigned, len(sc.Held), status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Discrepancies) == 0 {
+		fmt.Fprintln(w, "\nEvery assigned Axie is where it should be")
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "AXIE\tPROBLEM\tASSIGNED TO\tHELD BY")
+	for _, d := range r.Discrepancies {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.AxieID, d.Kind, orDash(d.AssignedTo), orDash(d.HeldBy))
+	}
+	return tw.Flush()
+}
+
+// WritePayoutPlan renders each scholar's split per token, then the totals
+func WritePayoutPlan(w io.Writer, p *payout.Plan, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, p)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCHOLAR\tTOKEN\tEARNED\tMANAGER %\tSCHOLAR GETS\tMANAGER KEEPS\tPAY TO")
+	for _, l := range p.Lines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			l.Scholar, l.Token, l.Earned, l.ManagerShare, l.ScholarAmount, l.ManagerAmount, orDash(l.PayoutAddress))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, t := range payout.Tokens {
+		if earned, ok := p.Earned[t.Symbol]; ok {
+			fmt.Fprintf(w, "%s: earned %s, scholars %s, manager %s\n",
+				t.Symbol, earned, p.Scholar[t.Symbol], p.Manager[t.Symbol])
+		}
+	}
+	fmt.Fprintf(w, "%d payments\n", len(p.Payments))
+	if len(p.Skipped) > 0 {
+		fmt.Fprintf(w, "no payout address for %s, set one with `guild payout`\n", strings.Join(p.Skipped, ", "))
+	}
+	return nil
+}
+
+// WriteFeeReport renders gas spend by method, contract and month, failed transactions in
+// their own columns
+func WriteFeeReport(w io.Writer, r *models.FeeReport, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, r)
+	}
+
+	sections := []struct {
+		title   string
+		buckets []models.FeeBucket
+	}{
+		{"METHOD", r.ByMethod},
+		{"CONTRACT", r.ByContract},
+		{"MONTH", r.ByMonth},
+	}
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\tTXS\tFEES RON\tFEES USD\tFAILED\tFAILED RON\tFAILED USD\n", s.title)
+		for _, b := range s.buckets {
+			key := b.Key
+			if b.Label != "" {
+				key += " (" + b.Label + ")"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%s\t%s\n",
+				key, b.Count, b.RON.StringFixed(4), b.USD.StringFixed(2), b.Failed, b.FailedRON.StringFixed(4), b.FailedUSD.StringFixed(2))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d transactions paid %s RON (%s USD) in fees\n",
+		r.Transactions, r.TotalRON.StringFixed(4), r.TotalUSD.StringFixed(2))
+	if r.Failed > 0 {
+		fmt.Fprintf(w, "%d failed, wasting %s RON (%s USD)\n", r.Failed, r.FailedRON.StringFixed(4), r.FailedUSD.StringFixed(2))
+	}
+	if r.Unpriced > 0 {
+		fmt.Fprintf(w, "%d transactions had no RON price and aren't in the USD totals\n", r.Unpriced)
+	}
+	return nil
+}
+
+// WriteTaxReport renders realized gains, one row per disposal and lot, then the totals
+func WriteTaxReport(w io.Writer, r *tax.Report, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, r)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DESCRIPTION\tACQUIRED\tSOLD\tPROCEEDS\tBASIS\tGAIN\tTERM")
+	for _, row := range r.Rows {
+		acquired, term := "unknown", "short"
+		if !row.MissingBasis {
+			acquired = row.Acquired.UTC().Format("2006-01-02")
+		}
+		if row.LongTerm {
+			term = "long"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			row.Description, acquired, row.Sold.UTC().Format("2006-01-02"),
+			row.Proceeds.StringFixed(2), row.Cost.StringFixed(2), row.Gain.StringFixed(2), term)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%s, %d transactions: proceeds %s USD, basis %s USD\n",
+		strings.ToUpper(r.Method), r.Transactions, r.Proceeds.StringFixed(2), r.Cost.StringFixed(2))
+	fmt.Fprintf(w, "short term gain %s USD, long term gain %s USD, other fees %s USD\n",
+		r.ShortTermGain.StringFixed(2), r.LongTermGain.StringFixed(2), r.Fees.StringFixed(2))
+	if r.MissingBasis > 0 {
+		fmt.Fprintf(w, "%d disposals have no matching acquisition in this wallet's history, their basis is 0\n", r.MissingBasis)
+	}
+	if r.Unpriced > 0 {
+		fmt.Fprintf(w, "%d transactions had nothing with a price and are valued at 0\n", r.Unpriced)
+	}
+	if r.Transfers > 0 {
+		fmt.Fprintf(w, "%d sends got nothing back and count as transfers, not sales\n", r.Transfers)
+	}
+	return nil
+}
+
+// WriteStreams renders the account's Moralis streams
+func WriteStreams(w io.Writer, streams []models.Stream, format string) error {
+	if format == FormatJSON {
+		if streams == nil {
+			streams = []models.Stream{}
+		}
+		return writeJSON(w, streams)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tCHAINS\tADDRESSES\tTAG\tWEBHOOK")
+	for _, st := range streams {
+		status := st.Status
+		if st.StatusMessage != "" && !strings.EqualFold(st.StatusMessage, st.Status) {
+			status += " (" + st.StatusMessage + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", st.ID, orDash(status), orDash(strings.Join(st.ChainIDs, ",")),
+			st.AmountOfAddresses, orDash(st.Tag), st.WebhookURL)
+	}
+	return tw.Flush()
+}
+
+// WriteStreamEvent renders one event as it arrives, a line of text or a JSON object per line
+func WriteStreamEvent(w io.Writer, e stream.Event, format string) error {
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(e)
+	}
+
+	state := ""
+	switch {
+	case e.Removed:
+		state = "  (removed, reorg)"
+	case e.Backfilled:
+		state = "  (backfilled)"
+	}
+	var err error
+	switch {
+	case e.Kind == stream.KindHead:
+		_, err = fmt.Fprintf(w, "#%d  head   %s  %s\n", e.BlockNumber, e.BlockHash, e.BlockTime.Format(time.RFC3339))
+	case e.NFT != nil:
+		_, err = fmt.Fprintf(w, "#%d  nft    %-7s  %s #%s  %s -> %s  %s%s\n", e.BlockNumber, orDash(e.NFT.Direction),
+			e.NFT.TokenAddress, e.NFT.TokenID, e.NFT.FromAddress, e.NFT.ToAddress, e.TxHash, state)
+	case e.ERC20 != nil:
+		_, err = fmt.Fprintf(w, "#%d  erc20  %-7s  %s %s  %s -> %s  %s%s\n", e.BlockNumber, orDash(e.ERC20.Direction),
+			e.ERC20.Address, e.ERC20.Value, e.ERC20.FromAddress, e.ERC20.ToAddress, e.TxHash, state)
+	}
+	return err
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
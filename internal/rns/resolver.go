@@ -0,0 +1,187 @@
+package rns
+
+import (
+	"cmd/internal/cache"
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Suffix is the top level domain of Ronin Name Service names
+const Suffix = ".ron"
+
+// DefaultResolverAddress is the RNS public resolver on Ronin mainnet
+// override with RNS_RESOLVER_ADDRESS if it's ever redeployed
+const DefaultResolverAddress = "0xadb077d236d9e81fb24b96ae9cb8089ab9942d48"
+
+// DefaultCacheTTL is how long lookups, including misses, are kept
+const DefaultCacheTTL = time.Hour
+
+// ErrNotFound is returned when a name has no address, or an address has no primary name
+var ErrNotFound = errors.New("rns: no record")
+
+// Function selectors, same interface as ENS resolvers
+var (
+	selectorAddr = ronin.Selector("addr(bytes32)")
+	selectorName = ronin.Selector("name(bytes32)")
+)
+
+// Resolver struct resolves .ron names via eth_call against the RNS resolver contract
+type Resolver struct {
+	rpc          *ronin.RPCClient
+	resolverAddr address.Address
+	forward      *cache.TTL[string, address.Address]
+	reverse      *cache.TTL[address.Address, string]
+	logger       *logger.Logger
+}
+
+// NewResolver func creates a resolver
+// resolverAddr may be empty for the mainnet default, ttl <= 0 uses DefaultCacheTTL
+func NewResolver(rpc *ronin.RPCClient, resolverAddr string, ttl time.Duration, log *logger.Logger) (*Resolver, error) {
+	if resolverAddr == "" {
+		resolverAddr = DefaultResolverAddress
+	}
+	addr, err := address.Parse(resolverAddr)
+	if err != nil {
+		return nil, fmt.Errorf("rns resolver address: %w", err)
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &Resolver{
+		rpc:          rpc,
+		resolverAddr: addr,
+		forward:      cache.NewTTL[string, address.Address]("rns_forward", ttl),
+		reverse:      cache.NewTTL[address.Address, string]("rns_reverse", ttl),
+		logger:       log.WithGroup("rns"),
+	}, nil
+}
+
+// IsName reports whether s looks like a .ron name rather than an address
+func IsName(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return len(s) > len(Suffix) && strings.HasSuffix(s, Suffix)
+}
+
+// Resolve
+// Explanation -> turns name.ron into the address it points to
+// Return -> the address, or ErrNotFound if the name isn't set
+func (r *Resolver) Resolve(ctx context.Context, name string) (address.Address, error) {
+	name = normalize(name)
+	if cached, ok := r.forward.Get(name); ok {
+		if cached.IsZero() {
+			return cached, fmt.Errorf("%w for %s", ErrNotFound, name)
+		}
+		return cached, nil
+	}
+
+	data, err := r.rpc.EthCall(ctx, r.resolverAddr, ronin.EncodeCall(selectorAddr, Namehash(name)))
+	if err != nil {
+		return address.Address{}, fmt.Errorf("resolving %s: %w", name, err)
+	}
+
+	// an empty result means the resolver has no record, same as the zero address
+	var addr address.Address
+	if len(data) > 0 {
+		addr, err = ronin.DecodeAddress(data, 0)
+		if err != nil {
+			return address.Address{}, fmt.Errorf("resolving %s: %w", name, err)
+		}
+	}
+
+	r.forward.Set(name, addr)
+	r.logger.DebugContext(ctx, "Resolved RNS name",
+		"name", name,
+		"address", addr.Hex(),
+	)
+
+	if addr.IsZero() {
+		return addr, fmt.Errorf("%w for %s", ErrNotFound, name)
+	}
+	return addr, nil
+}
+
+// LookupName
+// Explanation -> reverse resolves an address to its primary .ron name
+// the name is only trusted if it resolves back to the same address
+// Return -> the name, or ErrNotFound if there's no (valid) primary name
+func (r *Resolver) LookupName(ctx context.Context, addr address.Address) (string, error) {
+	if cached, ok := r.reverse.Get(addr); ok {
+		if cached == "" {
+			return "", fmt.Errorf("%w for %s", ErrNotFound, addr.Hex())
+		}
+		return cached, nil
+	}
+
+	node := Namehash(strings.TrimPrefix(addr.Lower(), "0x") + ".addr.reverse")
+	data, err := r.rpc.EthCall(ctx, r.resolverAddr, ronin.EncodeCall(selectorName, node))
+	if err != nil {
+		return "", fmt.Errorf("reverse resolving %s: %w", addr.Hex(), err)
+	}
+
+	var name string
+	if len(data) > 0 {
+		name, err = ronin.DecodeString(data, 0)
+		if err != nil {
+			return "", fmt.Errorf("reverse resolving %s: %w", addr.Hex(), err)
+		}
+	}
+
+	// anyone can set any reverse record on their own address, so check it points back
+	if name != "" {
+		forward, err := r.Resolve(ctx, name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+		if forward != addr {
+			r.logger.DebugContext(ctx, "RNS reverse record doesn't resolve back, ignoring",
+				"address", addr.Hex(),
+				"name", name,
+			)
+			name = ""
+		}
+	}
+
+	r.reverse.Set(addr, name)
+	if name == "" {
+		return "", fmt.Errorf("%w for %s", ErrNotFound, addr.Hex())
+	}
+	return name, nil
+}
+
+// ResolveInput
+// Explanation -> accepts either a .ron name or an address in any form pkg/address takes
+// Return -> the address
+func (r *Resolver) ResolveInput(ctx context.Context, input string) (address.Address, error) {
+	if IsName(input) {
+		return r.Resolve(ctx, input)
+	}
+	return address.Parse(input)
+}
+
+// Namehash implements the ENS namehash algorithm, which RNS uses as is
+func Namehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = ronin.Keccak256(node, ronin.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// normalize lowercases and trims a name
+// full UTS-46 normalization isn't done, RNS only allows lowercase a-z, 0-9 and - anyway
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
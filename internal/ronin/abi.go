@@ -0,0 +1,127 @@
+package ronin
+
+import (
+	"cmd/pkg/address"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Just enough ABI encoding for the read-only calls we make, no need for go-ethereum
+
+// wordSize is the size of an ABI word in bytes
+const wordSize = 32
+
+// ErrShortData is returned when return data is shorter than the ABI layout needs
+var ErrShortData = errors.New("abi: return data too short")
+
+// Keccak256 hashes the concatenation of data
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Selector returns the 4 byte function selector, e.g. Selector("addr(bytes32)")
+func Selector(signature string) []byte {
+	return Keccak256([]byte(signature))[:4]
+}
+
+// EncodeCall builds calldata from a selector and static 32 byte arguments
+func EncodeCall(selector []byte, args ...[]byte) []byte {
+	data := make([]byte, 0, len(selector)+len(args)*wordSize)
+	data = append(data, selector...)
+	for _, arg := range args {
+		data = append(data, LeftPad(arg)...)
+	}
+	return data
+}
+
+// LeftPad pads b to a full ABI word, the layout for addresses and uints
+func LeftPad(b []byte) []byte {
+	if len(b) >= wordSize {
+		return b[len(b)-wordSize:]
+	}
+	word := make([]byte, wordSize)
+	copy(word[wordSize-len(b):], b)
+	return word
+}
+
+// EncodeUint encodes an unsigned integer as an ABI word
+func EncodeUint(n *big.Int) []byte {
+	return LeftPad(n.Bytes())
+}
+
+// EncodeAddress encodes an address as an ABI word
+func EncodeAddress(a address.Address) []byte {
+	return LeftPad(a[:])
+}
+
+// DecodeAddress reads the address in the ABI word at index
+func DecodeAddress(data []byte, index int) (address.Address, error) {
+	var a address.Address
+
+	word, err := wordAt(data, index)
+	if err != nil {
+		return a, err
+	}
+	copy(a[:], word[wordSize-address.Length:])
+	return a, nil
+}
+
+// DecodeUint reads the uint256 in the ABI word at index
+func DecodeUint(data []byte, index int) (*big.Int, error) {
+	word, err := wordAt(data, index)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(word), nil
+}
+
+// DecodeString reads a dynamic string whose offset sits in the ABI word at index
+func DecodeString(data []byte, index int) (string, error) {
+	offset, err := DecodeUint(data, index)
+	if err != nil {
+		return "", err
+	}
+	if !offset.IsUint64() || offset.Uint64()+wordSize > uint64(len(data)) {
+		return "", ErrShortData
+	}
+
+	start := offset.Uint64()
+	length := new(big.Int).SetBytes(data[start : start+wordSize])
+	if !length.IsUint64() || start+wordSize+length.Uint64() > uint64(len(data)) {
+		return "", ErrShortData
+	}
+
+	begin := start + wordSize
+	return string(data[begin : begin+length.Uint64()]), nil
+}
+
+// ParseQuantity parses a JSON-RPC hex quantity like 0x1b4
+func ParseQuantity(s string) (uint64, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if trimmed == "" {
+		return 0, fmt.Errorf("empty quantity %q", s)
+	}
+	return strconv.ParseUint(trimmed, 16, 64)
+}
+
+// FormatQuantity renders n as a JSON-RPC hex quantity
+func FormatQuantity(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
+
+func wordAt(data []byte, index int) ([]byte, error) {
+	start := index * wordSize
+	if index < 0 || start+wordSize > len(data) {
+		return nil, ErrShortData
+	}
+	return data[start : start+wordSize], nil
+}
@@ -0,0 +1,161 @@
+package ronin
+
+import (
+	"bytes"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"cmd/pkg/requestid"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRPCURL is the public Ronin mainnet JSON-RPC endpoint
+const DefaultRPCURL = "https://api.roninchain.com/rpc"
+
+// RPCClient struct talks JSON-RPC to a Ronin node over HTTP
+type RPCClient struct {
+	httpClient *http.Client
+	url        string
+	nextID     atomic.Uint64
+	logger     *logger.Logger
+}
+
+// RPCError is an error returned by the node itself, e.g. an execution revert
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// NewRPCClient func creates a new client for the node at url
+// log is the application's root logger, the client logs under its own group
+func NewRPCClient(url string, log *logger.Logger) *RPCClient {
+	if url == "" {
+		url = DefaultRPCURL
+	}
+
+	return &RPCClient{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		url:        url,
+		logger:     log.WithGroup("ronin_rpc"),
+	}
+}
+
+// Call
+// Explanation -> makes a single JSON-RPC call and decodes the result into result
+// Return -> *RPCError for errors reported by the node, other errors for transport problems
+func (c *RPCClient) Call(ctx context.Context, method string, result any, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	c.logger.DebugContext(ctx, "RPC call completed",
+		"method", method,
+		"status_code", resp.StatusCode,
+		"duration", time.Since(start),
+	)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("calling %s: node returned status %d", method, resp.StatusCode)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("parsing %s response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("decoding %s result: %w", method, err)
+	}
+	return nil
+}
+
+// EthCall
+// Explanation -> runs a read-only contract call against the latest block
+// Return -> the raw ABI encoded return data
+func (c *RPCClient) EthCall(ctx context.Context, to address.Address, data []byte) ([]byte, error) {
+	call := map[string]string{
+		"to":   to.Hex(),
+		"data": "0x" + hex.EncodeToString(data),
+	}
+
+	var result string
+	if err := c.Call(ctx, "eth_call", &result, call, "latest"); err != nil {
+		return nil, err
+	}
+
+	return DecodeHex(result)
+}
+
+// BlockNumber returns the latest block number
+func (c *RPCClient) BlockNumber(ctx context.Context) (uint64, error) {
+	var result string
+	if err := c.Call(ctx, "eth_blockNumber", &result); err != nil {
+		return 0, err
+	}
+	return ParseQuantity(result)
+}
+
+// DecodeHex decodes 0x prefixed hex data
+func DecodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"cmd/pkg/address"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errInvalidToken is every reason a token is refused, callers aren't told which
+var errInvalidToken = errors.New("invalid or expired token")
+
+// claims are the JWT claims the push endpoints look at
+type claims struct {
+	Subject   string   `json:"sub"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Wallets   []string `json:"wallets"` // wallets the holder may watch, empty for any
+}
+
+// verifyJWT
+// Explanation -> checks an HS256 JWT signed with secret and its exp/nbf times, allowing a
+// minute of clock skew. Only HS256 is accepted, whatever the header asks for, and exp is
+// required: a leaked token without one would be good for as long as the secret is
+// Return -> the token's claims, errInvalidToken for anything wrong with it
+func verifyJWT(token, secret string, now time.Time) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || secret == "" {
+		return nil, errInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errInvalidToken
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, errInvalidToken
+	}
+	const skew = time.Minute
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(skew)) {
+		return nil, errInvalidToken
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-skew)) {
+		return nil, errInvalidToken
+	}
+	return &c, nil
+}
+
+// decodeSegment decodes one base64url JSON part of a JWT into v
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// bearerToken is the request's JWT, from "Authorization: Bearer" or the access_token query
+// parameter, browsers can't set headers on EventSource or WebSocket
+func bearerToken(r *http.Request) string {
+	if token := headerToken(r); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// headerToken is the JWT in "Authorization: Bearer" only
+func headerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// allows reports whether the token lets its holder watch wallet
+func (c *claims) allows(wallet string) bool {
+	if len(c.Wallets) == 0 {
+		return true
+	}
+	for _, w := range c.Wallets {
+		if address.Equal(w, wallet) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin
+// Explanation -> guards an endpoint that changes state behind a JWT signed with JWT_SECRET,
+// one not limited to wallets, those are handed to dashboards to watch with. The token must
+// be in the header, URLs end up in access logs. Without a secret the endpoint refuses every
+// call, it would be open to whoever reaches the port
+// Return -> the guarded handler
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.jwtSecret == "" {
+			s.writeError(w, r, http.StatusForbidden, "changes are disabled, set JWT_SECRET to allow them")
+			return
+		}
+		c, err := verifyJWT(headerToken(r), s.jwtSecret, time.Now())
+		if err != nil {
+			s.writeError(w, r, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if len(c.Wallets) > 0 {
+			s.writeError(w, r, http.StatusForbidden, "token is limited to wallets")
+			return
+		}
+		next(w, r)
+	}
+}
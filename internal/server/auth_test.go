@@ -0,0 +1,123 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "jwt-secret"
+
+var jwtNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// makeJWT signs claims under header with secret, HS512 headers are signed with SHA-512
+func makeJWT(header, claims map[string]any, secret string) string {
+	seg := func(v any) string {
+		raw, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	unsigned := seg(header) + "." + seg(claims)
+	h := sha256.New
+	if header["alg"] == "HS512" {
+		h = func() hash.Hash { return sha512.New() }
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var hs256 = map[string]any{"alg": "HS256", "typ": "JWT"}
+
+// unix is jwtNow moved by d, in seconds
+func unix(d time.Duration) int64 {
+	return jwtNow.Add(d).Unix()
+}
+
+func TestVerifyJWT(t *testing.T) {
+	valid := map[string]any{"sub": "dash", "exp": unix(time.Hour)}
+	signed := makeJWT(hs256, valid, testJWTSecret)
+	none := makeJWT(map[string]any{"alg": "none"}, valid, testJWTSecret)
+	forged, _ := json.Marshal(map[string]any{"sub": "ops", "exp": unix(time.Hour)})
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signed, true},
+		{"alg none", none, false},
+		{"alg none unsigned", none[:strings.LastIndex(none, ".")+1], false},
+		{"alg HS512", makeJWT(map[string]any{"alg": "HS512"}, valid, testJWTSecret), false},
+		{"bad signature", makeJWT(hs256, valid, "other-secret"), false},
+		{"claims swapped after signing", strings.Replace(signed, strings.Split(signed, ".")[1], base64.RawURLEncoding.EncodeToString(forged), 1), false},
+		{"no exp", makeJWT(hs256, map[string]any{"sub": "dash"}, testJWTSecret), false},
+		{"expired within skew", makeJWT(hs256, map[string]any{"exp": unix(-30 * time.Second)}, testJWTSecret), true},
+		{"expired beyond skew", makeJWT(hs256, map[string]any{"exp": unix(-2 * time.Minute)}, testJWTSecret), false},
+		{"not yet valid within skew", makeJWT(hs256, map[string]any{"exp": unix(time.Hour), "nbf": unix(30 * time.Second)}, testJWTSecret), true},
+		{"not yet valid beyond skew", makeJWT(hs256, map[string]any{"exp": unix(time.Hour), "nbf": unix(2 * time.Minute)}, testJWTSecret), false},
+		{"two parts", "a.b", false},
+		{"garbage", "not-a-token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := verifyJWT(tt.token, testJWTSecret, jwtNow)
+			if tt.ok && err != nil {
+				t.Errorf("verifyJWT = %v, want accepted", err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("verifyJWT accepted %+v", c)
+			}
+		})
+	}
+
+	if c, err := verifyJWT(signed, testJWTSecret, jwtNow); err != nil || c.Subject != "dash" {
+		t.Errorf("claims = %+v, %v, want sub dash", c, err)
+	}
+	if _, err := verifyJWT(makeJWT(hs256, valid, ""), "", jwtNow); err == nil {
+		t.Error("verifyJWT accepted a token with no secret configured")
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	admin := makeJWT(hs256, map[string]any{"sub": "ops", "exp": exp}, testJWTSecret)
+	watcher := makeJWT(hs256, map[string]any{"sub": "dash", "exp": exp, "wallets": []string{"0x1111111111111111111111111111111111111111"}}, testJWTSecret)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		query  string
+		want   int
+	}{
+		{"no secret configured", "", "Bearer " + admin, "", http.StatusForbidden},
+		{"admin token", testJWTSecret, "Bearer " + admin, "", http.StatusNoContent},
+		{"no token", testJWTSecret, "", "", http.StatusUnauthorized},
+		{"token in the query", testJWTSecret, "", "?access_token=" + admin, http.StatusUnauthorized},
+		{"wallet token", testJWTSecret, "Bearer " + watcher, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{jwtSecret: tt.secret}
+			h := s.requireAdmin(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/guild/scholars"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"cmd/internal/guild"
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// addScholarRequest is the body of POST /v1/guild/scholars
+type addScholarRequest struct {
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	ManagerShare *int   `json:"manager_share"` // omitted for guild.DefaultManagerShare
+}
+
+// axiesRequest is the body of the assign and unassign endpoints
+type axiesRequest struct {
+	AxieIDs []string `json:"axie_ids"`
+}
+
+// handleScholars lists the guild's scholars and their assigned Axies
+// GET /v1/guild/scholars
+func (s *Server) handleScholars(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, s.guild.List())
+}
+
+// handleAddScholar adds a scholar, or updates the one at that address
+// POST /v1/guild/scholars {"name": "Ana", "address": "ronin:...", "manager_share": 30}
+func (s *Server) handleAddScholar(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+
+	var body addScholarRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	share := -1
+	if body.ManagerShare != nil {
+		share = *body.ManagerShare
+		if share < 0 {
+			s.writeError(w, r, http.StatusBadRequest, guild.ErrInvalidShare.Error())
+			return
+		}
+	}
+
+	sc, err := s.guild.Add(body.Name, body.Address, share)
+	if err != nil {
+		s.writeGuildError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, sc)
+}
+
+// handleRemoveScholar removes a scholar by name or address
+// DELETE /v1/guild/scholars/{scholar}
+func (s *Server) handleRemoveScholar(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+	if err := s.guild.Remove(mux.Vars(r)["scholar"]); err != nil {
+		s.writeGuildError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAssignAxies assigns Axies to a scholar, moving them off anyone else
+// POST /v1/guild/scholars/{scholar}/axies {"axie_ids": ["123", "456"]}
+func (s *Server) handleAssignAxies(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+
+	var body axiesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	sc, err := s.guild.Assign(mux.Vars(r)["scholar"], body.AxieIDs)
+	if err != nil {
+		s.writeGuildError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, sc)
+}
+
+// handleUnassignAxies takes Axies off whichever scholar has them
+// DELETE /v1/guild/axies {"axie_ids": ["123"]}
+func (s *Server) handleUnassignAxies(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+
+	var body axiesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	removed, err := s.guild.Unassign(body.AxieIDs)
+	if err != nil {
+		s.writeGuildError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, map[string]int{"unassigned": removed})
+}
+
+// handleReconcile checks every scholar's account against their assignments
+// GET /v1/guild/reconcile
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if s.guild == nil {
+		s.writeError(w, r, http.StatusNotFound, "guild is not enabled")
+		return
+	}
+
+	report, err := s.nftService.ReconcileGuild(r.Context(), s.guild.List())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Guild reconcile failed",
+			"error", err,
+		)
+		s.writeError(w, r, http.StatusBadGateway, "failed to reconcile guild")
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, report)
+}
+
+// writeGuildError maps guild store errors to a status
+func (s *Server) writeGuildError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, guild.ErrNotFound):
+		s.writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, guild.ErrDuplicate):
+		s.writeError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, guild.ErrInvalidShare), errors.Is(err, guild.ErrInvalidAxieID), errors.Is(err, address.ErrInvalid):
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		s.logger.ErrorContext(r.Context(), "Guild update failed",
+			"error", err,
+		)
+		s.writeError(w, r, http.StatusInternalServerError, "failed to update guild")
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"cmd/internal/media"
+	"cmd/internal/models"
+	"cmd/pkg/address"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// handleMedia serves a token's image from the media library, downloading it on first request
+// GET /v1/media/{token_address}/{token_id}?size=thumb (or original, the default)
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	if s.media == nil {
+		s.writeError(w, r, http.StatusNotFound, "media library is not enabled")
+		return
+	}
+
+	vars := mux.Vars(r)
+	tokenAddr, tokenID := vars["token_address"], vars["token_id"]
+	if !address.Valid(tokenAddr) {
+		s.writeError(w, r, http.StatusBadRequest, "invalid token address")
+		return
+	}
+
+	size := r.URL.Query().Get("size")
+	if size != "" && size != "thumb" && size != "original" {
+		s.writeError(w, r, http.StatusBadRequest, "size must be thumb or original")
+		return
+	}
+
+	library := s.media.Library()
+	entry, ok := library.Lookup(tokenAddr, tokenID)
+	if !ok || !library.Exists(entry) {
+		var err error
+		if entry, err = s.downloadMedia(r, tokenAddr, tokenID); err != nil {
+			s.logger.WarnContext(r.Context(), "Media download failed",
+				"error", err,
+				"token_address", tokenAddr,
+				"token_id", tokenID,
+			)
+			s.writeError(w, r, http.StatusNotFound, "no media for this token")
+			return
+		}
+	}
+
+	file, contentType := entry.Path, entry.MIME
+	if size == "thumb" && entry.Thumbnail != "" {
+		file = entry.Thumbnail
+		contentType = "image/jpeg"
+		if strings.HasSuffix(file, ".png") {
+			contentType = "image/png"
+		}
+	}
+
+	f, err := os.Open(library.Path(file))
+	if err != nil {
+		s.writeError(w, r, http.StatusNotFound, "no media for this token")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		s.writeError(w, r, http.StatusInternalServerError, "failed to read media")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// files are named by their hash, what's at a path never changes
+	w.Header().Set("ETag", `"`+strings.TrimSuffix(path.Base(file), path.Ext(file))+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if contentType == "image/svg+xml" {
+		// SVGs can carry scripts, opened directly they'd run on our origin
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// downloadMedia looks the token up and downloads its image into the library
+func (s *Server) downloadMedia(r *http.Request, tokenAddr, tokenID string) (media.Entry, error) {
+	nfts, err := s.nftService.GetSpecficNFTs(r.Context(), []models.TokenRequest{
+		{TokenAddress: tokenAddr, TokenID: tokenID},
+	})
+	if err != nil {
+		return media.Entry{}, err
+	}
+	if len(nfts) == 0 {
+		return media.Entry{}, media.ErrNoImage
+	}
+
+	result, err := s.media.Download(r.Context(), nfts[:1])
+	if err != nil {
+		return media.Entry{}, err
+	}
+	if len(result.Entries) == 0 {
+		if len(result.Failures) > 0 {
+			return media.Entry{}, errors.New(result.Failures[0].Error)
+		}
+		return media.Entry{}, media.ErrNoImage
+	}
+	return result.Entries[0], nil
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"cmd/internal/collections"
+	"cmd/internal/models"
+	"cmd/internal/service"
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Upper bounds for a single API call, Moralis caps page size at 100 anyway
+const (
+	maxLimit          = 100
+	maxTokensPerQuery = 25
+)
+
+// handleHealth reports the server is up
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleWalletNFTs
+// GET /v1/wallets/{address}/nfts?limit=10&exclude_spam=true&class=Aquatic&part=Mouth:Risky+Fish
+// &filter=rarity_rank+<+1000+%26%26+verified
+func (s *Server) handleWalletNFTs(w http.ResponseWriter, r *http.Request) {
+	walletAddr := mux.Vars(r)["address"]
+	if !address.Valid(walletAddr) {
+		s.writeError(w, r, http.StatusBadRequest, "invalid wallet address")
+		return
+	}
+
+	params, err := parseQueryParams(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nfts, err := s.nftService.GetNFTsByWallet(r.Context(), walletAddr, params)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Wallet NFTs request failed",
+			"error", err,
+			"wallet_address", walletAddr,
+		)
+		s.writeError(w, r, http.StatusBadGateway, "failed to fetch NFTs")
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, nfts)
+}
+
+// handlePortfolio
+// GET /v1/wallets/{address}/portfolio?exclude_spam=true, takes the same filters as nfts
+func (s *Server) handlePortfolio(w http.ResponseWriter, r *http.Request) {
+	walletAddr := mux.Vars(r)["address"]
+	if !address.Valid(walletAddr) {
+		s.writeError(w, r, http.StatusBadRequest, "invalid wallet address")
+		return
+	}
+
+	params, err := parseQueryParams(r)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	// a portfolio is the whole wallet unless a limit was asked for
+	if r.URL.Query().Get("limit") == "" {
+		params.Limit = 0
+	}
+
+	portfolio, err := s.nftService.GetPortfolio(r.Context(), walletAddr, params)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Portfolio request failed",
+			"error", err,
+			"wallet_address", walletAddr,
+		)
+		s.writeError(w, r, http.StatusBadGateway, "failed to value portfolio")
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, portfolio)
+}
+
+// handleCollections lists the tracked collections from the registry file
+// GET /v1/collections
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	list := s.nftService.Collections()
+	if list == nil {
+		list = []collections.Entry{}
+	}
+	s.writeJSON(w, r, http.StatusOK, list)
+}
+
+// specificNFTsRequest is the body of POST /v1/nfts
+type specificNFTsRequest struct {
+	Tokens []models.TokenRequest `json:"tokens"`
+}
+
+// handleSpecificNFTs
+// POST /v1/nfts {"tokens": [{"token_address": "0x...", "token_id": "1"}]}
+func (s *Server) handleSpecificNFTs(w http.ResponseWriter, r *http.Request) {
+	var body specificNFTsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if len(body.Tokens) == 0 || len(body.Tokens) > maxTokensPerQuery {
+		s.writeError(w, r, http.StatusBadRequest, "tokens must contain between 1 and 25 entries")
+		return
+	}
+
+	nfts, err := s.nftService.GetSpecficNFTs(r.Context(), body.Tokens)
+	if errors.Is(err, address.ErrInvalid) {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Specific NFTs request failed",
+			"error", err,
+			"tokens", len(body.Tokens),
+		)
+		s.writeError(w, r, http.StatusBadGateway, "failed to fetch NFTs")
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, nfts)
+}
+
+// handleResync asks Moralis to refresh token metadata, it happens in the background
+// POST /v1/nfts/resync {"tokens": [{"token_address": "0x...", "token_id": "1"}]}
+func (s *Server) handleResync(w http.ResponseWriter, r *http.Request) {
+	var body specificNFTsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if len(body.Tokens) == 0 || len(body.Tokens) > maxTokensPerQuery {
+		s.writeError(w, r, http.StatusBadRequest, "tokens must contain between 1 and 25 entries")
+		return
+	}
+
+	err := s.nftService.ResyncMetadata(r.Context(), body.Tokens)
+	if errors.Is(err, address.ErrInvalid) {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Metadata resync failed",
+			"error", err,
+			"tokens", len(body.Tokens),
+		)
+		s.writeError(w, r, http.StatusBadGateway, "failed to request metadata resync")
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusAccepted, map[string]int{"requested": len(body.Tokens)})
+}
+
+// parseQueryParams reads the NFT query params shared by list endpoints
+func parseQueryParams(r *http.Request) (models.QueryParams, error) {
+	query := r.URL.Query()
+	params := models.QueryParams{Limit: 10}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return params, errInvalidParam("limit")
+		}
+		params.Limit = limit
+	}
+
+	if v := query.Get("exclude_spam"); v != "" {
+		excludeSpam, err := strconv.ParseBool(v)
+		if err != nil {
+			return params, errInvalidParam("exclude_spam")
+		}
+		params.ExcludeSpam = excludeSpam
+	}
+
+	if v := query.Get("include_prices"); v != "" {
+		includePrices, err := strconv.ParseBool(v)
+		if err != nil {
+			return params, errInvalidParam("include_prices")
+		}
+		params.IncludePrices = includePrices
+	}
+
+	if v := query.Get("media_items"); v != "" {
+		mediaItems, err := strconv.ParseBool(v)
+		if err != nil {
+			return params, errInvalidParam("media_items")
+		}
+		params.MediaItems = mediaItems
+	}
+
+	// Axie filters, part can be repeated
+	params.AxieClass = query.Get("class")
+	params.AxieParts = query["part"]
+
+	if v := query.Get("all_collections"); v != "" {
+		all, err := strconv.ParseBool(v)
+		if err != nil {
+			return params, errInvalidParam("all_collections")
+		}
+		params.AllCollections = all
+	}
+
+	// filter expression and spam mode, parsed (and rejected with a 400) by the service
+	params.Filter = query.Get("filter")
+	params.SpamMode = query.Get("spam")
+
+	return params, nil
+}
+
+// errInvalidParam is returned for query params that fail to parse
+type errInvalidParam string
+
+func (e errInvalidParam) Error() string {
+	return "invalid query parameter: " + string(e)
+}
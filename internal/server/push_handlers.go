@@ -0,0 +1,395 @@
+package server
+
+import (
+	"cmd/internal/events"
+	"cmd/internal/metrics"
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Live event connections
+const (
+	pushBuffer       = 64               // events a client may fall behind before it's cut off
+	pushWriteTimeout = 10 * time.Second // a write stuck this long means the client stopped reading
+	pushHeartbeat    = 25 * time.Second // under the usual 30s proxy idle timeout
+	pushReadTimeout  = 2 * pushHeartbeat
+	pushMaxMessage   = 4 << 10 // subscribe messages are small
+)
+
+// errWalletNotAllowed means the client asked for a wallet its token doesn't cover
+var errWalletNotAllowed = errors.New("token does not allow wallet")
+
+// pushTypes are the event types clients can ask for
+var pushTypes = map[string]bool{
+	events.TypeNFTIn:         true,
+	events.TypeNFTOut:        true,
+	events.TypeTokenTransfer: true,
+	events.TypePriceChange:   true,
+}
+
+// upgrader accepts WebSocket connections from any origin, a connection is authorised by its
+// token rather than by cookies, so another site can't ride on a user's session
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// liveFilter is which events one connection wants, WebSocket clients change it as they go
+type liveFilter struct {
+	claims *claims
+
+	mu      sync.RWMutex
+	wallets map[string]bool // lowercase 0x, empty for every wallet the token allows
+	types   map[string]bool // empty for all
+}
+
+// match is the connection's hub filter. Price moves concern no wallet and go to everyone
+// who takes their type
+func (f *liveFilter) match(e events.Event) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.types) > 0 && !f.types[e.Type] {
+		return false
+	}
+	if e.Wallet == "" {
+		return true
+	}
+	if len(f.wallets) > 0 {
+		return f.wallets[strings.ToLower(e.Wallet)]
+	}
+	return f.claims.allows(e.Wallet)
+}
+
+// add
+// Explanation -> adds wallets and types to the filter, all or nothing
+// Return -> an error for a bad address or type, or a wallet the token doesn't allow
+func (f *liveFilter) add(wallets, types []string) error {
+	keys, err := f.walletKeys(wallets)
+	if err != nil {
+		return err
+	}
+	for _, t := range types {
+		if !pushTypes[t] {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		f.wallets[k] = true
+	}
+	for _, t := range types {
+		f.types[t] = true
+	}
+	return nil
+}
+
+// remove takes wallets and types out of the filter
+func (f *liveFilter) remove(wallets, types []string) error {
+	keys, err := f.walletKeys(wallets)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.wallets, k)
+	}
+	for _, t := range types {
+		delete(f.types, t)
+	}
+	return nil
+}
+
+// walletKeys checks wallets and returns their filter keys
+func (f *liveFilter) walletKeys(wallets []string) ([]string, error) {
+	keys := make([]string, 0, len(wallets))
+	for _, w := range wallets {
+		addr, err := address.Parse(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wallet address %q", w)
+		}
+		if !f.claims.allows(addr.Hex()) {
+			return nil, fmt.Errorf("%w %s", errWalletNotAllowed, addr.Hex())
+		}
+		keys = append(keys, addr.Lower())
+	}
+	return keys, nil
+}
+
+// current is the filter as the client sees it
+func (f *liveFilter) current() (wallets, types []string) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for w := range f.wallets {
+		wallets = append(wallets, w)
+	}
+	for t := range f.types {
+		types = append(types, t)
+	}
+	return wallets, types
+}
+
+// listParam reads a query parameter given repeated and/or comma separated
+func listParam(r *http.Request, name string) []string {
+	var out []string
+	for _, v := range r.URL.Query()[name] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// openLive
+// Explanation -> the part both push endpoints share: checks they're on, authenticates the
+// token and builds the connection's filter from ?wallet= and ?type=. Writes the error
+// response itself
+// Return -> the filter, nil if the request was refused
+func (s *Server) openLive(w http.ResponseWriter, r *http.Request) *liveFilter {
+	if s.hub == nil || s.jwtSecret == "" {
+		s.writeError(w, r, http.StatusNotFound, "live events are not enabled")
+		return nil
+	}
+	c, err := verifyJWT(bearerToken(r), s.jwtSecret, time.Now())
+	if err != nil {
+		s.writeError(w, r, http.StatusUnauthorized, err.Error())
+		return nil
+	}
+
+	f := &liveFilter{claims: c, wallets: make(map[string]bool), types: make(map[string]bool)}
+	if err := f.add(listParam(r, "wallet"), listParam(r, "type")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errWalletNotAllowed) {
+			status = http.StatusForbidden
+		}
+		s.writeError(w, r, status, err.Error())
+		return nil
+	}
+	return f
+}
+
+// handleEventsSSE streams live events as Server-Sent Events, one `event: <type>` per event
+// with the event JSON as data. A client that falls pushBuffer events behind gets an
+// `overflow` event and is disconnected, it should refetch over REST and reconnect
+// GET /v1/events?wallet=0x...&type=nft_in,price_change&access_token=...
+func (s *Server) handleEventsSSE(w http.ResponseWriter, r *http.Request) {
+	f := s.openLive(w, r)
+	if f == nil {
+		return
+	}
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	sub := s.hub.SubscribeFunc(pushBuffer, f.match)
+	defer sub.Close()
+	metrics.AddPushClient("sse", 1)
+	defer metrics.AddPushClient("sse", -1)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would hold events back otherwise
+	w.WriteHeader(http.StatusOK)
+
+	// send writes one frame and flushes it, the deadline replaces the server's WriteTimeout
+	send := func(frame string) error {
+		rc.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+		if _, err := fmt.Fprint(w, frame); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	wallets, types := f.current()
+	s.logger.InfoContext(ctx, "Live events client connected",
+		"transport", "sse",
+		"subject", f.claims.Subject,
+		"wallets", wallets,
+		"types", types,
+	)
+	if err := send("retry: 5000\n\n"); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(pushHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var frame string
+		select {
+		case e := <-sub.Events():
+			if dropped := sub.Dropped(); dropped > 0 {
+				s.pushOverflow(r, "sse", f, dropped)
+				send(fmt.Sprintf("event: overflow\ndata: {\"dropped\":%d}\n\n", dropped))
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			frame = fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		case <-heartbeat.C:
+			frame = ": ping\n\n"
+		case <-s.shuttingDown:
+			return
+		case <-ctx.Done():
+			return
+		}
+		if err := send(frame); err != nil {
+			s.logger.InfoContext(ctx, "Live events client gone",
+				"transport", "sse",
+				"error", err,
+			)
+			return
+		}
+	}
+}
+
+// liveMessage is what a WebSocket client sends to change its filter, e.g.
+// {"action":"subscribe","wallets":["0x..."],"types":["nft_in"]}
+type liveMessage struct {
+	Action  string   `json:"action"` // subscribe or unsubscribe
+	Wallets []string `json:"wallets"`
+	Types   []string `json:"types"`
+}
+
+// liveReply answers a liveMessage, with the filter as it now stands
+type liveReply struct {
+	Type    string   `json:"type"` // subscribed or error
+	Wallets []string `json:"wallets,omitempty"`
+	Types   []string `json:"types,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// handleEventsWS streams live events over a WebSocket, one JSON event per text message. The
+// client starts from ?wallet= and ?type= like SSE and can subscribe and unsubscribe as it
+// goes. A client that falls pushBuffer events behind is closed with 1013 (try again later)
+// GET /v1/events/ws?wallet=0x...&access_token=...
+func (s *Server) handleEventsWS(w http.ResponseWriter, r *http.Request) {
+	f := s.openLive(w, r)
+	if f == nil {
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has answered already
+	}
+	defer conn.Close()
+	ctx := r.Context()
+
+	sub := s.hub.SubscribeFunc(pushBuffer, f.match)
+	defer sub.Close()
+	metrics.AddPushClient("websocket", 1)
+	defer metrics.AddPushClient("websocket", -1)
+
+	wallets, types := f.current()
+	s.logger.InfoContext(ctx, "Live events client connected",
+		"transport", "websocket",
+		"subject", f.claims.Subject,
+		"wallets", wallets,
+		"types", types,
+	)
+
+	// the reader handles filter changes and pongs, only this goroutine writes
+	replies := make(chan liveReply, 8)
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		conn.SetReadLimit(pushMaxMessage)
+		conn.SetReadDeadline(time.Now().Add(pushReadTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pushReadTimeout))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg liveMessage
+			reply := liveReply{Type: "subscribed"}
+			switch err = json.Unmarshal(data, &msg); {
+			case err != nil:
+				err = errors.New("invalid JSON message")
+			case msg.Action == "subscribe":
+				err = f.add(msg.Wallets, msg.Types)
+			case msg.Action == "unsubscribe":
+				err = f.remove(msg.Wallets, msg.Types)
+			default:
+				err = errors.New(`action must be "subscribe" or "unsubscribe"`)
+			}
+			if err != nil {
+				reply = liveReply{Type: "error", Error: err.Error()}
+			} else {
+				reply.Wallets, reply.Types = f.current()
+			}
+			select {
+			case replies <- reply:
+			default: // the client isn't reading what it asks for, it will overflow soon enough
+			}
+		}
+	}()
+
+	write := func(v any) error {
+		conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+		return conn.WriteJSON(v)
+	}
+	closeWith := func(code int, text string) {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text),
+			time.Now().Add(time.Second))
+	}
+
+	heartbeat := time.NewTicker(pushHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case e := <-sub.Events():
+			if dropped := sub.Dropped(); dropped > 0 {
+				s.pushOverflow(r, "websocket", f, dropped)
+				closeWith(websocket.CloseTryAgainLater, fmt.Sprintf("too slow, %d events missed", dropped))
+				return
+			}
+			err = write(e)
+		case reply := <-replies:
+			err = write(reply)
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pushWriteTimeout))
+		case <-gone:
+			return
+		case <-s.shuttingDown:
+			closeWith(websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			s.logger.InfoContext(ctx, "Live events client gone",
+				"transport", "websocket",
+				"error", err,
+			)
+			return
+		}
+	}
+}
+
+// pushOverflow logs and counts a client cut off for falling behind
+func (s *Server) pushOverflow(r *http.Request, transport string, f *liveFilter, dropped uint64) {
+	metrics.IncPushOverflow(transport)
+	s.logger.WarnContext(r.Context(), "Live events client too slow, disconnecting",
+		"transport", transport,
+		"subject", f.claims.Subject,
+		"dropped", dropped,
+	)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"cmd/pkg/requestid"
+	"encoding/json"
+	"net/http"
+)
+
+// envelope is the shape of every JSON response
+// request_id lets callers quote a failing call when reporting it
+type envelope struct {
+	RequestID string `json:"request_id,omitempty"`
+	Data      any    `json:"data,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// writeJSON writes data wrapped in the response envelope
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	s.write(w, r, status, envelope{
+		RequestID: requestid.FromContext(r.Context()),
+		Data:      data,
+	})
+}
+
+// writeError writes an error message wrapped in the response envelope
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	s.write(w, r, status, envelope{
+		RequestID: requestid.FromContext(r.Context()),
+		Error:     msg,
+	})
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request, status int, body envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write response",
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"cmd/internal/metrics"
+	"net/http"
+)
+
+// routes registers every endpoint and the middleware shared by all of them
+func (s *Server) routes() {
+	s.router.Use(s.requestIDMiddleware, s.tracingMiddleware, s.loggingMiddleware)
+
+	s.router.HandleFunc("/healthz", s.handleHealth).Methods(http.MethodGet)
+	s.router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	v1 := s.router.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/wallets/{address}/nfts", s.handleWalletNFTs).Methods(http.MethodGet)
+	v1.HandleFunc("/wallets/{address}/portfolio", s.handlePortfolio).Methods(http.MethodGet)
+	v1.HandleFunc("/nfts", s.handleSpecificNFTs).Methods(http.MethodPost)
+	v1.HandleFunc("/nfts/resync", s.handleResync).Methods(http.MethodPost)
+	v1.HandleFunc("/collections", s.handleCollections).Methods(http.MethodGet)
+	v1.HandleFunc("/media/{token_address}/{token_id}", s.handleMedia).Methods(http.MethodGet)
+
+	v1.HandleFunc("/guild/scholars", s.handleScholars).Methods(http.MethodGet)
+	v1.HandleFunc("/guild/scholars", s.requireAdmin(s.handleAddScholar)).Methods(http.MethodPost)
+	v1.HandleFunc("/guild/scholars/{scholar}", s.requireAdmin(s.handleRemoveScholar)).Methods(http.MethodDelete)
+	v1.HandleFunc("/guild/scholars/{scholar}/axies", s.requireAdmin(s.handleAssignAxies)).Methods(http.MethodPost)
+	v1.HandleFunc("/guild/axies", s.requireAdmin(s.handleUnassignAxies)).Methods(http.MethodDelete)
+	v1.HandleFunc("/guild/reconcile", s.handleReconcile).Methods(http.MethodGet)
+
+	v1.HandleFunc("/webhooks/moralis", s.handleStreamsWebhook).Methods(http.MethodPost)
+	v1.HandleFunc("/events", s.handleEventsSSE).Methods(http.MethodGet)
+	v1.HandleFunc("/events/ws", s.handleEventsWS).Methods(http.MethodGet)
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"cmd/internal/events"
+	"cmd/internal/guild"
+	"cmd/internal/media"
+	"cmd/internal/service"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Server struct exposes the services over HTTP
+type Server struct {
+	httpServer *http.Server
+	router     *mux.Router
+	nftService *service.NFTService
+	media      *media.Downloader
+	guild      *guild.Store
+	logger     *logger.Logger
+
+	// Moralis Streams webhooks
+	streamsSecret string
+	eventStore    *events.Store
+	hub           *events.Hub
+
+	// API tokens, for guild changes and live events over SSE and WebSocket
+	jwtSecret    string
+	shuttingDown chan struct{} // closed when shutdown starts, long lived streams end on it
+}
+
+// New func creates a new server listening on addr, e.g. ":8080"
+// log is the application's root logger, the server logs under its own group
+func New(addr string, nftService *service.NFTService, log *logger.Logger) *Server {
+	s := &Server{
+		router:     mux.NewRouter(),
+		nftService: nftService,
+		logger:     log.WithGroup("server"),
+
+		shuttingDown: make(chan struct{}),
+	}
+
+	s.routes()
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.router,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	// Shutdown waits for requests to finish and SSE streams never do by themselves
+	s.httpServer.RegisterOnShutdown(func() { close(s.shuttingDown) })
+
+	return s
+}
+
+// SetMediaDownloader turns on /v1/media, images missing from the library are downloaded on
+// first request. Without one the endpoint answers 404
+func (s *Server) SetMediaDownloader(d *media.Downloader) {
+	s.media = d
+}
+
+// SetGuild turns on the /v1/guild endpoints, without a store they answer 404
+func (s *Server) SetGuild(g *guild.Store) {
+	s.guild = g
+}
+
+// SetStreamsWebhook turns on /v1/webhooks/moralis: webhooks signed with secret are stored in
+// store and new events published to hub. Without a store the endpoint answers 404
+func (s *Server) SetStreamsWebhook(secret string, store *events.Store, hub *events.Hub) {
+	s.streamsSecret = secret
+	s.eventStore = store
+	s.hub = hub
+}
+
+// SetJWTSecret sets the key API tokens are signed with. It lets the guild be changed and
+// live events be watched, without one those answer 403 and 404
+func (s *Server) SetJWTSecret(secret string) {
+	s.jwtSecret = secret
+}
+
+// SetLiveEvents turns on /v1/events and /v1/events/ws, pushing what's published to hub to
+// clients holding a token, see SetJWTSecret
+func (s *Server) SetLiveEvents(hub *events.Hub) {
+	s.hub = hub
+}
+
+// Handler returns the root handler, with all middleware applied
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Run
+// Explanation -> serves until ctx is cancelled, then shuts down gracefully
+// Return -> error if the server couldn't start or shut down cleanly
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("HTTP server listening", "addr", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("HTTP server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"cmd/internal/events"
+	"cmd/internal/models"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxWebhookBody caps a webhook body, a busy block touching many watched wallets is large
+const maxWebhookBody = 10 << 20
+
+// webhookResult is what a webhook answers, for the Moralis dashboard's delivery log
+type webhookResult struct {
+	Received int `json:"received"` // transfers in the webhook
+	New      int `json:"new"`      // ones not seen before, stored and published
+}
+
+// handleStreamsWebhook receives a Moralis Streams webhook, stores the transfers not seen
+// before and publishes them to watchers. Moralis retries anything but a 2xx, storing is
+// idempotent so a retry after a partial failure is safe
+// POST /v1/webhooks/moralis
+func (s *Server) handleStreamsWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.eventStore == nil {
+		s.writeError(w, r, http.StatusNotFound, "webhooks are not enabled")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		s.writeError(w, r, http.StatusRequestEntityTooLarge, "body too large")
+		return
+	}
+	if !events.VerifySignature(body, r.Header.Get(events.SignatureHeader), s.streamsSecret) {
+		s.logger.WarnContext(r.Context(), "Rejected webhook with a bad signature",
+			"remote_addr", r.RemoteAddr,
+		)
+		s.writeError(w, r, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	var payload models.StreamWebhook
+	if err := json.Unmarshal(body, &payload); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	evs, err := events.FromWebhook(&payload, time.Now())
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := webhookResult{Received: len(evs)}
+	for _, e := range evs {
+		inserted, err := s.eventStore.Insert(r.Context(), e)
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "Failed to store webhook event",
+				"error", err,
+				"event_id", e.ID,
+			)
+			s.writeError(w, r, http.StatusInternalServerError, "storing events failed")
+			return
+		}
+		if inserted {
+			result.New++
+			if s.hub != nil {
+				s.hub.Publish(e)
+			}
+		}
+	}
+
+	s.logger.InfoContext(r.Context(), "Webhook processed",
+		"stream_id", payload.StreamID,
+		"block", payload.Block.Number,
+		"confirmed", payload.Confirmed,
+		"received", result.Received,
+		"new", result.New,
+	)
+	s.writeJSON(w, r, http.StatusOK, result)
+}
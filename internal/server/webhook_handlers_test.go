@@ -0,0 +1,95 @@
+package server
+
+import (
+	"cmd/internal/events"
+	"cmd/internal/ronin"
+	"cmd/pkg/logger"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testStreamsSecret = "s3cret"
+
+// blockWebhook is a block with one Axie received by a watched wallet
+const blockWebhook = `{
+	"confirmed": false,
+	"streamId": "stream-1",
+	"block": {"number": "1000", "hash": "0xb10c", "timestamp": "1709251200"},
+	"erc20Transfers": [],
+	"nftTransfers": [{
+		"transactionHash": "0xabc",
+		"logIndex": "4",
+		"contract": "0x32950db2a7164ae833121501c797d79e7b79d74c",
+		"from": "0x2222222222222222222222222222222222222222",
+		"to": "0x1111111111111111111111111111111111111111",
+		"tokenId": "7",
+		"amount": "1",
+		"tokenContractType": "ERC721",
+		"triggered_by": ["0x1111111111111111111111111111111111111111"]
+	}]
+}`
+
+func webhookServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := events.OpenStore(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	s := New("", nil, logger.New())
+	s.SetStreamsWebhook(testStreamsSecret, store, events.NewHub())
+	return s
+}
+
+func deliver(t *testing.T, s *Server, body, signature string) (int, webhookResult) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/moralis", strings.NewReader(body))
+	req.Header.Set(events.SignatureHeader, signature)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data webhookResult `json:"data"`
+	}
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+	}
+	return rec.Code, resp.Data
+}
+
+func sign(body string) string {
+	return "0x" + hex.EncodeToString(ronin.Keccak256([]byte(body), []byte(testStreamsSecret)))
+}
+
+func TestStreamsWebhookRedelivery(t *testing.T) {
+	s := webhookServer(t)
+
+	code, got := deliver(t, s, blockWebhook, sign(blockWebhook))
+	if code != http.StatusOK || got.Received != 1 || got.New != 1 {
+		t.Fatalf("first delivery = %d %+v, want 200 with 1 new", code, got)
+	}
+	// a retry of the same webhook, and the block again once confirmed
+	for _, body := range []string{blockWebhook, strings.Replace(blockWebhook, `"confirmed": false`, `"confirmed": true`, 1)} {
+		code, got = deliver(t, s, body, sign(body))
+		if code != http.StatusOK || got.Received != 1 || got.New != 0 {
+			t.Errorf("redelivery = %d %+v, want 200 with 0 new", code, got)
+		}
+	}
+}
+
+func TestStreamsWebhookBadSignature(t *testing.T) {
+	s := webhookServer(t)
+	for _, signature := range []string{"", sign(blockWebhook + " "), "0x00"} {
+		if code, _ := deliver(t, s, blockWebhook, signature); code != http.StatusUnauthorized {
+			t.Errorf("signature %q answered %d, want 401", signature, code)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"cmd/internal/filter"
+	"cmd/internal/models"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// GroupByCollection groups by TokenAddress, labelled with the collection name
+const GroupByCollection = "collection"
+
+// noGroupKey is the group for NFTs that don't have the group by field
+const noGroupKey = "(none)"
+
+// SortSpec is a parsed -sort value
+type SortSpec struct {
+	Field filter.Field
+	Desc  bool
+}
+
+// ParseSort
+// Explanation -> reads "field" or "field:asc|desc", a leading - also means descending
+// fields are the ones filter expressions take, a bare name that isn't one is an attribute
+// Return -> the spec, nil if s is blank
+func ParseSort(s string) (*SortSpec, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	spec := &SortSpec{}
+	if strings.HasPrefix(s, "-") {
+		spec.Desc = true
+		s = s[1:]
+	}
+	if name, dir, ok := strings.Cut(s, ":"); ok {
+		switch strings.ToLower(dir) {
+		case "asc":
+		case "desc":
+			spec.Desc = true
+		default:
+			return nil, fmt.Errorf("sort direction %q should be asc or desc", dir)
+		}
+		s = name
+	}
+
+	f, err := lookupFieldOrAttr(s)
+	if err != nil {
+		return nil, err
+	}
+	spec.Field = f
+	return spec, nil
+}
+
+// SortNFTs
+// Explanation -> sorts in place, numerically when both values are numbers, else by text
+// NFTs without the field go last whatever the direction, ties keep their order
+func SortNFTs(nfts []models.NFT, spec *SortSpec) {
+	if spec == nil {
+		return
+	}
+
+	sort.SliceStable(nfts, func(i, j int) bool {
+		a, okA := spec.Field.String(nfts[i])
+		b, okB := spec.Field.String(nfts[j])
+		if !okA || !okB {
+			return okA && !okB
+		}
+
+		cmp := 0
+		na, numA := spec.Field.Number(nfts[i])
+		nb, numB := spec.Field.Number(nfts[j])
+		switch {
+		case numA && numB:
+			if na < nb {
+				cmp = -1
+			} else if na > nb {
+				cmp = 1
+			}
+		case numA != numB:
+			// numbers before text
+			if numA {
+				cmp = -1
+			} else {
+				cmp = 1
+			}
+		default:
+			cmp = strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		}
+
+		if spec.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// Aggregate functions
+const (
+	AggCount = "count"
+	AggSum   = "sum"
+	AggAvg   = "avg"
+	AggMin   = "min"
+	AggMax   = "max"
+)
+
+// Aggregation is a parsed -agg value, e.g. count or sum(floor_price)
+type Aggregation struct {
+	Func  string
+	Field filter.Field // unset for count
+}
+
+// String returns the aggregation as it would be written, for table headers
+func (a Aggregation) String() string {
+	if a.Func == AggCount {
+		return AggCount
+	}
+	return a.Func + "(" + a.Field.Name() + ")"
+}
+
+// ParseAggregation
+// Explanation -> reads count, or sum|avg|min|max(field)
+// Return -> count if s is blank
+func ParseAggregation(s string) (Aggregation, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == AggCount {
+		return Aggregation{Func: AggCount}, nil
+	}
+
+	fn, rest, ok := strings.Cut(s, "(")
+	if !ok || !strings.HasSuffix(rest, ")") {
+		return Aggregation{}, fmt.Errorf("aggregation %q should be count or func(field), e.g. sum(floor_price)", s)
+	}
+	switch fn = strings.TrimSpace(fn); fn {
+	case AggSum, AggAvg, AggMin, AggMax:
+	default:
+		return Aggregation{}, fmt.Errorf("unknown aggregate function %q, expected count, sum, avg, min or max", fn)
+	}
+
+	f, err := lookupFieldOrAttr(strings.TrimSuffix(rest, ")"))
+	if err != nil {
+		return Aggregation{}, err
+	}
+	return Aggregation{Func: fn, Field: f}, nil
+}
+
+// Group is one bucket of a group by, with its aggregate
+type Group struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"` // collection name when grouping by collection
+	Count int    `json:"count"`
+	Value string `json:"value"` // aggregate as a decimal string, empty if no NFT had the field
+
+	NFTs []models.NFT `json:"-"`
+}
+
+// GroupNFTs
+// Explanation -> buckets NFTs by collection (TokenAddress) or a field/attribute, blank by
+// means one group with everything, then runs agg over each group
+// Return -> groups by aggregate value (count for count), largest first
+func GroupNFTs(nfts []models.NFT, by string, agg Aggregation) ([]Group, error) {
+	key, err := groupKeyFunc(by)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var groups []Group
+	for _, nft := range nfts {
+		// case-insensitive, so checksummed and lowercase addresses (or "beast" and "Beast") group together
+		k, label := key(nft)
+		i, ok := index[strings.ToLower(k)]
+		if !ok {
+			i = len(groups)
+			index[strings.ToLower(k)] = i
+			groups = append(groups, Group{Key: k, Label: label})
+		}
+		groups[i].Count++
+		groups[i].NFTs = append(groups[i].NFTs, nft)
+	}
+
+	values := make([]*big.Rat, len(groups))
+	for i := range groups {
+		values[i] = agg.apply(groups[i].NFTs)
+		if values[i] != nil {
+			groups[i].Value = ratString(values[i])
+		}
+	}
+
+	// sort by value, groups without one last, then by key so output is stable
+	order := make([]int, len(groups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := values[order[a]], values[order[b]]
+		if va == nil || vb == nil {
+			if va != nil || vb != nil {
+				return va != nil
+			}
+		} else if c := va.Cmp(vb); c != 0 {
+			return c > 0
+		}
+		return groups[order[a]].Key < groups[order[b]].Key
+	})
+
+	sorted := make([]Group, len(groups))
+	for i, o := range order {
+		sorted[i] = groups[o]
+	}
+	return sorted, nil
+}
+
+// groupKeyFunc builds the key (and label) function for a group by value
+func groupKeyFunc(by string) (func(models.NFT) (string, string), error) {
+	by = strings.TrimSpace(by)
+	switch {
+	case by == "":
+		return func(models.NFT) (string, string) { return "all", "" }, nil
+	case strings.EqualFold(by, GroupByCollection):
+		return func(nft models.NFT) (string, string) {
+			return nft.TokenAddress, nft.Name
+		}, nil
+	}
+
+	f, err := lookupFieldOrAttr(by)
+	if err != nil {
+		return nil, err
+	}
+	return func(nft models.NFT) (string, string) {
+		v, ok := f.String(nft)
+		if !ok || strings.TrimSpace(v) == "" {
+			return noGroupKey, ""
+		}
+		return v, ""
+	}, nil
+}
+
+// apply runs the aggregation over nfts, exactly, the values are decimal strings
+// Return -> nil if no NFT had a numeric value (never for count)
+func (a Aggregation) apply(nfts []models.NFT) *big.Rat {
+	if a.Func == AggCount || a.Func == "" {
+		return new(big.Rat).SetInt64(int64(len(nfts)))
+	}
+
+	var result *big.Rat
+	n := 0
+	for _, nft := range nfts {
+		s, ok := a.Field.String(nft)
+		if !ok {
+			continue
+		}
+		v, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+		if !ok {
+			continue
+		}
+		n++
+
+		switch {
+		case result == nil:
+			result = v
+		case a.Func == AggSum || a.Func == AggAvg:
+			result.Add(result, v)
+		case a.Func == AggMin && v.Cmp(result) < 0:
+			result = v
+		case a.Func == AggMax && v.Cmp(result) > 0:
+			result = v
+		}
+	}
+
+	if result != nil && a.Func == AggAvg {
+		result.Quo(result, new(big.Rat).SetInt64(int64(n)))
+	}
+	return result
+}
+
+// ratString formats r as a plain decimal, up to 18 places (wei precision)
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.RatString()
+	}
+	s := strings.TrimRight(r.FloatString(18), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// lookupFieldOrAttr takes a filter field name, or a bare attribute key, e.g. Class
+func lookupFieldOrAttr(name string) (filter.Field, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return filter.Field{}, fmt.Errorf("missing field name")
+	}
+	if f, err := filter.LookupField(name); err == nil {
+		return f, nil
+	}
+	return filter.LookupField("attr." + name)
+}
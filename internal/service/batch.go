@@ -0,0 +1,106 @@
+package service
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WalletResult is one wallet of a batch, its NFTs or why they couldn't be fetched
+type WalletResult struct {
+	Wallet string
+	NFTs   []models.NFT
+	Err    error
+}
+
+// GetNFTsByWallets
+// Explanation -> fetches several wallets' NFTs at once, at most workers at a time. The
+// workers share the Moralis client and so its rate limiter and retries; a wallet that fails
+// doesn't stop the others. With allPages each worker walks every page of its wallet, for
+// callers that sort or group the merged lists, otherwise it takes one page of params.Limit.
+// Every NFT's owner is set, so merged lists say whose it is
+// Return -> one result per wallet in the order given, an error only if ctx was cancelled
+func (c *NFTService) GetNFTsByWallets(ctx context.Context, wallets []string, params models.QueryParams, workers int, allPages bool) (_ []WalletResult, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("GetNFTsByWallets", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.GetNFTsByWallets")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("wallets", len(wallets)),
+		attribute.Int("workers", workers),
+		attribute.Bool("all_pages", allPages),
+	)
+
+	if workers < 1 {
+		workers = 1
+	}
+	workers = min(workers, len(wallets))
+
+	results := make([]WalletResult, len(wallets))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				nfts, err := c.walletNFTs(ctx, wallets[i], params, allPages)
+				for j := range nfts {
+					if nfts[j].OwnerOf == "" {
+						nfts[j].OwnerOf = c.formatAddress(wallets[i])
+					}
+				}
+				results[i] = WalletResult{Wallet: wallets[i], NFTs: nfts, Err: err}
+			}
+		}()
+	}
+
+feed:
+	for i := range wallets {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(attribute.Int("failed", failed))
+	c.logger.InfoContext(ctx, "NFT batch request processed",
+		"wallets", len(wallets),
+		"failed", failed,
+		"workers", workers,
+		"duration", time.Since(start),
+	)
+	return results, nil
+}
+
+// walletNFTs is one wallet of a batch, every page or just the first
+func (c *NFTService) walletNFTs(ctx context.Context, wallet string, params models.QueryParams, allPages bool) ([]models.NFT, error) {
+	if !allPages {
+		return c.GetNFTsByWallet(ctx, wallet, params)
+	}
+	params.Limit = 0 // pages as large as Moralis allows
+	var nfts []models.NFT
+	err := c.EachNFTPage(ctx, wallet, params, func(page []models.NFT) error {
+		nfts = append(nfts, page...)
+		return nil
+	})
+	return nfts, err
+}
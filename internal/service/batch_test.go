@@ -0,0 +1,181 @@
+package service
+
+import (
+	"cmd/internal/client"
+	"cmd/internal/models"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var quiet = logger.NewWithLevel(slog.LevelError + 4)
+
+// fakeWallets is a Moralis wallet NFT endpoint: every wallet has two pages of one NFT each,
+// failing answers 500 and flaky answers 503 once
+type fakeWallets struct {
+	delay   time.Duration
+	failing string
+	flaky   string
+
+	mu       sync.Mutex
+	requests map[string]int // by wallet
+	limits   []string
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (f *fakeWallets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for seen := f.maxSeen.Load(); n > seen && !f.maxSeen.CompareAndSwap(seen, n); seen = f.maxSeen.Load() {
+	}
+	time.Sleep(f.delay)
+
+	wallet := strings.ToLower(strings.Trim(strings.TrimSuffix(r.URL.Path, "/nft"), "/"))
+	f.mu.Lock()
+	f.requests[wallet]++
+	tries := f.requests[wallet]
+	f.limits = append(f.limits, r.URL.Query().Get("limit"))
+	f.mu.Unlock()
+
+	switch {
+	case wallet == f.failing:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	case wallet == f.flaky && tries == 1:
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := models.APIResponse{Cursor: "page2"}
+	tokenID := "1"
+	if r.URL.Query().Get("cursor") == "page2" {
+		resp.Cursor, tokenID = "", "2"
+	}
+	resp.Result = []models.RawNFTData{{TokenAddress: "0x32950db2a7164ae833121501c797d79e7b79d74c", TokenID: tokenID, Name: "Axie"}}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newFakeService(t *testing.T, f *fakeWallets) (*NFTService, *client.MoralisClient) {
+	t.Helper()
+	f.requests = make(map[string]int)
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	mc := client.NewMoralisClient("key", srv.URL, "", quiet)
+	return NewNFTService(mc, quiet), mc
+}
+
+// testWallets are n distinct wallets, lower case
+func testWallets(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = "0x" + strings.Repeat("0", 39) + string(rune('1'+i))
+	}
+	return out
+}
+
+func TestGetNFTsByWalletsWorkers(t *testing.T) {
+	wallets := testWallets(6)
+	f := &fakeWallets{delay: 20 * time.Millisecond, failing: wallets[2]}
+	svc, _ := newFakeService(t, f)
+
+	results, err := svc.GetNFTsByWallets(context.Background(), wallets, models.QueryParams{Limit: 10}, 2, false)
+	if err != nil {
+		t.Fatalf("GetNFTsByWallets: %v", err)
+	}
+	if got := f.maxSeen.Load(); got > 2 {
+		t.Errorf("%d requests at once, want at most 2 workers", got)
+	}
+	if len(results) != len(wallets) {
+		t.Fatalf("results = %d, want %d", len(results), len(wallets))
+	}
+	for i, r := range results {
+		if r.Wallet != wallets[i] {
+			t.Errorf("result %d is %s, want %s, results keep the order given", i, r.Wallet, wallets[i])
+		}
+		if i == 2 {
+			if r.Err == nil {
+				t.Errorf("failing wallet has no error")
+			}
+			continue
+		}
+		// one page each without allPages, owned by their wallet
+		if r.Err != nil || len(r.NFTs) != 1 {
+			t.Errorf("wallet %d = %d NFTs, %v, want 1", i, len(r.NFTs), r.Err)
+			continue
+		}
+		if !strings.EqualFold(r.NFTs[0].OwnerOf, wallets[i]) {
+			t.Errorf("wallet %d NFT owner = %s", i, r.NFTs[0].OwnerOf)
+		}
+	}
+}
+
+func TestGetNFTsByWalletsAllPages(t *testing.T) {
+	wallets := testWallets(3)
+	f := &fakeWallets{}
+	svc, _ := newFakeService(t, f)
+
+	results, err := svc.GetNFTsByWallets(context.Background(), wallets, models.QueryParams{Limit: 10}, 3, true)
+	if err != nil {
+		t.Fatalf("GetNFTsByWallets: %v", err)
+	}
+	for i, r := range results {
+		if r.Err != nil || len(r.NFTs) != 2 {
+			t.Errorf("wallet %d = %d NFTs, %v, want both pages", i, len(r.NFTs), r.Err)
+		}
+	}
+	for _, limit := range f.limits {
+		if limit != "100" {
+			t.Errorf("page asked for limit %q, want the largest page", limit)
+		}
+	}
+}
+
+func TestGetNFTsByWalletsSharedLimiter(t *testing.T) {
+	wallets := testWallets(5)
+	f := &fakeWallets{}
+	svc, mc := newFakeService(t, f)
+	// one request at once, then one every 50ms, however many workers ask
+	mc.SetRateLimit(20, 1)
+
+	start := time.Now()
+	results, err := svc.GetNFTsByWallets(context.Background(), wallets, models.QueryParams{}, 5, false)
+	if err != nil {
+		t.Fatalf("GetNFTsByWallets: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("5 requests took %s, the workers didn't share one limiter", elapsed)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("wallet %d: %v", i, r.Err)
+		}
+	}
+}
+
+func TestGetNFTsByWalletsRetries(t *testing.T) {
+	wallets := testWallets(2)
+	f := &fakeWallets{flaky: wallets[1]}
+	svc, mc := newFakeService(t, f)
+	mc.SetMaxRetries(2)
+
+	results, err := svc.GetNFTsByWallets(context.Background(), wallets, models.QueryParams{}, 2, false)
+	if err != nil {
+		t.Fatalf("GetNFTsByWallets: %v", err)
+	}
+	if r := results[1]; r.Err != nil || len(r.NFTs) != 1 {
+		t.Errorf("flaky wallet = %d NFTs, %v, want the retry's", len(r.NFTs), r.Err)
+	}
+	if got := f.requests[wallets[1]]; got != 2 {
+		t.Errorf("flaky wallet asked %d times, want 2", got)
+	}
+}
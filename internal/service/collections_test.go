@@ -0,0 +1,73 @@
+package service
+
+import (
+	"cmd/internal/client"
+	"cmd/internal/collections"
+	"cmd/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyCollections(t *testing.T) {
+	const (
+		axie  = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+		other = "0x1111111111111111111111111111111111111111"
+	)
+	nfts := func() []models.NFT {
+		return []models.NFT{
+			{TokenAddress: "0x" + strings.ToUpper(axie[2:]), TokenID: "1"},
+			{TokenAddress: other, TokenID: "2"},
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "collections.json")
+	r, err := collections.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewNFTService(client.NewMoralisClient("key", "http://127.0.0.1:0", "", quiet), quiet)
+	svc.SetCollections(r)
+
+	// an empty registry hides nothing
+	if got := svc.applyCollections(nfts(), true); len(got) != 2 || got[0].Label != "" {
+		t.Errorf("empty registry: %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"address":"`+axie+`","name":"Axie","category":"axie","tags":["axie-infinity"]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = collections.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.SetCollections(r)
+
+	tests := []struct {
+		name string
+		hide bool
+		ids  []string
+	}{
+		{"hide untracked", true, []string{"1"}},
+		{"all collections", false, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := svc.applyCollections(nfts(), tt.hide)
+			var ids []string
+			for _, nft := range got {
+				ids = append(ids, nft.TokenID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") {
+				t.Fatalf("kept %v, want %v", ids, tt.ids)
+			}
+			if got[0].Label != "Axie" || got[0].Category != "axie" || strings.Join(got[0].Tags, ",") != "axie-infinity" {
+				t.Errorf("tracked NFT = %+v, want the registry's label", got[0])
+			}
+			if len(got) > 1 && (got[1].Label != "" || got[1].Category != "") {
+				t.Errorf("untracked NFT = %+v, want no label", got[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"cmd/internal/guild"
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/payout"
+	"cmd/pkg/address"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ScholarEarnings
+// Explanation -> totals the AXS and SLP each scholar account received between
+// params.FromDate and params.ToDate. Transfers from other scholars or from exclude, e.g. the
+// team wallet topping an account up, aren't earnings
+// Return -> one earning per scholar and token received, in scholar order
+func (c *NFTService) ScholarEarnings(ctx context.Context, scholars []guild.Scholar, params models.HistoryParams, exclude []string) (_ []payout.Earning, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("ScholarEarnings", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.ScholarEarnings")
+	defer span.End()
+	span.SetAttributes(attribute.Int("scholars", len(scholars)))
+
+	internal := make(map[address.Address]bool, len(scholars)+len(exclude))
+	for _, sc := range scholars {
+		if addr, err := address.Parse(sc.Address); err == nil {
+			internal[addr] = true
+		}
+	}
+	for _, e := range exclude {
+		addr, err := address.Parse(e)
+		if err != nil {
+			return nil, fmt.Errorf("excluded address: %w", err)
+		}
+		internal[addr] = true
+	}
+
+	var earnings []payout.Earning
+	for _, sc := range scholars {
+		account, err := address.Parse(sc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("scholar %s: %w", sc.Name, err)
+		}
+
+		byToken := make(map[string]*payout.Earning)
+		err = c.EachHistoryPage(ctx, account.Hex(), params, func(txs []models.Transaction) error {
+			for _, tx := range txs {
+				if tx.ReceiptStatus == "0" {
+					continue
+				}
+				for _, t := range tx.ERC20Transfers {
+					token, ok := payout.TokenByAddress(t.Address)
+					if !ok {
+						continue
+					}
+					to, err := address.Parse(t.ToAddress)
+					if err != nil || to != account {
+						continue
+					}
+					if from, err := address.Parse(t.FromAddress); err == nil && internal[from] {
+						continue
+					}
+					units, ok := new(big.Int).SetString(strings.TrimSpace(t.Value), 10)
+					if !ok {
+						return fmt.Errorf("transaction %s: bad %s value %q", tx.Hash, token.Symbol, t.Value)
+					}
+
+					e, ok := byToken[token.Symbol]
+					if !ok {
+						e = &payout.Earning{Scholar: sc.Name, Address: account.Hex(), Token: token, Units: new(big.Int)}
+						byToken[token.Symbol] = e
+					}
+					e.Units.Add(e.Units, units)
+					e.Transfers++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scholar %s: %w", sc.Name, err)
+		}
+
+		for _, t := range payout.Tokens {
+			if e, ok := byToken[t.Symbol]; ok {
+				earnings = append(earnings, *e)
+			}
+		}
+	}
+
+	c.logger.InfoContext(ctx, "Scholar earnings totalled",
+		"scholars", len(scholars),
+		"earnings", len(earnings),
+		"duration", time.Since(start),
+	)
+	return earnings, nil
+}
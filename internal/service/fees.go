@@ -0,0 +1,192 @@
+package service
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/pkg/address"
+	"cmd/pkg/decimal"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// noMethodLabel groups transactions Moralis couldn't decode, plain transfers mostly
+const noMethodLabel = "(unknown)"
+
+// GetFeeReport
+// Explanation -> totals the gas the wallet paid on transactions it sent between
+// params.FromDate and params.ToDate, by method label, contract and month, in RON and USD.
+// USD uses the RON price on each transaction's day when the provider has history, else today's
+// Return -> the report, transactions without a fee or price are counted but not valued
+func (c *NFTService) GetFeeReport(ctx context.Context, walletAddr string, params models.HistoryParams) (_ *models.FeeReport, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("GetFeeReport", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.GetFeeReport")
+	defer span.End()
+	span.SetAttributes(attribute.String("wallet_address", walletAddr))
+
+	wallet, err := address.Parse(walletAddr)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address: %w", err)
+	}
+
+	r := &models.FeeReport{Wallet: wallet.Hex(), FromDate: params.FromDate, ToDate: params.ToDate}
+	byMethod := make(map[string]*models.FeeBucket)
+	byContract := make(map[string]*models.FeeBucket)
+	byMonth := make(map[string]*models.FeeBucket)
+	ronUSD := c.ronPriceByDay(ctx)
+
+	err = c.EachHistoryPage(ctx, wallet.Hex(), params, func(txs []models.Transaction) error {
+		for _, tx := range txs {
+			if !strings.EqualFold(tx.FromAddress, wallet.Hex()) {
+				continue // the sender pays the gas
+			}
+			at, err := time.Parse(time.RFC3339, tx.BlockTimestamp)
+			if err != nil {
+				return fmt.Errorf("transaction %s timestamp: %w", tx.Hash, err)
+			}
+			ron, ok := transactionFee(tx)
+			if !ok {
+				continue
+			}
+			usd, priced := ronUSD(at)
+			if priced {
+				usd = ron.Mul(usd)
+			} else {
+				r.Unpriced++
+			}
+			failed := tx.ReceiptStatus == "0"
+
+			r.Transactions++
+			r.TotalRON = r.TotalRON.Add(ron)
+			r.TotalUSD = r.TotalUSD.Add(usd)
+			if failed {
+				r.Failed++
+				r.FailedRON = r.FailedRON.Add(ron)
+				r.FailedUSD = r.FailedUSD.Add(usd)
+			}
+
+			method := noMethodLabel
+			if tx.MethodLabel != nil && *tx.MethodLabel != "" {
+				method = *tx.MethodLabel
+			}
+			addFee(byMethod, method, "", ron, usd, failed)
+
+			var label string
+			if tx.ToAddressLabel != nil {
+				label = *tx.ToAddressLabel
+			} else if tx.ToAddressEntity != nil {
+				label = *tx.ToAddressEntity
+			}
+			addFee(byContract, c.formatAddress(tx.ToAddress), label, ron, usd, failed)
+
+			addFee(byMonth, at.UTC().Format("2006-01"), "", ron, usd, failed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.ByMethod = sortedFees(byMethod, false)
+	r.ByContract = sortedFees(byContract, false)
+	r.ByMonth = sortedFees(byMonth, true)
+
+	c.logger.InfoContext(ctx, "Fee report built",
+		"wallet_address", wallet.Hex(),
+		"transactions", r.Transactions,
+		"failed", r.Failed,
+		"total_ron", r.TotalRON.String(),
+		"duration", time.Since(start),
+	)
+	return r, nil
+}
+
+// transactionFee is the gas paid in RON, from transaction_fee or else gas used times price
+func transactionFee(tx models.Transaction) (decimal.Decimal, bool) {
+	if fee, err := decimal.Parse(tx.TransactionFee); err == nil {
+		return fee, true
+	}
+	used, ok := new(big.Int).SetString(strings.TrimSpace(tx.ReceiptGasUsed), 10)
+	if !ok {
+		return decimal.Zero, false
+	}
+	gasPrice, ok := new(big.Int).SetString(strings.TrimSpace(tx.GasPrice), 10)
+	if !ok {
+		return decimal.Zero, false
+	}
+	fee, err := decimal.FromUnits(new(big.Int).Mul(used, gasPrice).String(), 18)
+	return fee, err == nil
+}
+
+// ronPriceByDay returns a lookup of the RON price on a day, asking the provider once per day.
+// Without price history every day gets today's price
+func (c *NFTService) ronPriceByDay(ctx context.Context) func(time.Time) (decimal.Decimal, bool) {
+	historical, _ := price.HistoricalOf(c.prices)
+	days := make(map[string]*decimal.Decimal)
+	used := make(map[string]*decimal.Decimal)
+
+	return func(at time.Time) (decimal.Decimal, bool) {
+		if historical == nil {
+			return c.usdPrice(ctx, price.RON, used)
+		}
+		day := at.UTC().Format(time.DateOnly)
+		if d, seen := days[day]; seen {
+			if d == nil {
+				return decimal.Zero, false
+			}
+			return *d, true
+		}
+		d, err := historical.USDPriceAt(ctx, price.RON, at)
+		if err != nil {
+			c.logger.WarnContext(ctx, "No RON price for day",
+				"day", day,
+				"error", err,
+			)
+			days[day] = nil
+			return decimal.Zero, false
+		}
+		days[day] = &d
+		return d, true
+	}
+}
+
+func addFee(buckets map[string]*models.FeeBucket, key, label string, ron, usd decimal.Decimal, failed bool) {
+	b, ok := buckets[key]
+	if !ok {
+		b = &models.FeeBucket{Key: key, Label: label}
+		buckets[key] = b
+	}
+	b.Count++
+	b.RON = b.RON.Add(ron)
+	b.USD = b.USD.Add(usd)
+	if failed {
+		b.Failed++
+		b.FailedRON = b.FailedRON.Add(ron)
+		b.FailedUSD = b.FailedUSD.Add(usd)
+	}
+}
+
+// sortedFees lists buckets by key when byKey, months read best in order, else biggest spend first
+func sortedFees(buckets map[string]*models.FeeBucket, byKey bool) []models.FeeBucket {
+	out := make([]models.FeeBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !byKey {
+			if cmp := out[i].RON.Cmp(out[j].RON); cmp != 0 {
+				return cmp > 0
+			}
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
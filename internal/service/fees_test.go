@@ -0,0 +1,134 @@
+package service
+
+import (
+	"cmd/internal/client"
+	"cmd/internal/models"
+	"cmd/pkg/address"
+	"cmd/pkg/decimal"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dayPrices is a price provider with history, days missing from it have no price
+type dayPrices map[string]string
+
+func (p dayPrices) USDPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	return decimal.Zero, errors.New("no current price in tests")
+}
+
+func (p dayPrices) USDPriceAt(ctx context.Context, symbol string, at time.Time) (decimal.Decimal, error) {
+	s, ok := p[at.UTC().Format(time.DateOnly)]
+	if !ok {
+		return decimal.Zero, errors.New("no price that day")
+	}
+	return decimal.Parse(s)
+}
+
+func TestGetFeeReport(t *testing.T) {
+	const (
+		wallet = "0x1111111111111111111111111111111111111111"
+		market = "0x3b3adf1422f84254b7fbb0e7ca62bd0865133fe3"
+		token  = "0x97a9107c1793bc407d6f527b77e7fff4d812bece"
+	)
+	str := func(s string) *string { return &s }
+	tx := func(hash, from, to, at, fee, gasUsed, gasPrice, status string, method *string) models.Transaction {
+		return models.Transaction{
+			Hash: hash, FromAddress: from, ToAddress: to, BlockTimestamp: at,
+			TransactionFee: fee, ReceiptGasUsed: gasUsed, GasPrice: gasPrice, ReceiptStatus: status,
+			MethodLabel: method,
+		}
+	}
+
+	sale := tx("0x1", wallet, market, "2024-01-10T08:00:00.000Z", "0.01", "", "", "1", str("settleAuction"))
+	sale.ToAddressLabel = str("Mavis Market")
+	// no transaction_fee, 21000 gas at 20 gwei, failed, the sender in another case
+	failed := tx("0x2", "0x"+strings.ToUpper(wallet[2:]), market, "2024-01-20T23:59:59.000Z", "", "21000", "20000000000", "0", str("settleAuction"))
+	failed.ToAddressLabel = str("Mavis Market")
+	// a day the provider has no price for
+	approve := tx("0x3", wallet, token, "2024-02-05T12:00:00.000Z", "0.002", "", "", "1", nil)
+	approve.ToAddressEntity = str("Axie Infinity")
+	// received, the sender paid
+	received := tx("0x4", market, wallet, "2024-01-11T00:00:00.000Z", "0.5", "", "", "1", str("transfer"))
+	// nothing to value the gas with
+	unknown := tx("0x5", wallet, token, "2024-02-06T00:00:00.000Z", "", "", "", "1", nil)
+
+	pages := [][]models.Transaction{{sale, failed, received}, {approve, unknown}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp models.HistoryResponse
+		if r.URL.Query().Get("cursor") == "" {
+			resp.Cursor, resp.Result = "page2", pages[0]
+		} else {
+			resp.Result = pages[1]
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	svc := NewNFTService(client.NewMoralisClient("key", srv.URL, "", quiet), quiet)
+	svc.SetPriceProvider(dayPrices{"2024-01-10": "2", "2024-01-20": "3"})
+
+	r, err := svc.GetFeeReport(context.Background(), wallet, models.HistoryParams{})
+	if err != nil {
+		t.Fatalf("GetFeeReport: %v", err)
+	}
+
+	same := func(d decimal.Decimal, want string) bool {
+		return d.Cmp(decimal.MustParse(want)) == 0
+	}
+	if r.Transactions != 3 || r.Failed != 1 || r.Unpriced != 1 {
+		t.Errorf("transactions %d, failed %d, unpriced %d, want 3, 1, 1", r.Transactions, r.Failed, r.Unpriced)
+	}
+	// 0.01 at $2 + 0.00042 at $3, the unpriced 0.002 is in RON only
+	if !same(r.TotalRON, "0.01242") || !same(r.TotalUSD, "0.02126") {
+		t.Errorf("total %s RON, %s USD, want 0.01242 and 0.02126", r.TotalRON, r.TotalUSD)
+	}
+	if !same(r.FailedRON, "0.00042") || !same(r.FailedUSD, "0.00126") {
+		t.Errorf("failed %s RON, %s USD, want 0.00042 and 0.00126", r.FailedRON, r.FailedUSD)
+	}
+
+	marketKey, _ := address.Normalize(market, address.FormatHex)
+	tokenKey, _ := address.Normalize(token, address.FormatHex)
+	type bucket struct {
+		key, label    string
+		count, failed int
+		ron, usd      string
+	}
+	tests := []struct {
+		name    string
+		buckets []models.FeeBucket
+		want    []bucket // in report order
+	}{
+		{"by method", r.ByMethod, []bucket{
+			{"settleAuction", "", 2, 1, "0.01042", "0.02126"},
+			{noMethodLabel, "", 1, 0, "0.002", "0"},
+		}},
+		{"by contract", r.ByContract, []bucket{
+			{marketKey, "Mavis Market", 2, 1, "0.01042", "0.02126"},
+			{tokenKey, "Axie Infinity", 1, 0, "0.002", "0"},
+		}},
+		{"by month", r.ByMonth, []bucket{
+			{"2024-01", "", 2, 1, "0.01042", "0.02126"},
+			{"2024-02", "", 1, 0, "0.002", "0"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.buckets) != len(tt.want) {
+				t.Fatalf("buckets = %+v, want %d", tt.buckets, len(tt.want))
+			}
+			for i, want := range tt.want {
+				b := tt.buckets[i]
+				if b.Key != want.key || b.Label != want.label || b.Count != want.count || b.Failed != want.failed ||
+					!same(b.RON, want.ron) || !same(b.USD, want.usd) {
+					t.Errorf("bucket %d = %+v, want %+v", i, b, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"cmd/internal/axie"
+	"cmd/internal/guild"
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/spam"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ReconcileGuild
+// Explanation -> fetches the Axies in every scholar account and checks them against the
+// assignments. A scholar whose account can't be fetched is reported with the error and the
+// rest still run, unless ctx is done
+// Return -> the report, an error only if ctx ended
+func (c *NFTService) ReconcileGuild(ctx context.Context, scholars []guild.Scholar) (_ *guild.Report, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("ReconcileGuild", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.ReconcileGuild")
+	defer span.End()
+	span.SetAttributes(attribute.Int("scholars", len(scholars)))
+
+	// every Axie counts, whatever the registry or spam rules say
+	params := models.QueryParams{AllCollections: true, SpamMode: spam.ModeFlag}
+
+	holdings := make(map[string][]string, len(scholars))
+	failed := make(map[string]error)
+	for _, sc := range scholars {
+		var ids []string
+		err := c.EachNFTPage(ctx, sc.Address, params, func(nfts []models.NFT) error {
+			for _, nft := range nfts {
+				if axie.IsAxie(nft.TokenAddress) {
+					ids = append(ids, nft.TokenID)
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			c.logger.WarnContext(ctx, "Failed to fetch scholar Axies",
+				"scholar", sc.Name,
+				"wallet_address", sc.Address,
+				"error", err,
+			)
+			failed[sc.Address] = err
+			continue
+		}
+		holdings[sc.Address] = ids
+	}
+
+	r := guild.Reconcile(scholars, holdings, failed)
+	c.logger.InfoContext(ctx, "Guild reconciled",
+		"scholars", len(scholars),
+		"discrepancies", len(r.Discrepancies),
+		"failed", len(failed),
+		"duration", time.Since(start),
+	)
+	return r, nil
+}
@@ -1,45 +1,176 @@
 package service
 
 import (
+	"cmd/internal/axie"
+	"cmd/internal/cache"
 	"cmd/internal/client"
+	"cmd/internal/collections"
+	"cmd/internal/filter"
+	"cmd/internal/metrics"
 	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/internal/rarity"
+	"cmd/internal/spam"
+	"cmd/internal/tokenmeta"
+	"cmd/pkg/address"
 	"cmd/pkg/logger"
+	"cmd/pkg/tracing"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var tracer = tracing.Tracer("cmd/internal/service")
+
+// ErrInvalidFilter is returned when query params hold a filter that doesn't parse
+var ErrInvalidFilter = errors.New("invalid filter")
+
 // NFTService struct handles NFT operations
 type NFTService struct {
 	moralisClient *client.MoralisClient
 	logger        *logger.Logger
+	addrFormat    address.Format
+	names         NameResolver
+	axie          *axie.Enricher
+	prices        price.Provider
+	rarity        *rarity.Engine
+	spam          spam.Classifier
+	spamMode      string
+	collections   *collections.Registry
+
+	metadata    *tokenmeta.Resolver
+	resyncAfter time.Duration
+	resynced    *cache.TTL[string, bool]
+}
+
+// maxResyncsPerRequest caps the resyncs one request can trigger, a wallet full of broken
+// tokens shouldn't burn through the Moralis quota
+const maxResyncsPerRequest = 20
+
+// NameResolver reverse resolves addresses to names, e.g. .ron names via rns.Resolver
+type NameResolver interface {
+	LookupName(ctx context.Context, addr address.Address) (string, error)
 }
 
 // NewNFTService func creates a new service
-func NewNFTService(client *client.MoralisClient) *NFTService {
-	log := logger.New().WithGroup("nft_service")
+// log is the application's root logger, the service logs under its own group
+func NewNFTService(client *client.MoralisClient, log *logger.Logger) *NFTService {
+	log = log.WithGroup("nft_service")
 
 	return &NFTService{
 		moralisClient: client,
 		logger:        log,
+		addrFormat:    address.FormatHex,
+		axie:          axie.NewEnricher(nil),
+		spam:          spam.MoralisFlag{},
+		spamMode:      spam.ModeDrop,
+	}
+}
+
+// SetAxieEnricher replaces the default enricher, e.g. with one that has a part catalog
+func (c *NFTService) SetAxieEnricher(e *axie.Enricher) {
+	c.axie = e
+}
+
+// SetCollections sets the collections registry, NFTs outside it are then hidden unless
+// asked for, nil (or an empty registry) shows everything
+func (c *NFTService) SetCollections(r *collections.Registry) {
+	c.collections = r
+}
+
+// Collections lists the tracked collections, nil without a registry
+func (c *NFTService) Collections() []collections.Entry {
+	if c.collections == nil {
+		return nil
 	}
+	return c.collections.List()
+}
+
+// SetSpamClassifier replaces the default classifier, which only trusts Moralis' possible_spam
+func (c *NFTService) SetSpamClassifier(classifier spam.Classifier) {
+	c.spam = classifier
+}
+
+// SetSpamMode sets what happens to spam when a request doesn't say, see spam.ParseMode
+func (c *NFTService) SetSpamMode(mode string) error {
+	mode, err := spam.ParseMode(mode)
+	if err != nil {
+		return err
+	}
+	c.spamMode = mode
+	return nil
+}
+
+// SetMetadataResolver turns on resolving metadata from token_uri for NFTs Moralis sent
+// none for, nil turns it off
+func (c *NFTService) SetMetadataResolver(r *tokenmeta.Resolver) {
+	c.metadata = r
+}
+
+// SetAutoResync has Moralis resync metadata for tokens that came back without any, or whose
+// last sync is older than staleAfter. Each token is asked for at most once per staleAfter
+// staleAfter <= 0 turns it off
+func (c *NFTService) SetAutoResync(staleAfter time.Duration) {
+	c.resyncAfter = staleAfter
+	if staleAfter > 0 {
+		c.resynced = cache.NewTTL[string, bool]("metadata_resync", staleAfter)
+	}
+}
+
+// SetRarityEngine turns on local rarity ranks for NFTs Moralis has none for, nil turns it off
+func (c *NFTService) SetRarityEngine(e *rarity.Engine) {
+	c.rarity = e
+}
+
+// SetNameResolver turns on owner name labels, nil turns them off
+func (c *NFTService) SetNameResolver(names NameResolver) {
+	c.names = names
+}
+
+// SetAddressFormat sets how addresses in returned NFTs are displayed, 0x (default) or ronin:
+func (c *NFTService) SetAddressFormat(f address.Format) {
+	c.addrFormat = f
 }
 
 // GetNFTsByWallet (see client/moralis_client for func.)
 // Explanation -> func gets NFTs for a wallet, cleans up the data
 // Return -> NFT data
-func (c *NFTService) GetNFTsByWallet(ctx context.Context, walletAddr string, params models.QueryParams) ([]models.NFT, error) {
+func (c *NFTService) GetNFTsByWallet(ctx context.Context, walletAddr string, params models.QueryParams) (_ []models.NFT, err error) {
 	start := time.Now()
+	defer func() { metrics.ObserveService("GetNFTsByWallet", err, time.Since(start)) }()
 
-	c.logger.Info("Processing NFT wallet request",
+	ctx, span := tracer.Start(ctx, "NFTService.GetNFTsByWallet")
+	defer span.End()
+	span.SetAttributes(attribute.String("wallet_address", walletAddr))
+
+	c.logger.InfoContext(ctx, "Processing NFT wallet request",
 		"wallet_address", walletAddr,
 		"params", params,
 	)
 
+	// validate before spending an API call, Moralis errors on bad addresses are opaque
+	wallet, err := address.Parse(walletAddr)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Invalid wallet address",
+			"error", err,
+			"wallet_address", walletAddr,
+		)
+		return nil, fmt.Errorf("wallet address: %w", err)
+	}
+
+	pipeline, err := c.newWalletPipeline(params)
+	if err != nil {
+		return nil, err
+	}
+
 	// get raw data from API
-	rawNFTs, err := c.moralisClient.GetNFTsByWallet(ctx, walletAddr, params)
+	rawNFTs, err := c.moralisClient.GetNFTsByWallet(ctx, wallet.Hex(), params)
 	if err != nil {
-		c.logger.Error("Failed to fetch NFTs from API",
+		c.logger.ErrorContext(ctx, "Failed to fetch NFTs from API",
 			"error", err,
 			"wallet_address", walletAddr,
 		)
@@ -47,11 +178,11 @@ func (c *NFTService) GetNFTsByWallet(ctx context.Context, walletAddr string, par
 	}
 
 	// Convert raw data to clean data
-	cleanNFTs := c.convertRawNFTs(rawNFTs)
+	cleanNFTs := c.runWalletPipeline(ctx, pipeline, rawNFTs)
 
 	// log results
 	duration := time.Since(start)
-	c.logger.Info("NFT wallet request processed",
+	c.logger.InfoContext(ctx, "NFT wallet request processed",
 		"wallet_address", walletAddr,
 		"raw_nfts", len(rawNFTs),
 		"clean_nfts", len(cleanNFTs),
@@ -61,31 +192,97 @@ func (c *NFTService) GetNFTsByWallet(ctx context.Context, walletAddr string, par
 	return cleanNFTs, nil
 }
 
+// walletPipeline is what a wallet request needs to turn raw NFTs into the ones returned,
+// parsed once so every page of a paginated request is treated the same
+type walletPipeline struct {
+	axieFilter     *axie.Filter
+	expr           *filter.Expr
+	spamMode       string
+	allCollections bool
+}
+
+// newWalletPipeline parses the filters in params
+// Return -> an error wrapping ErrInvalidFilter for filters that don't parse
+func (c *NFTService) newWalletPipeline(params models.QueryParams) (*walletPipeline, error) {
+	axieFilter, err := axie.ParseFilter(params.AxieClass, params.AxieParts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+	expr, err := filter.Parse(params.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+	spamMode := c.spamMode
+	if params.SpamMode != "" {
+		if spamMode, err = spam.ParseMode(params.SpamMode); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+		}
+	}
+
+	return &walletPipeline{
+		axieFilter:     axieFilter,
+		expr:           expr,
+		spamMode:       spamMode,
+		allCollections: params.AllCollections,
+	}, nil
+}
+
+// runWalletPipeline resolves, converts, labels and filters one batch of raw NFTs
+func (c *NFTService) runWalletPipeline(ctx context.Context, p *walletPipeline, rawNFTs []models.RawNFTData) []models.NFT {
+	c.resolveMetadata(ctx, rawNFTs)
+
+	nfts := c.convertRawNFTs(ctx, rawNFTs, p.spamMode)
+	nfts = c.applyCollections(nfts, !p.allCollections)
+	c.fillRarity(ctx, nfts) // before filtering, expressions can use rarity_rank
+	nfts = p.axieFilter.Apply(nfts)
+	nfts = p.expr.Apply(nfts)
+	c.labelOwners(ctx, nfts)
+	return nfts
+}
+
 // GetSpecificNFTs (see client/moralis_client for func.)
 // Explanation -> func gets specific NFT based on token ID and token address provided
 // Return -> NFT data
-func (c *NFTService) GetSpecficNFTs(ctx context.Context, tokens []models.TokenRequest) ([]models.NFT, error) {
+func (c *NFTService) GetSpecficNFTs(ctx context.Context, tokens []models.TokenRequest) (_ []models.NFT, err error) {
 	start := time.Now()
+	defer func() { metrics.ObserveService("GetSpecificNFTs", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.GetSpecificNFTs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("tokens", len(tokens)))
 
 	// log service call
-	c.logger.Info("Getting NFT",
+	c.logger.InfoContext(ctx, "Getting NFT",
 		"tokens", tokens,
 	)
 
+	tokens, err = normalizeTokens(tokens)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Invalid token address",
+			"error", err,
+		)
+		return nil, err
+	}
+
 	rawNFTs, err := c.moralisClient.GetSpecificNFTs(ctx, tokens)
 	if err != nil {
-		c.logger.Error("Failed to fetch NFT from API",
+		c.logger.ErrorContext(ctx, "Failed to fetch NFT from API",
 			"error", err,
 			"tokens", tokens,
 		)
 		return nil, fmt.Errorf("fetching specific NFTs from API: %w", err)
 	}
 
-	specificNFT := c.convertRawNFTs(rawNFTs)
+	c.resolveMetadata(ctx, rawNFTs)
+
+	specificNFT := c.convertRawNFTs(ctx, rawNFTs, c.spamMode)
+	specificNFT = c.applyCollections(specificNFT, false) // asked for by address, never hidden
+	c.fillRarity(ctx, specificNFT)
+	c.labelOwners(ctx, specificNFT)
 
 	// Log results
 	duration := time.Since(start)
-	c.logger.Info("NFT wallet request processed",
+	c.logger.InfoContext(ctx, "NFT wallet request processed",
 		"tokens", tokens,
 		"specific_nfts", len(specificNFT),
 		"duration", duration,
@@ -95,35 +292,53 @@ func (c *NFTService) GetSpecficNFTs(ctx context.Context, tokens []models.TokenRe
 
 // convertRawNFTs
 // Explanation -> func cleans up the raw data received from the API to clean data
+// spam is classified after conversion, spamMode decides whether it's dropped, flagged or all that's kept
 // Return -> cleaned NFT data
-func (c *NFTService) convertRawNFTs(rawNFTs []models.RawNFTData) []models.NFT {
+func (c *NFTService) convertRawNFTs(ctx context.Context, rawNFTs []models.RawNFTData, spamMode string) []models.NFT {
 	var cleanNFTs []models.NFT
 	spamCount := 0
 
 	for _, raw := range rawNFTs {
-		// skip spam count
-		if raw.PossibleSpam {
-			spamCount++
-			continue
-		}
-
 		nft := models.NFT{
 			TokenID:      raw.TokenID,
-			TokenAddress: raw.TokenAddress,
+			TokenAddress: c.formatAddress(raw.TokenAddress),
 			Name:         raw.Name,
 			IsVerified:   raw.VerifiedCollection,
 			PossibleSpam: raw.PossibleSpam,
 			RarityRank:   raw.RarityRank,
+
+			ContractType:      raw.ContractType,
+			Amount:            raw.Amount,
+			OwnerOf:           c.formatAddress(raw.OwnerOf),
+			Symbol:            raw.Symbol,
+			TokenURI:          raw.TokenURI,
+			BlockNumber:       raw.BlockNumber,
+			BlockNumberMinted: deref(raw.BlockNumberMinted),
+			MinterAddress:     c.formatAddress(deref(raw.MinterAddress)),
+			LastMetadataSync:  raw.LastMetadataSync,
+			RarityPercentage:  raw.RarityPercentage,
+			RarityLabel:       deref(raw.RarityLabel),
+			Collection:        raw.Collection(),
+			Media:             raw.Media,
+		}
+
+		if raw.RarityRank != nil {
+			nft.RaritySource = models.RaritySourceMoralis
 		}
 
 		// floor price, in case it returns nil/null
 		if raw.FloorPrice != nil {
 			nft.FloorPrice = *raw.FloorPrice
+			nft.FloorPriceCurrency = deref(raw.FloorPriceCurrency)
+			nft.FloorPriceUSD = deref(raw.FloorPriceUSD)
 		}
 
 		// metadata, in case it returns nil
 		if raw.NormalizedMetadata != nil {
 			nft.Image = raw.NormalizedMetadata.Image
+			nft.TokenName = raw.NormalizedMetadata.Name
+			nft.AnimationURL = deref(raw.NormalizedMetadata.AnimationURL)
+			nft.ExternalLink = deref(raw.NormalizedMetadata.ExternalLink)
 
 			if raw.NormalizedMetadata.Description != nil {
 				nft.Description = *raw.NormalizedMetadata.Description
@@ -137,15 +352,215 @@ func (c *NFTService) convertRawNFTs(rawNFTs []models.RawNFTData) []models.NFT {
 				}
 			}
 		}
+
+		// class, parts, purity and stats for Axies
+		c.axie.Enrich(&nft)
+
+		verdict := c.spam.Classify(nft)
+		nft.PossibleSpam = verdict.Spam
+		if verdict.Spam {
+			spamCount++
+		}
+		switch {
+		case spamMode == spam.ModeDrop && verdict.Spam,
+			spamMode == spam.ModeOnly && !verdict.Spam:
+			continue
+		case spamMode != spam.ModeDrop:
+			// reasons are for checking the rules, only worth the noise when spam is shown
+			for _, reason := range verdict.Reasons {
+				nft.SpamReasons = append(nft.SpamReasons, reason.String())
+			}
+		}
 		cleanNFTs = append(cleanNFTs, nft)
 	}
 
 	// Log conversion stats
+	if spamMode == spam.ModeDrop {
+		metrics.AddSpamFiltered(spamCount)
+	}
 	if spamCount > 0 {
-		c.logger.Info("Filtered spam NFTs",
-			"spam_fltered", spamCount,
+		c.logger.InfoContext(ctx, "Filtered spam NFTs",
+			"spam_filtered", spamCount,
+			"spam_mode", spamMode,
 			"clean_nfts", len(cleanNFTs),
 		)
 	}
 	return cleanNFTs
 }
+
+// resolveMetadata fills in metadata Moralis sent null for, when a resolver is set, then
+// queues Moralis resyncs for what's still missing or stale when auto resync is on
+func (c *NFTService) resolveMetadata(ctx context.Context, raws []models.RawNFTData) {
+	if c.metadata != nil {
+		if filled := c.metadata.Fill(ctx, raws); filled > 0 {
+			c.logger.DebugContext(ctx, "Resolved metadata from token URIs",
+				"filled", filled,
+			)
+		}
+	}
+
+	if c.resyncAfter <= 0 {
+		return
+	}
+
+	var stale []models.TokenRequest
+	for _, raw := range raws {
+		if len(stale) == maxResyncsPerRequest {
+			break
+		}
+		if raw.NormalizedMetadata != nil && !tokenmeta.Stale(raw.LastMetadataSync, c.resyncAfter) {
+			continue
+		}
+		key := strings.ToLower(raw.TokenAddress) + "/" + raw.TokenID
+		if _, done := c.resynced.Get(key); done {
+			continue
+		}
+		c.resynced.Set(key, true)
+		stale = append(stale, models.TokenRequest{TokenAddress: raw.TokenAddress, TokenID: raw.TokenID})
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	// the response shouldn't wait on it, and shouldn't cancel it either
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := c.ResyncMetadata(ctx, stale); err != nil {
+			c.logger.WarnContext(ctx, "Automatic metadata resync failed",
+				"error", err,
+			)
+		}
+	}()
+}
+
+// ResyncMetadata
+// Explanation -> asks Moralis to refresh the metadata of each token, one call per token
+// Return -> the first error, the remaining tokens are still tried
+func (c *NFTService) ResyncMetadata(ctx context.Context, tokens []models.TokenRequest) error {
+	ctx, span := tracer.Start(ctx, "NFTService.ResyncMetadata")
+	defer span.End()
+	span.SetAttributes(attribute.Int("tokens", len(tokens)))
+
+	tokens, err := normalizeTokens(tokens)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	requested := 0
+	for _, token := range tokens {
+		if err := c.moralisClient.ResyncMetadata(ctx, token.TokenAddress, token.TokenID); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("resyncing %s/%s: %w", token.TokenAddress, token.TokenID, err)
+			}
+			continue
+		}
+		requested++
+	}
+
+	c.logger.InfoContext(ctx, "Metadata resync requested",
+		"tokens", len(tokens),
+		"requested", requested,
+	)
+	return firstErr
+}
+
+// deref returns the string a nullable API field points to, or "" for null
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// applyCollections labels NFTs of tracked collections, and with hide drops the rest
+// nothing is hidden while the registry is empty, so a fresh install shows everything
+func (c *NFTService) applyCollections(nfts []models.NFT, hide bool) []models.NFT {
+	if c.collections == nil || c.collections.Len() == 0 {
+		return nfts
+	}
+
+	out := nfts[:0]
+	for _, nft := range nfts {
+		entry, ok := c.collections.Lookup(nft.TokenAddress)
+		if ok {
+			nft.Label = entry.Name
+			nft.Category = entry.Category
+			nft.Tags = entry.Tags
+		} else if hide {
+			continue
+		}
+		out = append(out, nft)
+	}
+	return out
+}
+
+// fillRarity ranks NFTs Moralis sent no rarity_rank for, when a rarity engine is set
+func (c *NFTService) fillRarity(ctx context.Context, nfts []models.NFT) {
+	if c.rarity == nil {
+		return
+	}
+
+	if filled := c.rarity.Fill(ctx, nfts); filled > 0 {
+		c.logger.DebugContext(ctx, "Filled local rarity ranks",
+			"filled", filled,
+		)
+	}
+}
+
+// labelOwners fills OwnerName when a name resolver is set
+// lookups are per unique owner, a failed lookup just leaves the label empty
+func (c *NFTService) labelOwners(ctx context.Context, nfts []models.NFT) {
+	if c.names == nil {
+		return
+	}
+
+	names := make(map[address.Address]string)
+	for i := range nfts {
+		owner, err := address.Parse(nfts[i].OwnerOf)
+		if err != nil {
+			continue
+		}
+
+		name, seen := names[owner]
+		if !seen {
+			name, err = c.names.LookupName(ctx, owner)
+			if err != nil {
+				c.logger.DebugContext(ctx, "No name for owner",
+					"owner", owner.Hex(),
+					"error", err,
+				)
+			}
+			names[owner] = name
+		}
+		nfts[i].OwnerName = name
+	}
+}
+
+// formatAddress renders an address from the API in the display format
+// anything that doesn't parse (empty, or odd API data) is passed through untouched
+func (c *NFTService) formatAddress(s string) string {
+	formatted, err := address.Normalize(s, c.addrFormat)
+	if err != nil {
+		return s
+	}
+	return formatted
+}
+
+// normalizeTokens validates token addresses and rewrites them in 0x form for the API
+// Return -> a copy of tokens, the caller's slice is left alone
+func normalizeTokens(tokens []models.TokenRequest) ([]models.TokenRequest, error) {
+	normalized := make([]models.TokenRequest, len(tokens))
+	for i, token := range tokens {
+		addr, err := address.Parse(token.TokenAddress)
+		if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i, err)
+		}
+		normalized[i] = models.TokenRequest{
+			TokenAddress: addr.Hex(),
+			TokenID:      strings.TrimSpace(token.TokenID),
+		}
+	}
+	return normalized, nil
+}
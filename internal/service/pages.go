@@ -0,0 +1,94 @@
+package service
+
+import (
+	"cmd/internal/models"
+	"cmd/pkg/address"
+	"context"
+	"fmt"
+)
+
+// pageSize is the page size paginated walks ask for, Moralis' maximum
+const pageSize = 100
+
+// EachNFTPage
+// Explanation -> walks every page of a wallet's NFTs, running each through the same
+// conversion and filters as GetNFTsByWallet, so exports of big wallets never hold the whole
+// wallet in memory. params.Limit is the page size, 0 for the maximum
+// Return -> the first error from fetching or from fn, which stops the walk
+func (c *NFTService) EachNFTPage(ctx context.Context, walletAddr string, params models.QueryParams, fn func([]models.NFT) error) error {
+	ctx, span := tracer.Start(ctx, "NFTService.EachNFTPage")
+	defer span.End()
+
+	wallet, err := address.Parse(walletAddr)
+	if err != nil {
+		return fmt.Errorf("wallet address: %w", err)
+	}
+	pipeline, err := c.newWalletPipeline(params)
+	if err != nil {
+		return err
+	}
+	if params.Limit <= 0 || params.Limit > pageSize {
+		params.Limit = pageSize
+	}
+
+	for page := 1; ; page++ {
+		rawNFTs, next, err := c.moralisClient.GetNFTsByWalletPage(ctx, wallet.Hex(), params)
+		if err != nil {
+			return fmt.Errorf("fetching NFTs page %d: %w", page, err)
+		}
+
+		if nfts := c.runWalletPipeline(ctx, pipeline, rawNFTs); len(nfts) > 0 {
+			if err := fn(nfts); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			c.logger.DebugContext(ctx, "Walked wallet NFTs",
+				"wallet_address", wallet.Hex(),
+				"pages", page,
+			)
+			return nil
+		}
+		params.Cursor = &next
+	}
+}
+
+// EachHistoryPage
+// Explanation -> walks a wallet's transaction history page by page, newest first unless
+// params.Order is ASC, stopping after params.MaxPages pages when set
+// Return -> the first error from fetching or from fn, which stops the walk
+func (c *NFTService) EachHistoryPage(ctx context.Context, walletAddr string, params models.HistoryParams, fn func([]models.Transaction) error) error {
+	ctx, span := tracer.Start(ctx, "NFTService.EachHistoryPage")
+	defer span.End()
+
+	wallet, err := address.Parse(walletAddr)
+	if err != nil {
+		return fmt.Errorf("wallet address: %w", err)
+	}
+	if params.Limit <= 0 || params.Limit > pageSize {
+		params.Limit = pageSize
+	}
+
+	for page := 1; ; page++ {
+		txs, next, err := c.moralisClient.GetWalletHistory(ctx, wallet.Hex(), params)
+		if err != nil {
+			return fmt.Errorf("fetching history page %d: %w", page, err)
+		}
+
+		if len(txs) > 0 {
+			if err := fn(txs); err != nil {
+				return err
+			}
+		}
+
+		if next == "" || (params.MaxPages > 0 && page >= params.MaxPages) {
+			c.logger.DebugContext(ctx, "Walked wallet history",
+				"wallet_address", wallet.Hex(),
+				"pages", page,
+			)
+			return nil
+		}
+		params.Cursor = next
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/pkg/decimal"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errPortfolioFull stops the page walk once params.Limit NFTs are in
+var errPortfolioFull = errors.New("portfolio limit reached")
+
+// SetPriceProvider sets where token prices come from for valuations, nil means only
+// the USD floor prices Moralis sends are used
+func (c *NFTService) SetPriceProvider(p price.Provider) {
+	c.prices = p
+}
+
+// GetPortfolio
+// Explanation -> fetches every page of a wallet's NFTs with prices and values them at floor,
+// per collection and overall, in RON and USD. params filters apply, a limit values only the
+// first limit NFTs, 0 the whole wallet
+// Return -> the portfolio, NFTs without a usable floor price are counted as unpriced
+func (c *NFTService) GetPortfolio(ctx context.Context, walletAddr string, params models.QueryParams) (_ *models.Portfolio, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("GetPortfolio", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.GetPortfolio")
+	defer span.End()
+	span.SetAttributes(attribute.String("wallet_address", walletAddr))
+
+	params.IncludePrices = true
+	limit := params.Limit
+	params.Limit = pageSize
+
+	var nfts []models.NFT
+	err = c.EachNFTPage(ctx, walletAddr, params, func(page []models.NFT) error {
+		nfts = append(nfts, page...)
+		if limit > 0 && len(nfts) >= limit {
+			nfts = nfts[:limit]
+			return errPortfolioFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPortfolioFull) {
+		return nil, err
+	}
+
+	p := c.valuePortfolio(ctx, nfts)
+	p.Wallet = walletAddr
+
+	c.logger.InfoContext(ctx, "Portfolio valued",
+		"wallet_address", walletAddr,
+		"collections", len(p.Collections),
+		"nfts", p.NFTs,
+		"unpriced", p.Unpriced,
+		"total_usd", p.TotalUSD.String(),
+		"duration", time.Since(start),
+	)
+	return p, nil
+}
+
+// valuePortfolio sums floor values per collection, collections worth the most come first
+func (c *NFTService) valuePortfolio(ctx context.Context, nfts []models.NFT) *models.Portfolio {
+	p := &models.Portfolio{Prices: make(map[string]decimal.Decimal)}
+	used := make(map[string]*decimal.Decimal)
+
+	index := make(map[string]int)
+	for _, nft := range nfts {
+		key := strings.ToLower(nft.TokenAddress)
+		i, ok := index[key]
+		if !ok {
+			i = len(p.Collections)
+			index[key] = i
+			p.Collections = append(p.Collections, models.CollectionValue{
+				TokenAddress: nft.TokenAddress,
+				Name:         nft.Name,
+				FloorPrice:   nft.FloorPrice,
+				Currency:     nft.FloorPriceCurrency,
+			})
+		}
+		col := &p.Collections[i]
+
+		amount := heldAmount(nft)
+		col.Count += amount
+		p.NFTs += amount
+
+		ron, usd, ok := c.valueNFT(ctx, nft, used)
+		if !ok {
+			col.Unpriced += amount
+			p.Unpriced += amount
+			continue
+		}
+		col.ValueRON = col.ValueRON.Add(ron)
+		col.ValueUSD = col.ValueUSD.Add(usd)
+		p.TotalRON = p.TotalRON.Add(ron)
+		p.TotalUSD = p.TotalUSD.Add(usd)
+	}
+
+	for symbol, d := range used {
+		if d != nil {
+			p.Prices[symbol] = *d
+		}
+	}
+
+	sort.SliceStable(p.Collections, func(i, j int) bool {
+		a, b := p.Collections[i], p.Collections[j]
+		if cmp := a.ValueUSD.Cmp(b.ValueUSD); cmp != 0 {
+			return cmp > 0
+		}
+		return a.ValueRON.Cmp(b.ValueRON) > 0
+	})
+	return p
+}
+
+// valueNFT
+// Explanation -> values one holding at floor, USD comes from Moralis if it sent it, else from
+// the price provider, RON from the floor price if it's in RON, else converted from USD
+// Return -> the RON and USD value for the whole amount held, false if either can't be worked out
+func (c *NFTService) valueNFT(ctx context.Context, nft models.NFT, used map[string]*decimal.Decimal) (decimal.Decimal, decimal.Decimal, bool) {
+	floor, err := decimal.Parse(nft.FloorPrice)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, false
+	}
+	amount := decimal.FromInt(int64(heldAmount(nft)))
+	currency := price.NormalizeSymbol(nft.FloorPriceCurrency)
+
+	var usd decimal.Decimal
+	if fromAPI, err := decimal.Parse(nft.FloorPriceUSD); err == nil {
+		usd = fromAPI.Mul(amount)
+	} else {
+		if currency == "" {
+			return decimal.Zero, decimal.Zero, false
+		}
+		unit, ok := c.usdPrice(ctx, currency, used)
+		if !ok {
+			return decimal.Zero, decimal.Zero, false
+		}
+		usd = floor.Mul(unit).Mul(amount)
+	}
+
+	if currency == price.RON {
+		return floor.Mul(amount), usd, true
+	}
+
+	ronUSD, ok := c.usdPrice(ctx, price.RON, used)
+	if !ok {
+		return decimal.Zero, decimal.Zero, false
+	}
+	ron, ok := usd.Div(ronUSD)
+	return ron, usd, ok
+}
+
+// usdPrice asks the provider once per symbol per valuation and records what was used
+// a symbol without a price is recorded as a nil entry so it's only looked up (and logged) once
+func (c *NFTService) usdPrice(ctx context.Context, symbol string, used map[string]*decimal.Decimal) (decimal.Decimal, bool) {
+	if d, seen := used[symbol]; seen {
+		if d == nil {
+			return decimal.Zero, false
+		}
+		return *d, true
+	}
+	if c.prices == nil {
+		return decimal.Zero, false
+	}
+
+	d, err := c.prices.USDPrice(ctx, symbol)
+	if err != nil {
+		c.logger.WarnContext(ctx, "No token price",
+			"symbol", symbol,
+			"error", err,
+		)
+		used[symbol] = nil
+		return decimal.Zero, false
+	}
+	used[symbol] = &d
+	return d, true
+}
+
+// heldAmount is how many of the token the wallet holds, 1 for ERC721
+func heldAmount(nft models.NFT) int {
+	if !nft.IsERC1155() {
+		return 1
+	}
+	amount, err := strconv.Atoi(strings.TrimSpace(nft.Amount))
+	if err != nil || amount < 1 {
+		return 1
+	}
+	return amount
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/internal/tax"
+	"cmd/pkg/address"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TaxReport
+// Explanation -> replays the wallet's history oldest first through a tax ledger and reports
+// the disposals made in year, 0 for every year. History after year is never fetched, history
+// before it is, lots acquired earlier decide the basis
+// Return -> the report, tax.ErrNoHistoricalPrices if the price provider can't price past dates
+func (c *NFTService) TaxReport(ctx context.Context, walletAddr string, year int, method string) (_ *tax.Report, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveService("TaxReport", err, time.Since(start)) }()
+
+	ctx, span := tracer.Start(ctx, "NFTService.TaxReport")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("wallet_address", walletAddr),
+		attribute.Int("year", year),
+		attribute.String("method", method),
+	)
+
+	historical, ok := price.HistoricalOf(c.prices)
+	if !ok {
+		return nil, tax.ErrNoHistoricalPrices
+	}
+	wallet, err := address.Parse(walletAddr)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address: %w", err)
+	}
+
+	params := models.HistoryParams{Order: "ASC"}
+	if year > 0 {
+		params.ToDate = strconv.Itoa(year) + "-12-31T23:59:59Z"
+	}
+
+	ledger := tax.NewLedger(wallet.Hex(), method, historical)
+	err = c.EachHistoryPage(ctx, wallet.Hex(), params, func(txs []models.Transaction) error {
+		for _, tx := range txs {
+			if err := ledger.Add(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := ledger.Report(year)
+	c.logger.InfoContext(ctx, "Tax report built",
+		"wallet_address", wallet.Hex(),
+		"year", year,
+		"method", method,
+		"rows", len(r.Rows),
+		"missing_basis", r.MissingBasis,
+		"duration", time.Since(start),
+	)
+	return r, nil
+}
@@ -0,0 +1,86 @@
+package spam
+
+import (
+	"cmd/pkg/address"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lists are the user's own calls on contracts, they beat every rule
+type Lists struct {
+	Allow map[address.Address]bool
+	Deny  map[address.Address]bool
+}
+
+// listsFile is the JSON layout of a lists file
+type listsFile struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// LoadLists
+// Explanation -> builds lists from a JSON file ({"allow": [...], "deny": [...]}) and comma
+// separated env values, any address form pkg/address takes. path may be empty
+// Return -> the lists, an error for a bad file or address
+func LoadLists(path, allow, deny string) (*Lists, error) {
+	var file listsFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("reading spam lists: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &file); err != nil {
+				return nil, fmt.Errorf("parsing spam lists %s: %w", path, err)
+			}
+		}
+	}
+
+	lists := &Lists{
+		Allow: make(map[address.Address]bool),
+		Deny:  make(map[address.Address]bool),
+	}
+	if err := addAll(lists.Allow, append(file.Allow, splitList(allow)...)); err != nil {
+		return nil, fmt.Errorf("spam allow list: %w", err)
+	}
+	if err := addAll(lists.Deny, append(file.Deny, splitList(deny)...)); err != nil {
+		return nil, fmt.Errorf("spam deny list: %w", err)
+	}
+	return lists, nil
+}
+
+func addAll(set map[address.Address]bool, entries []string) error {
+	for _, entry := range entries {
+		addr, err := address.Parse(entry)
+		if err != nil {
+			return err
+		}
+		set[addr] = true
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// lookup reports whether the contract is allowed or denied, neither if it's on no list
+func (l *Lists) lookup(tokenAddress string) (allowed, denied bool) {
+	if l == nil {
+		return false, false
+	}
+	addr, err := address.Parse(tokenAddress)
+	if err != nil {
+		return false, false
+	}
+	return l.Allow[addr], l.Deny[addr]
+}
@@ -0,0 +1,175 @@
+package spam
+
+import (
+	"cmd/internal/models"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// Rule names, as they show up in reasons
+const (
+	ruleMoralis    = "moralis_possible_spam"
+	ruleAllowList  = "allow_list"
+	ruleDenyList   = "deny_list"
+	ruleUnverified = "unverified_collection"
+	ruleVerified   = "verified_collection"
+	ruleURLInName  = "url_in_name"
+	ruleBaitWords  = "bait_words"
+	ruleAirdrop    = "airdrop_pattern"
+	ruleBadLink    = "suspicious_link"
+	ruleNoMetadata = "missing_metadata"
+)
+
+// DefaultThreshold is the score at which an NFT counts as spam
+// Moralis' flag reaches it alone, an unverified collection needs one more strong signal
+const DefaultThreshold = 1.0
+
+// Weights are what each rule adds to the score, tune them with the spam report
+type Weights struct {
+	Moralis    float64
+	Unverified float64
+	Verified   float64 // negative, verified collections are rarely spam
+	URLInName  float64
+	BaitWords  float64
+	Airdrop    float64
+	BadLink    float64
+	NoMetadata float64
+}
+
+// DefaultWeights are the weights NewRules uses
+var DefaultWeights = Weights{
+	Moralis:    1.0,
+	Unverified: 0.3,
+	Verified:   -0.5,
+	URLInName:  0.7,
+	BaitWords:  0.5,
+	Airdrop:    0.5,
+	BadLink:    0.5,
+	NoMetadata: 0.2,
+}
+
+var (
+	// a URL or bare domain, scam tokens are named e.g. "Claim at rewards-ronin.xyz"
+	urlPattern = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|io|xyz|net|org|app|top|click|live|site|online|gg|fun|link|claim|vip|icu|pro)\b)`)
+
+	baitWords    = []string{"claim", "reward", "voucher", "giveaway", "free mint", "redeem", "visit", "bonus", "eligible"}
+	airdropWords = []string{"airdrop", "air drop", "gift", "you received", "you have won", "congratulations", "whitelist"}
+
+	// link shorteners and chat invites hide where a link goes
+	badHosts = []string{"bit.ly", "tinyurl.com", "cutt.ly", "t.me", "t.co", "is.gd", "rebrand.ly", "shorturl.at"}
+	badTLDs  = []string{".xyz", ".top", ".click", ".live", ".site", ".online", ".icu", ".vip", ".claim"}
+)
+
+// Rules is the rule-based classifier, it adds up weighted signals and compares to a threshold
+// allow and deny lists are checked first and decide on their own
+type Rules struct {
+	lists     *Lists
+	weights   Weights
+	threshold float64
+}
+
+// NewRules func creates a classifier with DefaultWeights, lists may be nil
+// threshold <= 0 uses DefaultThreshold
+func NewRules(lists *Lists, threshold float64) *Rules {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Rules{lists: lists, weights: DefaultWeights, threshold: threshold}
+}
+
+// SetWeights replaces the rule weights
+func (r *Rules) SetWeights(w Weights) {
+	r.weights = w
+}
+
+// Classify
+// Explanation -> runs every rule and records the ones that fired
+// Return -> the verdict, with reasons even when it isn't spam so near misses can be tuned
+func (r *Rules) Classify(nft models.NFT) Verdict {
+	allowed, denied := r.lists.lookup(nft.TokenAddress)
+	switch {
+	case denied:
+		return Verdict{Spam: true, Score: r.threshold, Reasons: []Reason{{Rule: ruleDenyList, Weight: r.threshold}}}
+	case allowed:
+		return Verdict{Spam: false, Reasons: []Reason{{Rule: ruleAllowList}}}
+	}
+
+	var v Verdict
+	add := func(rule, detail string, weight float64) {
+		if weight == 0 {
+			return
+		}
+		v.Score += weight
+		v.Reasons = append(v.Reasons, Reason{Rule: rule, Detail: detail, Weight: weight})
+	}
+
+	if nft.PossibleSpam {
+		add(ruleMoralis, "", r.weights.Moralis)
+	}
+	if nft.IsVerified {
+		add(ruleVerified, "", r.weights.Verified)
+	} else {
+		add(ruleUnverified, "", r.weights.Unverified)
+	}
+
+	names := strings.Join([]string{nft.Name, nft.TokenName, nft.Symbol}, " ")
+	if m := urlPattern.FindString(names); m != "" {
+		add(ruleURLInName, m, r.weights.URLInName)
+	}
+
+	text := strings.ToLower(names + " " + nft.Description)
+	if words := containsAny(text, baitWords); len(words) > 0 {
+		add(ruleBaitWords, strings.Join(words, ", "), r.weights.BaitWords)
+	}
+	if words := containsAny(text, airdropWords); len(words) > 0 {
+		add(ruleAirdrop, strings.Join(words, ", "), r.weights.Airdrop)
+	}
+
+	for _, link := range []string{nft.ExternalLink, nft.Image, nft.TokenURI} {
+		if host, ok := suspiciousHost(link); ok {
+			add(ruleBadLink, host, r.weights.BadLink)
+			break
+		}
+	}
+
+	if nft.Image == "" && nft.TokenName == "" && len(nft.Attributes) == 0 {
+		add(ruleNoMetadata, "", r.weights.NoMetadata)
+	}
+
+	v.Spam = v.Score >= r.threshold
+	return v
+}
+
+// containsAny returns the words found in text, text is already lowercase
+func containsAny(text string, words []string) []string {
+	var found []string
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// suspiciousHost reports links to shorteners or throwaway TLDs
+// ipfs://, ar:// and data: links have no host and are fine
+func suspiciousHost(link string) (string, bool) {
+	u, err := neturl.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, bad := range badHosts {
+		if host == bad || strings.HasSuffix(host, "."+bad) {
+			return host, true
+		}
+	}
+	for _, tld := range badTLDs {
+		if strings.HasSuffix(host, tld) {
+			return host, true
+		}
+	}
+	return "", false
+}
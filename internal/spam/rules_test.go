@@ -0,0 +1,177 @@
+package spam
+
+import (
+	"cmd/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	allowedContract = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+	deniedContract  = "0x1111111111111111111111111111111111111111"
+	otherContract   = "0x2222222222222222222222222222222222222222"
+)
+
+// plainNFT has metadata and no signals, only the unverified rule fires
+func plainNFT() models.NFT {
+	return models.NFT{
+		TokenAddress: otherContract,
+		Name:         "Pixels",
+		TokenName:    "Pixel #1",
+		Image:        "ipfs://Qm1",
+	}
+}
+
+func TestRulesClassify(t *testing.T) {
+	lists, err := LoadLists("", allowedContract, "ronin:"+deniedContract[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRules(lists, 0)
+
+	tests := []struct {
+		name  string
+		edit  func(n *models.NFT)
+		spam  bool
+		score float64
+		rules []string
+	}{
+		{"plain", func(n *models.NFT) {}, false, 0.3, []string{ruleUnverified}},
+		{"verified", func(n *models.NFT) { n.IsVerified = true }, false, -0.5, []string{ruleVerified}},
+		{"moralis flag", func(n *models.NFT) { n.PossibleSpam = true }, true, 1.3, []string{ruleMoralis, ruleUnverified}},
+		{
+			"url and bait in the name",
+			func(n *models.NFT) { n.Name = "Claim at rewards-ronin.xyz" },
+			true, 1.5, []string{ruleUnverified, ruleURLInName, ruleBaitWords},
+		},
+		{
+			"airdrop text and a shortened link",
+			func(n *models.NFT) {
+				n.Description = "Congratulations, you received an airdrop"
+				n.ExternalLink = "https://bit.ly/x"
+			},
+			true, 1.3, []string{ruleUnverified, ruleAirdrop, ruleBadLink},
+		},
+		{
+			"throwaway tld image",
+			func(n *models.NFT) { n.Image = "https://cdn.example.top/1.png" },
+			false, 0.8, []string{ruleUnverified, ruleBadLink},
+		},
+		{
+			"no metadata",
+			func(n *models.NFT) { n.Image, n.TokenName = "", "" },
+			false, 0.5, []string{ruleUnverified, ruleNoMetadata},
+		},
+		{
+			"deny list beats everything",
+			func(n *models.NFT) { n.TokenAddress, n.IsVerified = deniedContract, true },
+			true, 1, []string{ruleDenyList},
+		},
+		{
+			"allow list beats moralis",
+			func(n *models.NFT) {
+				n.TokenAddress = strings.ToUpper(allowedContract[:2]) + allowedContract[2:]
+				n.PossibleSpam = true
+				n.Name = "Free mint at www.scam.xyz"
+			},
+			false, 0, []string{ruleAllowList},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nft := plainNFT()
+			tt.edit(&nft)
+			v := r.Classify(nft)
+
+			if v.Spam != tt.spam {
+				t.Errorf("Spam = %v, want %v (%+v)", v.Spam, tt.spam, v)
+			}
+			if diff := v.Score - tt.score; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Score = %.2f, want %.2f", v.Score, tt.score)
+			}
+			var got []string
+			for _, reason := range v.Reasons {
+				got = append(got, reason.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.rules, ",") {
+				t.Errorf("rules = %v, want %v", got, tt.rules)
+			}
+		})
+	}
+}
+
+func TestRulesThreshold(t *testing.T) {
+	nft := plainNFT()
+	nft.Image, nft.TokenName = "", ""
+
+	if v := NewRules(nil, 0.5).Classify(nft); !v.Spam {
+		t.Errorf("0.5 threshold: %+v, want spam", v)
+	}
+	if v := NewRules(nil, -1).Classify(nft); v.Spam {
+		t.Errorf("default threshold: %+v, want not spam", v)
+	}
+
+	// a zero weight turns the rule off, it isn't even a reason
+	r := NewRules(nil, 0)
+	w := DefaultWeights
+	w.Unverified = 0
+	r.SetWeights(w)
+	if v := r.Classify(plainNFT()); v.Score != 0 || len(v.Reasons) != 0 {
+		t.Errorf("without the unverified weight: %+v, want nothing", v)
+	}
+}
+
+func TestMoralisFlag(t *testing.T) {
+	nft := plainNFT()
+	if v := (MoralisFlag{}).Classify(nft); v.Spam || len(v.Reasons) != 0 {
+		t.Errorf("unflagged: %+v", v)
+	}
+	nft.Name = "Claim at rewards-ronin.xyz" // the rules would call this spam, Moralis didn't
+	nft.PossibleSpam = true
+	if v := (MoralisFlag{}).Classify(nft); !v.Spam || v.Reasons[0].Rule != ruleMoralis {
+		t.Errorf("flagged: %+v", v)
+	}
+}
+
+func TestLoadLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lists.json")
+	body := `{"allow": ["` + allowedContract + `"], "deny": ["ronin:` + deniedContract[2:] + `"]}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lists, err := LoadLists(path, "", " "+otherContract+", ")
+	if err != nil {
+		t.Fatalf("LoadLists: %v", err)
+	}
+	for _, tt := range []struct {
+		contract        string
+		allowed, denied bool
+	}{
+		{allowedContract, true, false},
+		{deniedContract, false, true},
+		{otherContract, false, true},
+		{"0x3333333333333333333333333333333333333333", false, false},
+		{"not an address", false, false},
+	} {
+		if a, d := lists.lookup(tt.contract); a != tt.allowed || d != tt.denied {
+			t.Errorf("lookup(%s) = %v, %v, want %v, %v", tt.contract, a, d, tt.allowed, tt.denied)
+		}
+	}
+
+	if _, err := LoadLists(filepath.Join(t.TempDir(), "missing.json"), "", ""); err != nil {
+		t.Errorf("missing file: %v, want empty lists", err)
+	}
+	if _, err := LoadLists("", "0x12", ""); err == nil {
+		t.Error("bad allow address accepted")
+	}
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLists(path, "", ""); err == nil {
+		t.Error("bad lists file accepted")
+	}
+}
@@ -0,0 +1,67 @@
+package spam
+
+import (
+	"cmd/internal/models"
+	"fmt"
+	"strings"
+)
+
+// Reason is one rule that fired, with how much it counted
+type Reason struct {
+	Rule   string  `json:"rule"`
+	Detail string  `json:"detail,omitempty"`
+	Weight float64 `json:"weight"` // negative for rules that make spam less likely
+}
+
+func (r Reason) String() string {
+	if r.Detail == "" {
+		return fmt.Sprintf("%s (%+.2f)", r.Rule, r.Weight)
+	}
+	return fmt.Sprintf("%s: %s (%+.2f)", r.Rule, r.Detail, r.Weight)
+}
+
+// Verdict is a classifier's decision and everything that went into it
+type Verdict struct {
+	Spam    bool     `json:"spam"`
+	Score   float64  `json:"score"`
+	Reasons []Reason `json:"reasons,omitempty"`
+}
+
+// Classifier decides whether an NFT is spam
+type Classifier interface {
+	Classify(nft models.NFT) Verdict
+}
+
+// MoralisFlag trusts Moralis' possible_spam and nothing else, the original behaviour
+type MoralisFlag struct{}
+
+// Classify returns Moralis' verdict
+func (MoralisFlag) Classify(nft models.NFT) Verdict {
+	if !nft.PossibleSpam {
+		return Verdict{}
+	}
+	return Verdict{
+		Spam:    true,
+		Score:   1,
+		Reasons: []Reason{{Rule: ruleMoralis, Weight: 1}},
+	}
+}
+
+// Modes for what happens to spam
+const (
+	ModeDrop = "drop" // leave it out of results (default)
+	ModeFlag = "flag" // keep it, with PossibleSpam and SpamReasons set
+	ModeOnly = "only" // keep only spam, for checking what would be dropped
+)
+
+// ParseMode checks a mode name, "" means ModeDrop
+func ParseMode(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "":
+		return ModeDrop, nil
+	case ModeDrop, ModeFlag, ModeOnly:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown spam mode %q, expected drop, flag or only", s)
+	}
+}
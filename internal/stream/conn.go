@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"cmd/internal/ronin"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// noteBuffer is how many notifications a connection holds for a slow handler, past that the
+// connection is dropped and the blocks it missed backfilled on the next one
+const noteBuffer = 4096
+
+// writeTimeout bounds a single request write, a node that stops reading is a dead connection
+const writeTimeout = 10 * time.Second
+
+// readTimeout is how long a connection may go quiet, Ronin makes a block every 3s so a
+// newHeads subscription that says nothing for this long is dead
+const readTimeout = time.Minute
+
+// errOverflow means notifications came faster than the handler took them
+var errOverflow = errors.New("stream: handler fell behind, notifications dropped")
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// rpcMessage is anything the node sends: a response to one of our calls (ID set) or a
+// subscription notification (Method eth_subscription)
+type rpcMessage struct {
+	ID     *uint64          `json:"id"`
+	Result json.RawMessage  `json:"result"`
+	Error  *ronin.RPCError  `json:"error"`
+	Method string           `json:"method"`
+	Params *subscriptionMsg `json:"params"`
+}
+
+type subscriptionMsg struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// conn is JSON-RPC over one WebSocket connection: calls wait for their response by id while
+// notifications queue up for the subscriber
+type conn struct {
+	ws     *websocket.Conn
+	nextID atomic.Uint64
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint64]chan rpcMessage
+
+	notes chan subscriptionMsg
+	done  chan struct{}
+	err   error // why the connection closed, set before done is closed
+}
+
+// dial opens the connection and starts reading from it
+func dial(ctx context.Context, dialer *websocket.Dialer, url string, header http.Header) (*conn, error) {
+	ws, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("dialing %s: %w (status %d)", url, err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("dialing %s: %w", url, err)
+	}
+
+	c := &conn{
+		ws:      ws,
+		pending: make(map[uint64]chan rpcMessage),
+		notes:   make(chan subscriptionMsg, noteBuffer),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop hands responses to their callers and queues notifications until the connection fails
+func (c *conn) readLoop() {
+	var err error
+	for {
+		var msg rpcMessage
+		c.ws.SetReadDeadline(time.Now().Add(readTimeout))
+		if err = c.ws.ReadJSON(&msg); err != nil {
+			break
+		}
+
+		if msg.ID != nil {
+			c.mu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+			continue
+		}
+		if msg.Method == "eth_subscription" && msg.Params != nil {
+			select {
+			case c.notes <- *msg.Params:
+			default:
+				err = errOverflow
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	c.err = err
+	close(c.done)
+	c.ws.Close()
+}
+
+// call sends one request and waits for its response
+// Return -> *ronin.RPCError for errors reported by the node, other errors for a broken connection
+func (c *conn) call(ctx context.Context, method string, result any, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	id := c.nextID.Add(1)
+	ch := make(chan rpcMessage, 1)
+
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := c.ws.WriteJSON(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	c.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("sending %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("decoding %s result: %w", method, err)
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("calling %s: %w", method, c.err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close ends the connection, readLoop notices and closes done
+func (c *conn) close() {
+	c.writeMu.Lock()
+	c.ws.SetWriteDeadline(time.Now().Add(time.Second))
+	c.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.writeMu.Unlock()
+	c.ws.Close()
+	<-c.done
+}
@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"cmd/internal/models"
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TransferTopic is topic 0 of Transfer(address,address,uint256), the same event for ERC-20
+// and ERC-721. ERC-721 indexes the token id too, so the two differ in topic count
+var TransferTopic = "0x" + hex.EncodeToString(ronin.Keccak256([]byte("Transfer(address,address,uint256)")))
+
+// errNotTransfer is a log that isn't an ERC-20 or ERC-721 Transfer
+var errNotTransfer = errors.New("not a transfer log")
+
+// rpcLog is a log as eth_getLogs and logs subscriptions return it
+type rpcLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	BlockHash       string   `json:"blockHash"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+	Removed         bool     `json:"removed"`
+}
+
+// rpcHead is the part of a newHeads notification we use
+type rpcHead struct {
+	Number     string `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// decodeHead turns a newHeads notification into a head event
+func decodeHead(h rpcHead) (Event, error) {
+	number, err := ronin.ParseQuantity(h.Number)
+	if err != nil {
+		return Event{}, fmt.Errorf("head number: %w", err)
+	}
+	e := Event{Kind: KindHead, BlockNumber: number, BlockHash: h.Hash}
+	if ts, err := ronin.ParseQuantity(h.Timestamp); err == nil {
+		e.BlockTime = time.Unix(int64(ts), 0).UTC()
+	}
+	return e, nil
+}
+
+// decodeLog
+// Explanation -> decodes a Transfer log into an NFT or ERC-20 transfer event. Direction is
+// relative to the watched addresses: receive if one of them is the recipient, send if only
+// the sender is, blank when nothing is watched
+// Return -> the event, errNotTransfer for other logs
+func decodeLog(l rpcLog, watched map[address.Address]bool, format address.Format) (Event, error) {
+	if len(l.Topics) < 3 || !strings.EqualFold(l.Topics[0], TransferTopic) {
+		return Event{}, errNotTransfer
+	}
+	block, err := ronin.ParseQuantity(l.BlockNumber)
+	if err != nil {
+		return Event{}, fmt.Errorf("log block number: %w", err)
+	}
+	index, err := ronin.ParseQuantity(l.LogIndex)
+	if err != nil {
+		return Event{}, fmt.Errorf("log index: %w", err)
+	}
+	token, err := address.Parse(l.Address)
+	if err != nil {
+		return Event{}, fmt.Errorf("log address: %w", err)
+	}
+	from, err := topicAddress(l.Topics[1])
+	if err != nil {
+		return Event{}, fmt.Errorf("transfer from: %w", err)
+	}
+	to, err := topicAddress(l.Topics[2])
+	if err != nil {
+		return Event{}, fmt.Errorf("transfer to: %w", err)
+	}
+
+	direction := ""
+	switch {
+	case watched[to]:
+		direction = "receive"
+	case watched[from]:
+		direction = "send"
+	}
+
+	e := Event{
+		BlockNumber: block,
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TransactionHash,
+		LogIndex:    int(index),
+		Removed:     l.Removed,
+	}
+
+	switch len(l.Topics) {
+	case 4: // ERC-721, the token id is indexed
+		id, err := topicUint(l.Topics[3])
+		if err != nil {
+			return Event{}, fmt.Errorf("token id: %w", err)
+		}
+		e.Kind = KindNFT
+		e.NFT = &models.NFTTransfer{
+			LogIndex:        int(index),
+			Value:           "0",
+			ContractType:    "ERC721",
+			TransactionType: "Single",
+			TokenAddress:    token.Format(format),
+			TokenID:         id,
+			FromAddress:     from.Format(format),
+			ToAddress:       to.Format(format),
+			Amount:          "1",
+			Direction:       direction,
+		}
+	case 3: // ERC-20, the value is the data
+		data, err := ronin.DecodeHex(l.Data)
+		if err != nil {
+			return Event{}, fmt.Errorf("transfer value: %w", err)
+		}
+		value, err := ronin.DecodeUint(data, 0)
+		if err != nil {
+			return Event{}, fmt.Errorf("transfer value: %w", err)
+		}
+		e.Kind = KindERC20
+		e.ERC20 = &models.ERC20Transfer{
+			Address:     token.Format(format),
+			FromAddress: from.Format(format),
+			ToAddress:   to.Format(format),
+			LogIndex:    int(index),
+			Value:       value.String(),
+			Direction:   direction,
+		}
+	default:
+		return Event{}, errNotTransfer
+	}
+	return e, nil
+}
+
+// topicAddress reads an address left padded into a 32 byte topic
+func topicAddress(topic string) (address.Address, error) {
+	b, err := ronin.DecodeHex(topic)
+	if err != nil {
+		return address.Address{}, err
+	}
+	return ronin.DecodeAddress(b, 0)
+}
+
+// topicUint reads a uint256 topic as a decimal string
+func topicUint(topic string) (string, error) {
+	b, err := ronin.DecodeHex(topic)
+	if err != nil {
+		return "", err
+	}
+	n, err := ronin.DecodeUint(b, 0)
+	if err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// addressTopic is an address as a topic filter value
+func addressTopic(a address.Address) string {
+	return "0x" + hex.EncodeToString(ronin.EncodeAddress(a))
+}
@@ -0,0 +1,388 @@
+// Package stream follows Ronin as blocks are produced, over a node's WebSocket eth_subscribe:
+// new heads, and the ERC-20/ERC-721 Transfer logs of watched addresses decoded into the same
+// transfer models as wallet history. A dropped connection is redialled and the blocks it
+// missed are backfilled with eth_getLogs from the last processed block
+package stream
+
+import (
+	"cmd/internal/metrics"
+	"cmd/internal/models"
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event kinds
+const (
+	KindHead  = "head"
+	KindNFT   = "nft"
+	KindERC20 = "erc20"
+)
+
+// Defaults for Options left zero
+const (
+	defaultBackfillChunk = 500
+	defaultReconnectMin  = time.Second
+	defaultReconnectMax  = 30 * time.Second
+)
+
+// seenDepth is how many blocks back delivered logs are remembered, so a backfill overlapping
+// what already arrived live doesn't deliver it twice
+const seenDepth = 128
+
+// ErrNoURL means there's no node to connect to
+var ErrNoURL = errors.New("stream: no WebSocket URL, set RONIN_WS_URL")
+
+// Event is a new block or a transfer in one
+type Event struct {
+	Kind        string    `json:"kind"`
+	BlockNumber uint64    `json:"block_number"`
+	BlockHash   string    `json:"block_hash,omitempty"`
+	BlockTime   time.Time `json:"block_time,omitzero"` // heads only, logs don't carry it
+	TxHash      string    `json:"transaction_hash,omitempty"`
+	LogIndex    int       `json:"log_index,omitempty"`
+	Removed     bool      `json:"removed,omitempty"`    // the log was undone by a reorg
+	Backfilled  bool      `json:"backfilled,omitempty"` // found by eth_getLogs after a reconnect, not live
+
+	NFT   *models.NFTTransfer   `json:"nft,omitempty"`
+	ERC20 *models.ERC20Transfer `json:"erc20,omitempty"`
+}
+
+// Options configures a Subscriber
+type Options struct {
+	URL       string   // ws:// or wss:// node endpoint
+	Addresses []string // watched, any format, empty watches every Transfer on chain
+	FromBlock uint64   // backfill from this block on the first connection, 0 starts at the head
+
+	BackfillChunk uint64 // blocks per eth_getLogs call
+	ReconnectMin  time.Duration
+	ReconnectMax  time.Duration
+
+	AddressFormat address.Format // how addresses in transfers are written
+}
+
+// Subscriber struct follows the chain for one set of watched addresses
+type Subscriber struct {
+	opts    Options
+	watched map[address.Address]bool
+	filters []map[string]any // one logs filter per side of the transfer
+	dialer  *websocket.Dialer
+	logger  *logger.Logger
+
+	mu   sync.Mutex
+	last uint64 // highest block processed, backfills start here
+	seen map[logKey]uint64
+}
+
+// logKey identifies a delivered log, removals are separate from the log they undo
+type logKey struct {
+	blockHash string
+	txHash    string
+	index     int
+	removed   bool
+}
+
+// New
+// Explanation -> validates the options and builds the logs filters: Transfers from or to the
+// watched addresses, or every Transfer when none are given
+// Return -> the subscriber, not yet connected
+func New(opts Options, log *logger.Logger) (*Subscriber, error) {
+	if opts.URL == "" {
+		return nil, ErrNoURL
+	}
+	if opts.BackfillChunk == 0 {
+		opts.BackfillChunk = defaultBackfillChunk
+	}
+	if opts.ReconnectMin <= 0 {
+		opts.ReconnectMin = defaultReconnectMin
+	}
+	if opts.ReconnectMax < opts.ReconnectMin {
+		opts.ReconnectMax = max(defaultReconnectMax, opts.ReconnectMin)
+	}
+
+	s := &Subscriber{
+		opts:    opts,
+		watched: make(map[address.Address]bool, len(opts.Addresses)),
+		dialer:  &websocket.Dialer{HandshakeTimeout: 15 * time.Second},
+		logger:  log.WithGroup("stream"),
+		seen:    make(map[logKey]uint64),
+	}
+
+	topics := make([]string, 0, len(opts.Addresses))
+	for _, a := range opts.Addresses {
+		addr, err := address.Parse(a)
+		if err != nil {
+			return nil, fmt.Errorf("watched address %q: %w", a, err)
+		}
+		if !s.watched[addr] {
+			s.watched[addr] = true
+			topics = append(topics, addressTopic(addr))
+		}
+	}
+	if len(topics) == 0 {
+		s.filters = []map[string]any{{"topics": []any{TransferTopic}}}
+	} else {
+		s.filters = []map[string]any{
+			{"topics": []any{TransferTopic, topics}},
+			{"topics": []any{TransferTopic, nil, topics}},
+		}
+	}
+	return s, nil
+}
+
+// LastBlock is the highest block processed so far, what a caller would persist to resume with
+// Options.FromBlock after a restart
+func (s *Subscriber) LastBlock() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Run
+// Explanation -> connects, subscribes and calls handle for every event until ctx is done,
+// redialling with backoff whenever the connection drops. handle runs on one goroutine, in
+// chain order; one slow enough to back up the connection gets it dropped, and what it missed
+// is backfilled on the next one
+// Return -> ctx's error once it's done
+func (s *Subscriber) Run(ctx context.Context, handle func(Event)) error {
+	delay := s.opts.ReconnectMin
+	for {
+		started := time.Now()
+		err := s.session(ctx, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// a connection that lasted a while starts the backoff over
+		if time.Since(started) > s.opts.ReconnectMax {
+			delay = s.opts.ReconnectMin
+		}
+		metrics.IncStreamReconnect()
+		s.logger.WarnContext(ctx, "Stream disconnected, reconnecting",
+			"error", err,
+			"last_block", s.LastBlock(),
+			"delay", delay,
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay = min(delay*2, s.opts.ReconnectMax)
+	}
+}
+
+// session is one connection: subscribe, backfill what was missed, then deliver live events
+// until the connection fails
+func (s *Subscriber) session(ctx context.Context, handle func(Event)) error {
+	c, err := dial(ctx, s.dialer, s.opts.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	// subscribe before looking at the head, so nothing falls between backfill and live
+	subs := make(map[string]string, 1+len(s.filters))
+	var id string
+	if err := c.call(ctx, "eth_subscribe", &id, "newHeads"); err != nil {
+		return fmt.Errorf("subscribing to heads: %w", err)
+	}
+	subs[id] = KindHead
+	for _, f := range s.filters {
+		if err := c.call(ctx, "eth_subscribe", &id, "logs", f); err != nil {
+			return fmt.Errorf("subscribing to logs: %w", err)
+		}
+		subs[id] = "logs"
+	}
+
+	var headHex string
+	if err := c.call(ctx, "eth_blockNumber", &headHex); err != nil {
+		return err
+	}
+	head, err := ronin.ParseQuantity(headHex)
+	if err != nil {
+		return fmt.Errorf("block number: %w", err)
+	}
+
+	from, resume := s.resumeFrom()
+	if resume && from <= head {
+		if err := s.backfill(ctx, c, from, head, handle); err != nil {
+			return err
+		}
+	}
+	s.advance(head)
+	s.logger.InfoContext(ctx, "Stream subscribed",
+		"head", head,
+		"backfilled_from", from,
+		"watched", len(s.watched),
+	)
+
+	for {
+		select {
+		case note := <-c.notes:
+			if err := s.handleNote(ctx, subs, note, handle); err != nil {
+				return err
+			}
+		case <-c.done:
+			// what arrived before the drop is still good, deliver it before reconnecting
+			for {
+				select {
+				case note := <-c.notes:
+					if err := s.handleNote(ctx, subs, note, handle); err != nil {
+						return err
+					}
+				default:
+					return c.err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleNote delivers one subscription notification, subs maps subscription ids to kinds
+func (s *Subscriber) handleNote(ctx context.Context, subs map[string]string, note subscriptionMsg, handle func(Event)) error {
+	switch subs[note.Subscription] {
+	case KindHead:
+		var h rpcHead
+		if err := json.Unmarshal(note.Result, &h); err != nil {
+			return fmt.Errorf("decoding head: %w", err)
+		}
+		e, err := decodeHead(h)
+		if err != nil {
+			return err
+		}
+		s.advance(e.BlockNumber)
+		metrics.IncStreamEvent(KindHead)
+		handle(e)
+	case "logs":
+		var l rpcLog
+		if err := json.Unmarshal(note.Result, &l); err != nil {
+			return fmt.Errorf("decoding log: %w", err)
+		}
+		s.deliver(ctx, l, false, handle)
+	}
+	return nil
+}
+
+// resumeFrom is where a backfill starts: the last processed block again, since its logs may
+// not all have arrived, or Options.FromBlock before anything was processed
+func (s *Subscriber) resumeFrom() (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.last > 0:
+		return s.last, true
+	case s.opts.FromBlock > 0:
+		return s.opts.FromBlock, true
+	default:
+		return 0, false
+	}
+}
+
+// backfill delivers the Transfer logs in blocks from..to, in chunks, oldest first
+func (s *Subscriber) backfill(ctx context.Context, c *conn, from, to uint64, handle func(Event)) error {
+	start := time.Now()
+	for lo := from; lo <= to; lo += s.opts.BackfillChunk {
+		hi := min(lo+s.opts.BackfillChunk-1, to)
+
+		var logs []rpcLog
+		for _, f := range s.filters {
+			query := map[string]any{
+				"fromBlock": ronin.FormatQuantity(lo),
+				"toBlock":   ronin.FormatQuantity(hi),
+			}
+			for k, v := range f {
+				query[k] = v
+			}
+			var batch []rpcLog
+			if err := c.call(ctx, "eth_getLogs", &batch, query); err != nil {
+				return fmt.Errorf("backfilling blocks %d-%d: %w", lo, hi, err)
+			}
+			logs = append(logs, batch...)
+		}
+
+		sort.SliceStable(logs, func(i, j int) bool {
+			bi, _ := ronin.ParseQuantity(logs[i].BlockNumber)
+			bj, _ := ronin.ParseQuantity(logs[j].BlockNumber)
+			if bi != bj {
+				return bi < bj
+			}
+			li, _ := ronin.ParseQuantity(logs[i].LogIndex)
+			lj, _ := ronin.ParseQuantity(logs[j].LogIndex)
+			return li < lj
+		})
+		for _, l := range logs {
+			s.deliver(ctx, l, true, handle)
+		}
+		s.advance(hi)
+	}
+
+	s.logger.InfoContext(ctx, "Stream backfilled",
+		"from_block", from,
+		"to_block", to,
+		"duration", time.Since(start),
+	)
+	return nil
+}
+
+// deliver decodes a log and hands it on, unless it was delivered already
+func (s *Subscriber) deliver(ctx context.Context, l rpcLog, backfilled bool, handle func(Event)) {
+	e, err := decodeLog(l, s.watched, s.opts.AddressFormat)
+	if err != nil {
+		if !errors.Is(err, errNotTransfer) {
+			s.logger.WarnContext(ctx, "Skipping undecodable log",
+				"error", err,
+				"transaction_hash", l.TransactionHash,
+			)
+		}
+		return
+	}
+	if !s.firstSeen(e) {
+		return
+	}
+	e.Backfilled = backfilled
+	metrics.IncStreamEvent(e.Kind)
+	handle(e)
+}
+
+// firstSeen records a transfer event, false if it was recorded before
+func (s *Subscriber) firstSeen(e Event) bool {
+	key := logKey{blockHash: e.BlockHash, txHash: e.TxHash, index: e.LogIndex, removed: e.Removed}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = e.BlockNumber
+	return true
+}
+
+// advance moves the last processed block forward and forgets logs too old to be backfilled again
+func (s *Subscriber) advance(block uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if block <= s.last {
+		return
+	}
+	s.last = block
+	metrics.SetStreamHead(block)
+	for key, b := range s.seen {
+		if b+seenDepth < block {
+			delete(s.seen, key)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package stream
+
+import (
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	watched = address.MustParse("0x1111111111111111111111111111111111111111")
+	other   = address.MustParse("0x2222222222222222222222222222222222222222")
+	axie    = address.MustParse("0x32950db2a7164ae833121501c797d79e7b79d74c")
+	slp     = address.MustParse("0xa8754b9fa15fc18bb59458815510e40a12cd2014")
+)
+
+// fakeNode is a WebSocket JSON-RPC node. Each connection gets the head and logs of the next
+// script, then the script's live notifications once the client has asked for the head
+type fakeNode struct {
+	t       *testing.T
+	scripts []nodeScript
+
+	mu       sync.Mutex
+	conns    int
+	getLogs  [][2]uint64 // block ranges asked for, by every connection
+	upgrader websocket.Upgrader
+}
+
+type nodeScript struct {
+	head  uint64
+	logs  []rpcLog // served by eth_getLogs, filtered by block range only
+	live  []any    // notifications: rpcHead or rpcLog
+	close bool     // drop the connection after the live notifications
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := n.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		n.t.Errorf("upgrade: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	n.mu.Lock()
+	script := n.scripts[min(n.conns, len(n.scripts)-1)]
+	n.conns++
+	n.mu.Unlock()
+
+	var writeMu sync.Mutex
+	write := func(v any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return ws.WriteJSON(v)
+	}
+
+	subs := map[string]string{}
+	for {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_subscribe":
+			var kind string
+			json.Unmarshal(req.Params[0], &kind)
+			id := ronin.FormatQuantity(uint64(len(subs) + 1))
+			subs[id] = kind
+			result = id
+		case "eth_blockNumber":
+			result = ronin.FormatQuantity(script.head)
+		case "eth_getLogs":
+			var q struct{ FromBlock, ToBlock string }
+			json.Unmarshal(req.Params[0], &q)
+			from, _ := ronin.ParseQuantity(q.FromBlock)
+			to, _ := ronin.ParseQuantity(q.ToBlock)
+			n.mu.Lock()
+			n.getLogs = append(n.getLogs, [2]uint64{from, to})
+			n.mu.Unlock()
+			logs := []rpcLog{}
+			for _, l := range script.logs {
+				if b, _ := ronin.ParseQuantity(l.BlockNumber); b >= from && b <= to {
+					logs = append(logs, l)
+				}
+			}
+			result = logs
+		default:
+			n.t.Errorf("unexpected method %s", req.Method)
+		}
+		if err := write(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result}); err != nil {
+			return
+		}
+
+		if req.Method != "eth_blockNumber" {
+			continue
+		}
+		for _, note := range script.live {
+			kind := "logs"
+			if _, ok := note.(rpcHead); ok {
+				kind = "newHeads"
+			}
+			var sub string
+			for id, k := range subs {
+				if k == kind {
+					sub = id
+					break
+				}
+			}
+			write(map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params":  map[string]any{"subscription": sub, "result": note},
+			})
+		}
+		if script.close {
+			return
+		}
+	}
+}
+
+func head(n uint64) rpcHead {
+	return rpcHead{Number: ronin.FormatQuantity(n), Hash: ronin.FormatQuantity(n * 1000), Timestamp: ronin.FormatQuantity(1_700_000_000 + n*3)}
+}
+
+// transferLog is a Transfer log, an ERC-721 one when id is set, else ERC-20 for value
+func transferLog(token, from, to address.Address, id string, value int64, block uint64, index uint64) rpcLog {
+	word := func(n *big.Int) string { return "0x" + hex.EncodeToString(ronin.EncodeUint(n)) }
+	l := rpcLog{
+		Address:         token.Hex(),
+		Topics:          []string{TransferTopic, addressTopic(from), addressTopic(to)},
+		Data:            "0x",
+		BlockNumber:     ronin.FormatQuantity(block),
+		BlockHash:       ronin.FormatQuantity(block * 1000),
+		TransactionHash: ronin.FormatQuantity(block*100 + index),
+		LogIndex:        ronin.FormatQuantity(index),
+	}
+	if id != "" {
+		n, _ := new(big.Int).SetString(id, 10)
+		l.Topics = append(l.Topics, word(n))
+	} else {
+		l.Data = word(big.NewInt(value))
+	}
+	return l
+}
+
+func TestTransferTopic(t *testing.T) {
+	const want = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	if TransferTopic != want {
+		t.Fatalf("TransferTopic = %s, want %s", TransferTopic, want)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	watch := map[address.Address]bool{watched: true}
+
+	e, err := decodeLog(transferLog(axie, other, watched, "123456", 0, 10, 2), watch, address.FormatRonin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Kind != KindNFT || e.NFT == nil || e.BlockNumber != 10 || e.LogIndex != 2 {
+		t.Fatalf("got %+v", e)
+	}
+	if e.NFT.TokenID != "123456" || e.NFT.Direction != "receive" || e.NFT.ToAddress != watched.Ronin() {
+		t.Fatalf("got nft %+v", e.NFT)
+	}
+
+	e, err = decodeLog(transferLog(slp, watched, other, "", 750, 11, 0), watch, address.FormatHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Kind != KindERC20 || e.ERC20 == nil || e.ERC20.Value != "750" || e.ERC20.Direction != "send" {
+		t.Fatalf("got %+v", e.ERC20)
+	}
+
+	approval := transferLog(slp, watched, other, "", 1, 11, 1)
+	approval.Topics[0] = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+	if _, err := decodeLog(approval, watch, address.FormatHex); err != errNotTransfer {
+		t.Fatalf("approval log: err = %v, want errNotTransfer", err)
+	}
+}
+
+// TestReconnectBackfill drops the first connection and checks the second backfills from the
+// last processed block, without repeating the log that already arrived live
+func TestReconnectBackfill(t *testing.T) {
+	nft := transferLog(axie, other, watched, "42", 0, 101, 0)
+	erc20 := transferLog(slp, watched, other, "", 500, 102, 3)
+	unrelated := transferLog(axie, other, other, "7", 0, 102, 4) // no watched side, the node wouldn't send it but decoding copes
+
+	node := &fakeNode{t: t, scripts: []nodeScript{
+		{head: 100, live: []any{head(101), nft}, close: true},
+		{head: 103, logs: []rpcLog{nft, erc20, unrelated}, live: []any{head(104)}},
+	}}
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+
+	sub, err := New(Options{
+		URL:          "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Addresses:    []string{watched.Ronin()},
+		ReconnectMin: 10 * time.Millisecond,
+		ReconnectMax: 50 * time.Millisecond,
+	}, logger.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []Event
+	err = sub.Run(ctx, func(e Event) {
+		events = append(events, e)
+		if e.Kind == KindHead && e.BlockNumber == 104 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	type got struct {
+		kind       string
+		block      uint64
+		backfilled bool
+	}
+	want := []got{
+		{KindHead, 101, false},
+		{KindNFT, 101, false},
+		{KindERC20, 102, true},
+		{KindNFT, 102, true},
+		{KindHead, 104, false},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %+v, want %d", len(events), events, len(want))
+	}
+	for i, w := range want {
+		e := events[i]
+		if (got{e.Kind, e.BlockNumber, e.Backfilled}) != w {
+			t.Errorf("event %d = %+v, want %+v", i, got{e.Kind, e.BlockNumber, e.Backfilled}, w)
+		}
+	}
+	if events[1].NFT.TokenID != "42" || events[1].NFT.Direction != "receive" {
+		t.Errorf("nft = %+v", events[1].NFT)
+	}
+	if events[2].ERC20.Value != "500" || events[2].ERC20.Direction != "send" {
+		t.Errorf("erc20 = %+v", events[2].ERC20)
+	}
+	if !events[0].BlockTime.Equal(time.Unix(1_700_000_303, 0)) {
+		t.Errorf("head time = %v", events[0].BlockTime)
+	}
+
+	// no backfill on the first connection, then from the last processed block to the head
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	for _, r := range node.getLogs {
+		if r != [2]uint64{101, 103} {
+			t.Errorf("eth_getLogs range %v, want [101 103]", r)
+		}
+	}
+	if len(node.getLogs) != 2 { // one per filter side
+		t.Errorf("eth_getLogs called %d times, want 2", len(node.getLogs))
+	}
+	if sub.LastBlock() != 104 {
+		t.Errorf("LastBlock = %d, want 104", sub.LastBlock())
+	}
+}
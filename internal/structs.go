@@ -256,8 +256,16 @@ type NFTTransfer struct {
 	NormalizedMetadata    *NormalizedMetadata `json:"normalized_metadata,omitempty"`
 }
 
+// NativeTransfer is RON moving in a transaction, the transaction's own value or an internal
+// call paying out, e.g. a marketplace sale paying the seller
 type NativeTransfer struct {
-	// Add fields as needed when native transfers are present
+	FromAddress         string `json:"from_address"`
+	ToAddress           string `json:"to_address"`
+	Value               string `json:"value"` // wei
+	ValueFormatted      string `json:"value_formatted"`
+	Direction           string `json:"direction"`
+	InternalTransaction bool   `json:"internal_transaction"` // false for the transaction's own value
+	TokenSymbol         string `json:"token_symbol"`
 }
 
 // Client struct
@@ -0,0 +1,64 @@
+package tax
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// form8949Header follows the form's columns (a) to (h), with the term and transaction after
+var form8949Header = []string{
+	"Description of property",
+	"Date acquired",
+	"Date sold or disposed of",
+	"Proceeds",
+	"Cost or other basis",
+	"Code",
+	"Amount of adjustment",
+	"Gain or (loss)",
+	"Term",
+	"Transaction hash",
+}
+
+// WriteForm8949
+// Explanation -> writes the report's rows as a Form 8949 style CSV, dollars to the cent,
+// dates as MM/DD/YYYY. Rows with no lot to match have an UNKNOWN acquired date and a 0 basis
+// for whoever prepares the return to fill in
+// Return -> the first write error
+func WriteForm8949(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(form8949Header); err != nil {
+		return fmt.Errorf("writing form 8949: %w", err)
+	}
+
+	for _, row := range r.Rows {
+		acquired := "UNKNOWN"
+		if !row.MissingBasis {
+			acquired = row.Acquired.UTC().Format("01/02/2006")
+		}
+		term := "Short"
+		if row.LongTerm {
+			term = "Long"
+		}
+		if err := cw.Write([]string{
+			row.Description,
+			acquired,
+			row.Sold.UTC().Format("01/02/2006"),
+			row.Proceeds.StringFixed(2),
+			row.Cost.StringFixed(2),
+			"",
+			"",
+			row.Gain.StringFixed(2),
+			term,
+			row.Hash,
+		}); err != nil {
+			return fmt.Errorf("writing form 8949: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing form 8949: %w", err)
+	}
+	return nil
+}
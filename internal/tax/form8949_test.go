@@ -0,0 +1,35 @@
+package tax
+
+import (
+	"cmd/pkg/decimal"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWriteForm8949(t *testing.T) {
+	prices, txs := methodHistory()
+	l := NewLedger(wallet, FIFO, prices)
+	for _, tx := range txs {
+		if err := l.Add(context.Background(), tx); err != nil {
+			t.Fatalf("Add %s: %v", tx.Hash, err)
+		}
+	}
+	// a sale with nothing to match, before the history starts
+	l.dispose(leg{asset: "erc20:0xaxs", description: "1 AXS", quantity: decimal.FromInt(1), usd: decimal.FromInt(1)},
+		day("2024-05-01"), "0xorphan")
+
+	var b strings.Builder
+	if err := WriteForm8949(&b, l.Report(2024)); err != nil {
+		t.Fatalf("WriteForm8949: %v", err)
+	}
+	want := strings.Join([]string{
+		"Description of property,Date acquired,Date sold or disposed of,Proceeds,Cost or other basis,Code,Amount of adjustment,Gain or (loss),Term,Transaction hash",
+		"10 RON,01/01/2024,04/01/2024,38.00,10.00,,,28.00,Short,0xsale",
+		"5 RON,02/01/2024,04/01/2024,19.00,15.00,,,4.00,Short,0xsale",
+		"1 AXS,UNKNOWN,05/01/2024,1.00,0.00,,,1.00,Short,0xorphan",
+	}, "\n") + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("form 8949 =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package tax
+
+import (
+	"cmd/pkg/decimal"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cost basis methods, which lots a disposal uses up first
+const (
+	FIFO = "fifo" // oldest first
+	LIFO = "lifo" // newest first
+	HIFO = "hifo" // highest unit cost first, smallest gains
+)
+
+// Methods lists the supported methods
+var Methods = []string{FIFO, LIFO, HIFO}
+
+// ParseMethod checks a method name, "" is FIFO
+func ParseMethod(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "":
+		return FIFO, nil
+	case FIFO, LIFO, HIFO:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown cost basis method %q, want one of %s", s, strings.Join(Methods, ", "))
+	}
+}
+
+// Lot is an acquisition not yet disposed of, Cost is the USD basis of the whole Quantity
+type Lot struct {
+	Acquired time.Time       `json:"acquired"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Cost     decimal.Decimal `json:"cost_usd"`
+	Hash     string          `json:"hash"`
+}
+
+// piece is the part of a lot a disposal used
+type piece struct {
+	acquired time.Time
+	quantity decimal.Decimal
+	cost     decimal.Decimal
+}
+
+// inventory is the open lots of one asset, in acquisition order
+type inventory struct {
+	lots []Lot
+}
+
+// take
+// Explanation -> removes quantity from the lots, picked by method, splitting a lot's cost
+// pro rata when only part of it goes
+// Return -> the pieces used, and how much quantity no lot covered
+func (inv *inventory) take(quantity decimal.Decimal, method string) ([]piece, decimal.Decimal) {
+	order := make([]int, len(inv.lots))
+	for i := range order {
+		order[i] = i
+	}
+	switch method {
+	case LIFO:
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	case HIFO:
+		sort.SliceStable(order, func(a, b int) bool {
+			return unitCost(inv.lots[order[a]]).Cmp(unitCost(inv.lots[order[b]])) > 0
+		})
+	}
+
+	var pieces []piece
+	left := quantity
+	for _, i := range order {
+		if left.Sign() <= 0 {
+			break
+		}
+		lot := &inv.lots[i]
+		if lot.Quantity.Sign() <= 0 {
+			continue
+		}
+
+		if lot.Quantity.Cmp(left) <= 0 {
+			pieces = append(pieces, piece{acquired: lot.Acquired, quantity: lot.Quantity, cost: lot.Cost})
+			left = left.Sub(lot.Quantity)
+			lot.Quantity, lot.Cost = decimal.Zero, decimal.Zero
+			continue
+		}
+
+		share, _ := left.Div(lot.Quantity)
+		cost := lot.Cost.Mul(share)
+		pieces = append(pieces, piece{acquired: lot.Acquired, quantity: left, cost: cost})
+		lot.Quantity = lot.Quantity.Sub(left)
+		lot.Cost = lot.Cost.Sub(cost)
+		left = decimal.Zero
+	}
+
+	// drop used up lots, keeping acquisition order
+	open := inv.lots[:0]
+	for _, lot := range inv.lots {
+		if lot.Quantity.Sign() > 0 {
+			open = append(open, lot)
+		}
+	}
+	inv.lots = open
+
+	if left.Sign() < 0 {
+		left = decimal.Zero
+	}
+	return pieces, left
+}
+
+func unitCost(l Lot) decimal.Decimal {
+	d, ok := l.Cost.Div(l.Quantity)
+	if !ok {
+		return decimal.Zero
+	}
+	return d
+}
@@ -0,0 +1,137 @@
+package tax
+
+import (
+	"cmd/pkg/decimal"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// threeLots are 2 units each at unit costs 5, 15 and 10, in acquisition order
+func threeLots() *inventory {
+	return &inventory{lots: []Lot{
+		{Acquired: day("2024-01-01"), Quantity: decimal.FromInt(2), Cost: decimal.FromInt(10), Hash: "0x1"},
+		{Acquired: day("2024-02-01"), Quantity: decimal.FromInt(2), Cost: decimal.FromInt(30), Hash: "0x2"},
+		{Acquired: day("2024-03-01"), Quantity: decimal.FromInt(2), Cost: decimal.FromInt(20), Hash: "0x3"},
+	}}
+}
+
+func TestInventoryTake(t *testing.T) {
+	type used struct {
+		acquired string
+		quantity string
+		cost     string
+	}
+	tests := []struct {
+		name     string
+		method   string
+		quantity int64
+		pieces   []used
+		missing  string
+		left     []used // open lots after, in acquisition order
+	}{
+		{
+			name: "fifo partial lot", method: FIFO, quantity: 3,
+			pieces:  []used{{"2024-01-01", "2", "10"}, {"2024-02-01", "1", "15"}},
+			missing: "0",
+			left:    []used{{"2024-02-01", "1", "15"}, {"2024-03-01", "2", "20"}},
+		},
+		{
+			name: "lifo partial lot", method: LIFO, quantity: 3,
+			pieces:  []used{{"2024-03-01", "2", "20"}, {"2024-02-01", "1", "15"}},
+			missing: "0",
+			left:    []used{{"2024-01-01", "2", "10"}, {"2024-02-01", "1", "15"}},
+		},
+		{
+			name: "hifo partial lot", method: HIFO, quantity: 3,
+			pieces:  []used{{"2024-02-01", "2", "30"}, {"2024-03-01", "1", "10"}},
+			missing: "0",
+			left:    []used{{"2024-01-01", "2", "10"}, {"2024-03-01", "1", "10"}},
+		},
+		{
+			name: "fifo more than held", method: FIFO, quantity: 8,
+			pieces:  []used{{"2024-01-01", "2", "10"}, {"2024-02-01", "2", "30"}, {"2024-03-01", "2", "20"}},
+			missing: "2",
+		},
+		{
+			name: "hifo exact lot", method: HIFO, quantity: 2,
+			pieces:  []used{{"2024-02-01", "2", "30"}},
+			missing: "0",
+			left:    []used{{"2024-01-01", "2", "10"}, {"2024-03-01", "2", "20"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := threeLots()
+			pieces, missing := inv.take(decimal.FromInt(tt.quantity), tt.method)
+
+			if len(pieces) != len(tt.pieces) {
+				t.Fatalf("pieces = %+v, want %d", pieces, len(tt.pieces))
+			}
+			for i, want := range tt.pieces {
+				p := pieces[i]
+				if !p.acquired.Equal(day(want.acquired)) || p.quantity.String() != want.quantity || p.cost.String() != want.cost {
+					t.Errorf("piece %d = %s %s@%s, want %s %s@%s", i,
+						p.acquired.Format(time.DateOnly), p.quantity, p.cost, want.acquired, want.quantity, want.cost)
+				}
+			}
+			if missing.String() != tt.missing {
+				t.Errorf("missing = %s, want %s", missing, tt.missing)
+			}
+
+			if len(inv.lots) != len(tt.left) {
+				t.Fatalf("open lots = %+v, want %d", inv.lots, len(tt.left))
+			}
+			for i, want := range tt.left {
+				l := inv.lots[i]
+				if !l.Acquired.Equal(day(want.acquired)) || l.Quantity.String() != want.quantity || l.Cost.String() != want.cost {
+					t.Errorf("lot %d = %s %s@%s, want %s %s@%s", i,
+						l.Acquired.Format(time.DateOnly), l.Quantity, l.Cost, want.acquired, want.quantity, want.cost)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	for in, want := range map[string]string{"": FIFO, "FIFO": FIFO, " lifo ": LIFO, "hifo": HIFO} {
+		if got, err := ParseMethod(in); err != nil || got != want {
+			t.Errorf("ParseMethod(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMethod("avg"); err == nil {
+		t.Error("ParseMethod(avg) accepted")
+	}
+}
+
+func TestLongTerm(t *testing.T) {
+	acquired := time.Date(2023, 3, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		sold time.Time
+		want bool
+	}{
+		{"day before the anniversary", time.Date(2024, 3, 14, 23, 59, 0, 0, time.UTC), false},
+		{"anniversary, earlier in the day", time.Date(2024, 3, 15, 1, 0, 0, 0, time.UTC), false},
+		{"anniversary, later in the day", time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC), false},
+		{"day after the anniversary", time.Date(2024, 3, 16, 0, 0, 1, 0, time.UTC), true},
+		// dates are UTC ones, 9pm on the 15th in New York is the 16th
+		{"day after in UTC only", time.Date(2024, 3, 15, 21, 0, 0, 0, time.FixedZone("EST", -5*3600)), true},
+		{"same day", acquired, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longTerm(acquired, tt.sold); got != tt.want {
+				t.Errorf("longTerm = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
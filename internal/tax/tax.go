@@ -0,0 +1,510 @@
+// Package tax rebuilds a wallet's acquisitions and disposals from its transaction history
+// and works out cost basis and realized gains in USD, for Form 8949 style reports.
+//
+// Every transaction is treated as a trade: what left the wallet was disposed of, what came
+// in was acquired, both at the USD value of the priced side (RON, WETH, AXS, SLP... at the
+// day's price). NFTs and unknown tokens take that value, split evenly, e.g. an Axie bought for
+// 0.01 WETH has a basis of 0.01 WETH in USD, and sold for 0.02 WETH has proceeds of 0.02 WETH.
+//
+// Gas is RON spent: it leaves the RON lots and its USD value goes on the basis of what was
+// acquired, or comes off the proceeds of what was disposed of. Gas on transactions that did
+// neither, approvals, failed transactions, transfers, is totalled as Fees.
+//
+// Sends with nothing coming back are transfers, e.g. to another wallet of yours, they leave
+// the lots without a gain. This is a tool for preparing a return, not tax advice.
+package tax
+
+import (
+	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/pkg/decimal"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// nativeAsset is the lot key of native RON, tokens are keyed by contract
+const nativeAsset = "native:ron"
+
+// ErrNoHistoricalPrices is returned when the price provider can't price past dates
+var ErrNoHistoricalPrices = errors.New("price provider has no historical prices")
+
+// Row is one line of Form 8949, the part of a disposal that used one lot
+type Row struct {
+	Description  string          `json:"description"`
+	Asset        string          `json:"asset"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Acquired     time.Time       `json:"acquired,omitempty"` // zero when no lot covered it
+	Sold         time.Time       `json:"sold"`
+	Proceeds     decimal.Decimal `json:"proceeds_usd"`
+	Cost         decimal.Decimal `json:"cost_usd"`
+	Gain         decimal.Decimal `json:"gain_usd"`
+	LongTerm     bool            `json:"long_term"`
+	MissingBasis bool            `json:"missing_basis,omitempty"` // acquired before the history starts, or elsewhere
+	Hash         string          `json:"hash"`
+}
+
+// Report is the realized gains for a year, or all of history when Year is 0
+type Report struct {
+	Wallet string `json:"wallet"`
+	Method string `json:"method"`
+	Year   int    `json:"year,omitempty"`
+	Rows   []Row  `json:"rows"`
+
+	Proceeds      decimal.Decimal `json:"proceeds_usd"`
+	Cost          decimal.Decimal `json:"cost_usd"`
+	ShortTermGain decimal.Decimal `json:"short_term_gain_usd"`
+	LongTermGain  decimal.Decimal `json:"long_term_gain_usd"`
+	Fees          decimal.Decimal `json:"fees_usd"` // gas not folded into a basis or proceeds
+
+	Transactions int `json:"transactions"`
+	Transfers    int `json:"transfers"`     // sends with nothing back, no gain reported
+	MissingBasis int `json:"missing_basis"` // rows with no lot to match
+	Unpriced     int `json:"unpriced"`      // transactions where nothing had a price, valued at 0
+}
+
+// leg is one asset moving in or out of the wallet in a transaction
+type leg struct {
+	asset       string
+	description string
+	symbol      string // price symbol, "" for NFTs
+	quantity    decimal.Decimal
+	usd         decimal.Decimal
+	priced      bool
+}
+
+// Ledger replays a wallet's history in block order, keeping open lots per asset
+type Ledger struct {
+	wallet string
+	method string
+	prices price.Historical
+
+	lots   map[string]*inventory
+	rows   []Row
+	events []event // per transaction counters, kept with their date for Report's year filter
+}
+
+// event is what a transaction added to the report totals besides rows
+type event struct {
+	at       time.Time
+	fees     decimal.Decimal
+	transfer bool
+	unpriced bool
+}
+
+// NewLedger
+// Explanation -> creates a ledger for wallet (0x form), method from ParseMethod, prices
+// from a provider with history, see price.HistoricalOf
+// Return -> the ledger, feed it transactions oldest first with Add
+func NewLedger(wallet, method string, prices price.Historical) *Ledger {
+	return &Ledger{
+		wallet: strings.ToLower(wallet),
+		method: method,
+		prices: prices,
+		lots:   make(map[string]*inventory),
+	}
+}
+
+// Add
+// Explanation -> applies one transaction: disposals first, then gas, then acquisitions
+// Return -> an error only for transactions it can't read, missing prices just value at 0
+func (l *Ledger) Add(ctx context.Context, tx models.Transaction) error {
+	at, err := time.Parse(time.RFC3339, tx.BlockTimestamp)
+	if err != nil {
+		return fmt.Errorf("transaction %s timestamp: %w", tx.Hash, err)
+	}
+	ev := event{at: at}
+
+	// gas, only the sender pays it
+	var feeRON, feeUSD decimal.Decimal
+	if strings.EqualFold(tx.FromAddress, l.wallet) && tx.TransactionFee != "" {
+		if feeRON, err = decimal.Parse(tx.TransactionFee); err != nil {
+			return fmt.Errorf("transaction %s fee: %w", tx.Hash, err)
+		}
+		if p, err := l.prices.USDPriceAt(ctx, price.RON, at); err == nil {
+			feeUSD = feeRON.Mul(p)
+		}
+	}
+
+	// a failed transaction moved nothing but still burned gas
+	if tx.ReceiptStatus == "0" {
+		l.spendGas(feeRON)
+		ev.fees = feeUSD
+		l.events = append(l.events, ev)
+		return nil
+	}
+
+	in, out, err := l.legs(ctx, tx, at)
+	if err != nil {
+		return err
+	}
+	if !value(in, out) {
+		ev.unpriced = len(in)+len(out) > 0
+	}
+
+	switch {
+	case len(out) > 0 && len(in) == 0:
+		// nothing came back, a transfer rather than a sale
+		for _, o := range out {
+			l.inventory(o.asset).take(o.quantity, l.method)
+		}
+		ev.transfer = true
+		ev.fees = feeUSD
+	case len(out) > 0:
+		spread(out, feeUSD.Neg())
+		for _, o := range out {
+			l.dispose(o, at, tx.Hash)
+		}
+	case len(in) == 0:
+		ev.fees = feeUSD // approvals, listings, claims that failed to pay out
+	}
+
+	l.spendGas(feeRON)
+
+	if len(in) > 0 && len(out) == 0 {
+		spread(in, feeUSD)
+	}
+	for _, i := range in {
+		inv := l.inventory(i.asset)
+		inv.lots = append(inv.lots, Lot{Acquired: at, Quantity: i.quantity, Cost: i.usd, Hash: tx.Hash})
+	}
+
+	l.events = append(l.events, ev)
+	return nil
+}
+
+// legs splits the transaction into what came in and what went out, priced where possible.
+// Transfers flagged as spam are left out, they would only add worthless lots
+func (l *Ledger) legs(ctx context.Context, tx models.Transaction, at time.Time) (in, out []leg, err error) {
+	add := func(incoming bool, lg leg) {
+		if lg.quantity.Sign() <= 0 {
+			return
+		}
+		if lg.symbol != "" {
+			if p, err := l.prices.USDPriceAt(ctx, lg.symbol, at); err == nil {
+				lg.usd, lg.priced = lg.quantity.Mul(p), true
+			}
+		}
+		if incoming {
+			in = append(in, lg)
+		} else {
+			out = append(out, lg)
+		}
+	}
+
+	if tx.Value != "" && tx.Value != "0" {
+		ron, err := decimal.FromUnits(tx.Value, 18)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %s value: %w", tx.Hash, err)
+		}
+		native := leg{asset: nativeAsset, description: ron.String() + " RON", symbol: price.RON, quantity: ron}
+		switch {
+		case strings.EqualFold(tx.FromAddress, l.wallet):
+			add(false, native)
+		case strings.EqualFold(tx.ToAddress, l.wallet):
+			add(true, native)
+		}
+	}
+
+	// RON paid by contracts, e.g. a marketplace paying the seller. The transaction's own value
+	// is in here too, not internal, and was counted above
+	for _, t := range tx.NativeTransfers {
+		if !t.InternalTransaction || t.Value == "" || t.Value == "0" {
+			continue
+		}
+		// by address, a fee paid on to the marketplace's treasury passes through other wallets
+		incoming, ok := l.direction("", t.FromAddress, t.ToAddress)
+		if !ok {
+			continue
+		}
+		ron, err := decimal.FromUnits(t.Value, 18)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %s internal transfer value: %w", tx.Hash, err)
+		}
+		add(incoming, leg{asset: nativeAsset, description: ron.String() + " RON", symbol: price.RON, quantity: ron})
+	}
+
+	for _, t := range tx.ERC20Transfers {
+		if t.PossibleSpam {
+			continue
+		}
+		incoming, ok := l.direction(t.Direction, t.FromAddress, t.ToAddress)
+		if !ok {
+			continue
+		}
+		amount, err := tokenAmount(t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %s transfer %d: %w", tx.Hash, t.LogIndex, err)
+		}
+		symbol := t.TokenSymbol
+		if symbol == "" {
+			symbol = t.Address
+		}
+		add(incoming, leg{
+			asset:       "erc20:" + strings.ToLower(t.Address),
+			description: amount.String() + " " + symbol,
+			symbol:      price.NormalizeSymbol(t.TokenSymbol),
+			quantity:    amount,
+		})
+	}
+
+	for _, t := range tx.NFTTransfers {
+		if t.PossibleSpam {
+			continue
+		}
+		incoming, ok := l.direction(t.Direction, t.FromAddress, t.ToAddress)
+		if !ok {
+			continue
+		}
+		quantity := decimal.FromInt(1)
+		if t.Amount != "" {
+			if q, err := decimal.Parse(t.Amount); err == nil && q.Sign() > 0 {
+				quantity = q
+			}
+		}
+		name := t.TokenAddress + " #" + t.TokenID
+		if t.NormalizedMetadata != nil && t.NormalizedMetadata.Name != "" {
+			name = t.NormalizedMetadata.Name + " #" + t.TokenID
+		}
+		if quantity.Cmp(decimal.FromInt(1)) != 0 {
+			name = quantity.String() + " x " + name
+		}
+		add(incoming, leg{
+			asset:       "nft:" + strings.ToLower(t.TokenAddress) + ":" + t.TokenID,
+			description: name,
+			quantity:    quantity,
+		})
+	}
+	return in, out, nil
+}
+
+// direction reports whether a transfer came into the wallet, false ok for ones that didn't
+// touch it, e.g. a marketplace fee leg between two other addresses
+func (l *Ledger) direction(dir, from, to string) (incoming, ok bool) {
+	switch strings.ToLower(dir) {
+	case "receive", "incoming":
+		return true, true
+	case "send", "outgoing":
+		return false, true
+	}
+	switch {
+	case strings.EqualFold(to, l.wallet):
+		return true, true
+	case strings.EqualFold(from, l.wallet):
+		return false, true
+	}
+	return false, false
+}
+
+// value gives unpriced legs the transaction's value. What came in sets the value if any of it
+// is priced, otherwise what went out does, and each side's unpriced legs split what its priced
+// legs don't cover
+// Return -> false if nothing was priced
+func value(in, out []leg) bool {
+	inUSD, inPriced := pricedTotal(in)
+	outUSD, outPriced := pricedTotal(out)
+	var total decimal.Decimal
+	switch {
+	case inPriced:
+		total = inUSD
+	case outPriced:
+		total = outUSD
+	default:
+		return false
+	}
+	fill(in, total.Sub(inUSD))
+	fill(out, total.Sub(outUSD))
+	return true
+}
+
+func pricedTotal(legs []leg) (decimal.Decimal, bool) {
+	total, ok := decimal.Zero, false
+	for _, lg := range legs {
+		if lg.priced {
+			total, ok = total.Add(lg.usd), true
+		}
+	}
+	return total, ok
+}
+
+// fill splits usd evenly over the unpriced legs, never below 0
+func fill(legs []leg, usd decimal.Decimal) {
+	if usd.Sign() < 0 {
+		usd = decimal.Zero
+	}
+	var n int64
+	for _, lg := range legs {
+		if !lg.priced {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	each, _ := usd.Div(decimal.FromInt(n))
+	for i := range legs {
+		if !legs[i].priced {
+			legs[i].usd = each
+		}
+	}
+}
+
+// spread adds usd to the legs in proportion to their value, evenly if they have none
+func spread(legs []leg, usd decimal.Decimal) {
+	if usd.IsZero() || len(legs) == 0 {
+		return
+	}
+	total := decimal.Zero
+	for _, lg := range legs {
+		total = total.Add(lg.usd)
+	}
+	for i := range legs {
+		share, ok := legs[i].usd.Div(total)
+		if !ok {
+			share, _ = decimal.FromInt(1).Div(decimal.FromInt(int64(len(legs))))
+		}
+		legs[i].usd = legs[i].usd.Add(usd.Mul(share))
+	}
+}
+
+// dispose matches a disposal against the asset's lots, one row per lot used
+func (l *Ledger) dispose(o leg, at time.Time, hash string) {
+	proceeds := o.usd
+	if proceeds.Sign() < 0 {
+		proceeds = decimal.Zero // gas bigger than the sale
+	}
+	pieces, missing := l.inventory(o.asset).take(o.quantity, l.method)
+	if missing.Sign() > 0 {
+		pieces = append(pieces, piece{quantity: missing})
+	}
+
+	for _, p := range pieces {
+		share, _ := p.quantity.Div(o.quantity)
+		row := Row{
+			Description:  o.description,
+			Asset:        o.asset,
+			Quantity:     p.quantity,
+			Acquired:     p.acquired,
+			Sold:         at,
+			Proceeds:     proceeds.Mul(share),
+			Cost:         p.cost,
+			MissingBasis: p.acquired.IsZero(),
+			Hash:         hash,
+		}
+		if len(pieces) > 1 && !strings.HasPrefix(o.asset, "nft:") {
+			row.Description = p.quantity.String() + " " + symbolOf(o.description)
+		}
+		row.Gain = row.Proceeds.Sub(row.Cost)
+		row.LongTerm = !row.MissingBasis && longTerm(p.acquired, at)
+		l.rows = append(l.rows, row)
+	}
+}
+
+// longTerm reports whether a lot was held more than a year. Holding periods count calendar
+// days in UTC, a sale on the anniversary is still short term whatever the time of day
+func longTerm(acquired, sold time.Time) bool {
+	day := func(t time.Time) time.Time {
+		y, m, d := t.UTC().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return day(sold).After(day(acquired).AddDate(1, 0, 0))
+}
+
+// spendGas takes the fee out of the RON lots, its basis goes with it
+func (l *Ledger) spendGas(ron decimal.Decimal) {
+	if ron.Sign() > 0 {
+		l.inventory(nativeAsset).take(ron, l.method)
+	}
+}
+
+func (l *Ledger) inventory(asset string) *inventory {
+	inv, ok := l.lots[asset]
+	if !ok {
+		inv = &inventory{}
+		l.lots[asset] = inv
+	}
+	return inv
+}
+
+// Open returns the lots still held, by asset
+func (l *Ledger) Open() map[string][]Lot {
+	open := make(map[string][]Lot, len(l.lots))
+	for asset, inv := range l.lots {
+		if len(inv.lots) > 0 {
+			open[asset] = append([]Lot(nil), inv.lots...)
+		}
+	}
+	return open
+}
+
+// Report
+// Explanation -> totals the rows for disposals in year (UTC), 0 for every year. Rows are
+// short term first then long term, each by date sold, the order Form 8949 lists them in
+// Return -> the report
+func (l *Ledger) Report(year int) *Report {
+	r := &Report{Wallet: l.wallet, Method: l.method, Year: year}
+	inYear := func(t time.Time) bool { return year == 0 || t.UTC().Year() == year }
+
+	for _, row := range l.rows {
+		if !inYear(row.Sold) {
+			continue
+		}
+		r.Rows = append(r.Rows, row)
+		r.Proceeds = r.Proceeds.Add(row.Proceeds)
+		r.Cost = r.Cost.Add(row.Cost)
+		if row.LongTerm {
+			r.LongTermGain = r.LongTermGain.Add(row.Gain)
+		} else {
+			r.ShortTermGain = r.ShortTermGain.Add(row.Gain)
+		}
+		if row.MissingBasis {
+			r.MissingBasis++
+		}
+	}
+	sort.SliceStable(r.Rows, func(i, j int) bool {
+		if r.Rows[i].LongTerm != r.Rows[j].LongTerm {
+			return !r.Rows[i].LongTerm
+		}
+		return r.Rows[i].Sold.Before(r.Rows[j].Sold)
+	})
+
+	for _, ev := range l.events {
+		if !inYear(ev.at) {
+			continue
+		}
+		r.Transactions++
+		r.Fees = r.Fees.Add(ev.fees)
+		if ev.transfer {
+			r.Transfers++
+		}
+		if ev.unpriced {
+			r.Unpriced++
+		}
+	}
+	return r
+}
+
+// tokenAmount is the transfer in whole tokens
+func tokenAmount(t models.ERC20Transfer) (decimal.Decimal, error) {
+	if t.ValueFormatted != "" {
+		return decimal.Parse(t.ValueFormatted)
+	}
+	decimals := 18
+	if t.TokenDecimals != "" {
+		if _, err := fmt.Sscan(t.TokenDecimals, &decimals); err != nil {
+			return decimal.Zero, fmt.Errorf("token decimals %q: %w", t.TokenDecimals, err)
+		}
+	}
+	return decimal.FromUnits(t.Value, decimals)
+}
+
+// symbolOf is the token symbol at the end of a "1.5 AXS" description
+func symbolOf(description string) string {
+	if i := strings.LastIndexByte(description, ' '); i >= 0 {
+		return description[i+1:]
+	}
+	return description
+}
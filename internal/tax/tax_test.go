@@ -0,0 +1,213 @@
+package tax
+
+import (
+	"cmd/internal/models"
+	"cmd/internal/price"
+	"cmd/pkg/decimal"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const (
+	wallet = "0x1111111111111111111111111111111111111111"
+	buyer  = "0x2222222222222222222222222222222222222222"
+	market = "0x3333333333333333333333333333333333333333"
+	axies  = "0x32950db2a7164ae833121501c797d79e7b79d74c"
+)
+
+// fixedPrices prices every day the same, per symbol
+type fixedPrices map[string]string
+
+func (p fixedPrices) USDPriceAt(_ context.Context, symbol string, _ time.Time) (decimal.Decimal, error) {
+	if v, ok := p[price.NormalizeSymbol(symbol)]; ok {
+		return decimal.MustParse(v), nil
+	}
+	return decimal.Zero, fmt.Errorf("%w %s", price.ErrUnknownSymbol, symbol)
+}
+
+// wei is whole RON in wei
+func wei(ron int64) string {
+	return decimal.FromInt(ron).Mul(decimal.MustParse("1000000000000000000")).String()
+}
+
+func nftTransfer(from, to, tokenID string) models.NFTTransfer {
+	return models.NFTTransfer{TokenAddress: axies, TokenID: tokenID, FromAddress: from, ToAddress: to, Amount: "1"}
+}
+
+func TestSalePaidByInternalTransfer(t *testing.T) {
+	l := NewLedger(wallet, FIFO, fixedPrices{price.RON: "2"})
+	ctx := context.Background()
+
+	// funded with 10 RON, then bought for them, the wallet sent the value itself
+	fund := models.Transaction{
+		Hash:           "0xfund",
+		FromAddress:    buyer,
+		ToAddress:      wallet,
+		Value:          wei(10),
+		BlockTimestamp: "2024-01-01T00:00:00Z",
+	}
+	buy := models.Transaction{
+		Hash:           "0xbuy",
+		FromAddress:    wallet,
+		ToAddress:      market,
+		Value:          wei(10),
+		BlockTimestamp: "2024-01-10T00:00:00Z",
+		NFTTransfers:   []models.NFTTransfer{nftTransfer(market, wallet, "7")},
+	}
+	// sold for 15 RON: the buyer sent the transaction, the marketplace paid the wallet with an
+	// internal call and nothing in the transaction's own value is the wallet's
+	sale := models.Transaction{
+		Hash:           "0xsale",
+		FromAddress:    buyer,
+		ToAddress:      market,
+		Value:          wei(16),
+		BlockTimestamp: "2024-03-01T00:00:00Z",
+		NFTTransfers:   []models.NFTTransfer{nftTransfer(wallet, buyer, "7")},
+		NativeTransfers: []models.NativeTransfer{
+			{FromAddress: buyer, ToAddress: market, Value: wei(16)},
+			{FromAddress: market, ToAddress: wallet, Value: wei(15), InternalTransaction: true},
+			{FromAddress: market, ToAddress: buyer, Value: wei(1), InternalTransaction: true}, // fee, not ours
+		},
+	}
+	for _, tx := range []models.Transaction{fund, buy, sale} {
+		if err := l.Add(ctx, tx); err != nil {
+			t.Fatalf("Add %s: %v", tx.Hash, err)
+		}
+	}
+
+	r := l.Report(0)
+	if r.Transfers != 0 {
+		t.Errorf("Transfers = %d, the sale was booked as a transfer", r.Transfers)
+	}
+	// the RON spent on the buy, then the Axie sold
+	if len(r.Rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(r.Rows))
+	}
+	row := r.Rows[1]
+	if got := row.Proceeds.String(); got != "30" {
+		t.Errorf("proceeds = %s, want 30", got)
+	}
+	if got := row.Cost.String(); got != "20" {
+		t.Errorf("cost = %s, want 20", got)
+	}
+	if row.MissingBasis || row.Hash != "0xsale" {
+		t.Errorf("row = %+v", row)
+	}
+	// the 15 RON received is now a lot
+	if lots := l.Open()[nativeAsset]; len(lots) != 1 || lots[0].Quantity.String() != "15" {
+		t.Errorf("RON lots = %+v, want one of 15", lots)
+	}
+}
+
+// ronByDay prices RON by UTC day, like CoinGecko's history
+type ronByDay map[string]string
+
+func (p ronByDay) USDPriceAt(_ context.Context, symbol string, at time.Time) (decimal.Decimal, error) {
+	if v, ok := p[at.UTC().Format(time.DateOnly)]; ok && price.NormalizeSymbol(symbol) == price.RON {
+		return decimal.MustParse(v), nil
+	}
+	return decimal.Zero, fmt.Errorf("%w %s", price.ErrUnknownSymbol, symbol)
+}
+
+// methodHistory funds the wallet with 10 RON three times at 1, 3 and 2 USD, spends 15 RON
+// on an Axie at 4 USD paying 0.75 RON gas, then burns 0.25 RON on a failed transaction
+func methodHistory() (ronByDay, []models.Transaction) {
+	prices := ronByDay{"2024-01-01": "1", "2024-02-01": "3", "2024-03-01": "2", "2024-04-01": "4", "2024-04-02": "4"}
+	fund := func(hash, date string) models.Transaction {
+		return models.Transaction{Hash: hash, FromAddress: buyer, ToAddress: wallet, Value: wei(10), BlockTimestamp: date + "T00:00:00Z"}
+	}
+	txs := []models.Transaction{
+		fund("0xf1", "2024-01-01"),
+		fund("0xf2", "2024-02-01"),
+		fund("0xf3", "2024-03-01"),
+		{
+			Hash:           "0xsale",
+			FromAddress:    wallet,
+			ToAddress:      market,
+			Value:          wei(15),
+			TransactionFee: "0.75",
+			BlockTimestamp: "2024-04-01T00:00:00Z",
+			NFTTransfers:   []models.NFTTransfer{nftTransfer(market, wallet, "9")},
+		},
+		{
+			Hash:           "0xfailed",
+			FromAddress:    wallet,
+			ToAddress:      market,
+			Value:          wei(15),
+			TransactionFee: "0.25",
+			ReceiptStatus:  "0",
+			BlockTimestamp: "2024-04-02T00:00:00Z",
+		},
+	}
+	return prices, txs
+}
+
+func TestLedgerMethods(t *testing.T) {
+	type row struct {
+		quantity, acquired, proceeds, cost string
+	}
+	// 15 RON at 4 USD is 60, less 3 USD of gas is 57 of proceeds, split 10:5 over two lots
+	tests := []struct {
+		method string
+		rows   []row
+		gain   string
+	}{
+		{FIFO, []row{{"10", "2024-01-01", "38", "10"}, {"5", "2024-02-01", "19", "15"}}, "32"},
+		{LIFO, []row{{"10", "2024-03-01", "38", "20"}, {"5", "2024-02-01", "19", "15"}}, "22"},
+		{HIFO, []row{{"10", "2024-02-01", "38", "30"}, {"5", "2024-03-01", "19", "10"}}, "17"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			prices, txs := methodHistory()
+			l := NewLedger(wallet, tt.method, prices)
+			for _, tx := range txs {
+				if err := l.Add(context.Background(), tx); err != nil {
+					t.Fatalf("Add %s: %v", tx.Hash, err)
+				}
+			}
+			r := l.Report(2024)
+
+			if len(r.Rows) != len(tt.rows) {
+				t.Fatalf("rows = %+v, want %d", r.Rows, len(tt.rows))
+			}
+			for i, want := range tt.rows {
+				got := r.Rows[i]
+				if got.Quantity.String() != want.quantity || got.Acquired.Format(time.DateOnly) != want.acquired ||
+					got.Proceeds.String() != want.proceeds || got.Cost.String() != want.cost {
+					t.Errorf("row %d = %s RON from %s, %s proceeds %s cost, want %+v", i, got.Quantity,
+						got.Acquired.Format(time.DateOnly), got.Proceeds, got.Cost, want)
+				}
+				if got.Hash != "0xsale" || got.LongTerm || got.MissingBasis {
+					t.Errorf("row %d = %+v", i, got)
+				}
+			}
+			if got := r.ShortTermGain.String(); got != tt.gain {
+				t.Errorf("short term gain = %s, want %s", got, tt.gain)
+			}
+
+			// the failed transaction moved nothing, its gas is a fee of 0.25 RON at 4 USD
+			if got := r.Fees.String(); got != "1" {
+				t.Errorf("fees = %s, want 1", got)
+			}
+			if r.Transactions != 5 || r.Transfers != 0 || r.Unpriced != 0 {
+				t.Errorf("transactions %d, transfers %d, unpriced %d", r.Transactions, r.Transfers, r.Unpriced)
+			}
+
+			// 30 funded - 15 spent - 1 of gas, and the Axie at the 60 USD it cost
+			open := l.Open()
+			held := decimal.Zero
+			for _, lot := range open[nativeAsset] {
+				held = held.Add(lot.Quantity)
+			}
+			if held.String() != "14" {
+				t.Errorf("RON held = %s, want 14", held)
+			}
+			if axie := open["nft:"+axies+":9"]; len(axie) != 1 || axie[0].Cost.String() != "60" {
+				t.Errorf("Axie lots = %+v, want one costing 60", axie)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package tokenmeta
+
+import (
+	"bytes"
+	"cmd/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNotMetadata is returned for documents that aren't a metadata JSON object
+var ErrNotMetadata = errors.New("not token metadata")
+
+// document is the union of the ERC-721 and ERC-1155 metadata JSON schemas, plus the
+// OpenSea extensions most contracts follow
+type document struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Image        string          `json:"image"`
+	ImageURL     string          `json:"image_url"`  // OpenSea's older name for image
+	ImageData    string          `json:"image_data"` // raw SVG, for on-chain art
+	AnimationURL string          `json:"animation_url"`
+	ExternalURL  string          `json:"external_url"`
+	ExternalLink string          `json:"external_link"`
+	Attributes   json.RawMessage `json:"attributes"`
+	Traits       json.RawMessage `json:"traits"`     // some collections use this name
+	Properties   json.RawMessage `json:"properties"` // ERC-1155
+}
+
+// rawAttribute is one entry of an attributes array
+type rawAttribute struct {
+	TraitType   string  `json:"trait_type"`
+	Type        string  `json:"type"` // used instead of trait_type now and then
+	Value       any     `json:"value"`
+	DisplayType *string `json:"display_type,omitempty"`
+	MaxValue    *int    `json:"max_value,omitempty"`
+}
+
+// Parse
+// Explanation -> decodes ERC-721 or ERC-1155 metadata JSON into the shape Moralis' normalized
+// metadata has, so it slots in where Moralis sent null. Attributes can be an array of
+// {trait_type, value}, a plain object, or ERC-1155 properties
+// Return -> the metadata, ErrNotMetadata for JSON that isn't an object
+func Parse(data []byte) (*models.NormalizedMetadata, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, ErrNotMetadata
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotMetadata, err)
+	}
+
+	meta := &models.NormalizedMetadata{
+		Name:  strings.TrimSpace(doc.Name),
+		Image: firstNonEmpty(doc.Image, doc.ImageURL),
+	}
+	if meta.Image == "" && strings.TrimSpace(doc.ImageData) != "" {
+		meta.Image = "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(doc.ImageData))
+	}
+	if s := strings.TrimSpace(doc.Description); s != "" {
+		meta.Description = &s
+	}
+	if s := strings.TrimSpace(doc.AnimationURL); s != "" {
+		meta.AnimationURL = &s
+	}
+	if s := firstNonEmpty(doc.ExternalURL, doc.ExternalLink); s != "" {
+		meta.ExternalLink = &s
+	}
+
+	var attrs []models.Attribute
+	for _, raw := range []json.RawMessage{doc.Attributes, doc.Traits, doc.Properties} {
+		attrs = append(attrs, parseAttributes(raw)...)
+	}
+	if len(attrs) > 0 {
+		meta.Attributes = &attrs
+	}
+	return meta, nil
+}
+
+// parseAttributes reads an attributes array or object, anything else is skipped
+func parseAttributes(raw json.RawMessage) []models.Attribute {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	switch raw[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil
+		}
+		var attrs []models.Attribute
+		for _, item := range list {
+			var a rawAttribute
+			if err := json.Unmarshal(item, &a); err != nil {
+				continue // e.g. a bare string, it has no trait name to file it under
+			}
+			name := firstNonEmpty(a.TraitType, a.Type)
+			if name == "" || a.Value == nil {
+				continue
+			}
+			attrs = append(attrs, models.Attribute{
+				TraitType:   name,
+				Value:       a.Value,
+				DisplayType: a.DisplayType,
+				MaxValue:    a.MaxValue,
+			})
+		}
+		return attrs
+
+	case '{':
+		var props map[string]any
+		if err := json.Unmarshal(raw, &props); err != nil {
+			return nil
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names) // maps have no order, keep output stable
+
+		var attrs []models.Attribute
+		for _, name := range names {
+			value := props[name]
+			// ERC-1155 allows {"name": ..., "value": ..., "display_value": ...} per property
+			if obj, ok := value.(map[string]any); ok {
+				v, ok := obj["value"]
+				if !ok {
+					continue
+				}
+				value = v
+			}
+			if value == nil {
+				continue
+			}
+			attrs = append(attrs, models.Attribute{TraitType: name, Value: value})
+		}
+		return attrs
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,348 @@
+package tokenmeta
+
+import (
+	"cmd/internal/cache"
+	"cmd/internal/models"
+	"cmd/internal/ronin"
+	"cmd/pkg/address"
+	"cmd/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Options
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxBytes    = 1 << 20 // metadata JSON, not media, a megabyte is generous
+	DefaultCacheTTL    = time.Hour
+	DefaultConcurrency = 4
+)
+
+// ErrNoTokenURI is returned when neither Moralis nor the contract has a token URI
+var ErrNoTokenURI = errors.New("no token URI")
+
+// ErrBlockedHost is returned for URIs pointing at private or loopback addresses
+// token URIs are set by whoever deployed the contract, they shouldn't reach our network
+var ErrBlockedHost = errors.New("blocked metadata host")
+
+// Function selectors, tokenURI for ERC-721 and uri for ERC-1155
+var (
+	selectorTokenURI = ronin.Selector("tokenURI(uint256)")
+	selectorURI      = ronin.Selector("uri(uint256)")
+)
+
+// Options tune the resolver, zero values use the defaults
+type Options struct {
+	Gateways    Gateways
+	Timeout     time.Duration
+	MaxBytes    int64
+	CacheTTL    time.Duration
+	Concurrency int // Fill's parallel fetches
+}
+
+// Resolver struct fetches and parses token metadata when Moralis has none
+type Resolver struct {
+	httpClient  *http.Client
+	rpc         *ronin.RPCClient
+	gateways    Gateways
+	maxBytes    int64
+	concurrency int
+	cache       *cache.TTL[string, *models.NormalizedMetadata]
+	logger      *logger.Logger
+}
+
+// NewResolver func creates a resolver
+// rpc may be nil, token URIs are then only taken from the Moralis data
+func NewResolver(rpc *ronin.RPCClient, opts Options, log *logger.Logger) *Resolver {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	gateways := opts.Gateways.withDefaults()
+
+	return &Resolver{
+		httpClient:  NewHTTPClient(gateways, opts.Timeout),
+		rpc:         rpc,
+		gateways:    gateways,
+		maxBytes:    opts.MaxBytes,
+		concurrency: opts.Concurrency,
+		cache:       cache.NewTTL[string, *models.NormalizedMetadata]("token_metadata", opts.CacheTTL),
+		logger:      log.WithGroup("metadata"),
+	}
+}
+
+// Gateways returns the gateways the resolver rewrites URIs onto
+func (r *Resolver) Gateways() Gateways {
+	return r.gateways
+}
+
+// TokenURI
+// Explanation -> reads the token URI from the contract, tokenURI(uint256) first and uri(uint256)
+// for ERC-1155 contracts that don't have it. {id} placeholders are filled in
+// Return -> the URI, ErrNoTokenURI if the contract has none or there's no RPC client
+func (r *Resolver) TokenURI(ctx context.Context, contractAddr, tokenID string) (string, error) {
+	if r.rpc == nil {
+		return "", ErrNoTokenURI
+	}
+	contract, err := address.Parse(contractAddr)
+	if err != nil {
+		return "", err
+	}
+	id, ok := new(big.Int).SetString(strings.TrimSpace(tokenID), 10)
+	if !ok {
+		return "", fmt.Errorf("invalid token ID %q", tokenID)
+	}
+
+	var lastErr error
+	for _, selector := range [][]byte{selectorTokenURI, selectorURI} {
+		out, err := r.rpc.EthCall(ctx, contract, ronin.EncodeCall(selector, ronin.EncodeUint(id)))
+		if err != nil {
+			lastErr = err // reverts when the function doesn't exist, try the next one
+			continue
+		}
+		uri, err := ronin.DecodeString(out, 0)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if uri = strings.TrimSpace(uri); uri != "" {
+			return ExpandID(uri, tokenID), nil
+		}
+	}
+
+	if lastErr != nil {
+		return "", fmt.Errorf("%w: %w", ErrNoTokenURI, lastErr)
+	}
+	return "", ErrNoTokenURI
+}
+
+// Fetch
+// Explanation -> reads the document a token URI points at, data: URIs are decoded in place
+// and everything else goes through the gateways
+// Return -> the content, at most MaxBytes of it
+func (r *Resolver) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	if IsDataURI(uri) {
+		_, data, err := DecodeDataURI(uri)
+		return data, err
+	}
+
+	target, err := r.gateways.HTTPURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", target, resp.StatusCode)
+	}
+
+	// one byte over the limit tells a big document from one exactly at it
+	data, err := io.ReadAll(io.LimitReader(resp.Body, r.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", target, err)
+	}
+	if int64(len(data)) > r.maxBytes {
+		return nil, fmt.Errorf("metadata at %s is over %d bytes", target, r.maxBytes)
+	}
+	return data, nil
+}
+
+// Resolve
+// Explanation -> gets the metadata for a token, from tokenURI if Moralis had one, from the
+// contract otherwise. Images and animations on ipfs:// or ar:// are rewritten to gateway
+// URLs so clients can show them. Results, failures included, are cached by URI
+// Return -> the metadata
+func (r *Resolver) Resolve(ctx context.Context, contractAddr, tokenID, tokenURI string) (*models.NormalizedMetadata, error) {
+	uri := strings.TrimSpace(tokenURI)
+	if uri == "" {
+		var err error
+		if uri, err = r.TokenURI(ctx, contractAddr, tokenID); err != nil {
+			return nil, err
+		}
+	}
+	uri = ExpandID(uri, tokenID)
+
+	key := uri
+	if IsDataURI(uri) {
+		key = contractAddr + "/" + tokenID // no point keeping a copy of the whole document as the key
+	}
+	if cached, ok := r.cache.Get(key); ok {
+		if cached == nil {
+			return nil, fmt.Errorf("metadata for %s recently failed", truncate(uri, 64))
+		}
+		return cached, nil
+	}
+
+	data, err := r.Fetch(ctx, uri)
+	if err != nil {
+		r.cache.Set(key, nil)
+		return nil, err
+	}
+	meta, err := Parse(data)
+	if err != nil {
+		r.cache.Set(key, nil)
+		return nil, err
+	}
+
+	meta.Image = r.mediaURL(meta.Image)
+	if meta.AnimationURL != nil {
+		animation := r.mediaURL(*meta.AnimationURL)
+		meta.AnimationURL = &animation
+	}
+
+	r.cache.Set(key, meta)
+	return meta, nil
+}
+
+// mediaURL rewrites content addressed media onto the gateways, data: and unknown URIs are kept
+func (r *Resolver) mediaURL(uri string) string {
+	if uri == "" || IsDataURI(uri) {
+		return uri
+	}
+	if u, err := r.gateways.HTTPURL(uri); err == nil {
+		return u
+	}
+	return uri
+}
+
+// Fill
+// Explanation -> resolves metadata for every raw NFT Moralis sent none for, a few at a time,
+// and sets NormalizedMetadata on them. Failures are logged and leave the NFT as it was
+// Return -> how many were filled
+func (r *Resolver) Fill(ctx context.Context, raws []models.RawNFTData) int {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		filled int
+		slots  = make(chan struct{}, r.concurrency)
+	)
+
+	for i := range raws {
+		if raws[i].NormalizedMetadata != nil {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(raw *models.RawNFTData) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			meta, err := r.Resolve(ctx, raw.TokenAddress, raw.TokenID, raw.TokenURI)
+			if err != nil {
+				r.logger.DebugContext(ctx, "Metadata not resolved",
+					"token_address", raw.TokenAddress,
+					"token_id", raw.TokenID,
+					"error", err,
+				)
+				return
+			}
+			raw.NormalizedMetadata = meta
+
+			mu.Lock()
+			filled++
+			mu.Unlock()
+		}(&raws[i])
+	}
+	wg.Wait()
+
+	return filled
+}
+
+// Stale reports whether Moralis last synced the metadata longer than after ago
+// a missing or unreadable sync time counts as stale
+func Stale(lastSync string, after time.Duration) bool {
+	if after <= 0 {
+		return false
+	}
+	synced, err := time.Parse(time.RFC3339, strings.TrimSpace(lastSync))
+	if err != nil {
+		return true
+	}
+	return time.Since(synced) > after
+}
+
+// NewHTTPClient returns a client that refuses to connect to private addresses, except for
+// the configured gateways, which may well be a local IPFS node
+// for anything fetching URLs that come from token metadata, e.g. the media downloader
+func NewHTTPClient(gateways Gateways, timeout time.Duration) *http.Client {
+	gateways = gateways.withDefaults()
+	trusted := make(map[string]bool)
+	for _, g := range []string{gateways.IPFS, gateways.Arweave} {
+		if u, err := neturl.Parse(g); err == nil && u.Hostname() != "" {
+			trusted[strings.ToLower(u.Hostname())] = true
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if trusted[strings.ToLower(host)] {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if blockedIP(ip.IP) {
+				return nil, fmt.Errorf("%w: %s", ErrBlockedHost, host)
+			}
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no addresses for %s", host)
+		}
+		// dial the address we checked, a second lookup could answer differently
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast()
+}
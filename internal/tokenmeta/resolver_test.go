@@ -0,0 +1,86 @@
+package tokenmeta
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientBlocksPrivateHosts(t *testing.T) {
+	var redirectTo string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ipfs/redirect" {
+			http.Redirect(w, r, redirectTo, http.StatusFound)
+			return
+		}
+		w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+
+	// the server is on 127.0.0.1, so only a client trusting it as the gateway can reach it
+	locked := NewHTTPClient(Gateways{}, 2*time.Second)
+	gateway := NewHTTPClient(Gateways{IPFS: srv.URL + "/ipfs/"}, 2*time.Second)
+
+	tests := []struct {
+		name     string
+		client   *http.Client
+		url      string
+		redirect string
+		blocked  bool
+	}{
+		{"loopback", locked, srv.URL + "/", "", true},
+		{"localhost", locked, "http://localhost:" + port + "/", "", true},
+		{"cloud metadata", locked, "http://169.254.169.254/latest/meta-data/", "", true},
+		{"private network", locked, "http://10.0.0.1/", "", true},
+		{"ipv6 loopback", locked, "http://[::1]:" + port + "/", "", true},
+		{"trusted gateway", gateway, srv.URL + "/ipfs/cid", "", false},
+		// the gateway is trusted by name, where it redirects to isn't
+		{"redirect to localhost", gateway, srv.URL + "/ipfs/redirect", "http://localhost:" + port + "/", true},
+		{"redirect to cloud metadata", gateway, srv.URL + "/ipfs/redirect", "http://169.254.169.254/latest/meta-data/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redirectTo = tt.redirect
+			resp, err := tt.client.Get(tt.url)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if tt.blocked {
+				if !errors.Is(err, ErrBlockedHost) {
+					t.Errorf("Get = %v, want ErrBlockedHost", err)
+				}
+				return
+			}
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Errorf("Get = %v, %v, want 200", resp, err)
+			}
+		})
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"224.0.0.1":       true,
+		"::1":             true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"8.8.8.8":         false,
+		"104.16.0.1":      false,
+		"2606:4700::1":    false,
+	} {
+		if got := blockedIP(net.ParseIP(ip)); got != want {
+			t.Errorf("blockedIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package tokenmeta
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	neturl "net/url"
+	"strings"
+)
+
+// Default gateways, override with IPFS_GATEWAY and ARWEAVE_GATEWAY
+const (
+	DefaultIPFSGateway    = "https://ipfs.io/ipfs/"
+	DefaultArweaveGateway = "https://arweave.net/"
+)
+
+// ErrUnsupportedURI is returned for schemes we can't fetch
+var ErrUnsupportedURI = errors.New("unsupported token URI")
+
+// Gateways turn content addressed URIs into fetchable HTTP URLs
+type Gateways struct {
+	IPFS    string // base URL the CID and path are appended to, ends in /
+	Arweave string
+}
+
+// DefaultGateways are the public gateways
+var DefaultGateways = Gateways{IPFS: DefaultIPFSGateway, Arweave: DefaultArweaveGateway}
+
+func (g Gateways) withDefaults() Gateways {
+	if g.IPFS == "" {
+		g.IPFS = DefaultIPFSGateway
+	}
+	if g.Arweave == "" {
+		g.Arweave = DefaultArweaveGateway
+	}
+	g.IPFS = strings.TrimSuffix(g.IPFS, "/") + "/"
+	g.Arweave = strings.TrimSuffix(g.Arweave, "/") + "/"
+	return g
+}
+
+// HTTPURL
+// Explanation -> rewrites ipfs://, ar:// and gateway URLs of other IPFS gateways onto ours,
+// http(s) URLs pass through. data: URIs aren't URLs, see DecodeDataURI
+// Return -> the URL to fetch, ErrUnsupportedURI for anything else
+func (g Gateways) HTTPURL(uri string) (string, error) {
+	g = g.withDefaults()
+	uri = strings.TrimSpace(uri)
+	lower := strings.ToLower(uri)
+
+	switch {
+	case strings.HasPrefix(lower, "ipfs://"):
+		path := uri[len("ipfs://"):]
+		// ipfs://ipfs/Qm... is a common mistake, the extra ipfs/ isn't part of the path
+		path = strings.TrimPrefix(path, "ipfs/")
+		return g.IPFS + path, nil
+
+	case strings.HasPrefix(lower, "ar://"):
+		return g.Arweave + uri[len("ar://"):], nil
+
+	case strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "http://"):
+		// someone else's IPFS gateway is often rate limited or gone, use ours
+		if i := strings.Index(uri, "/ipfs/"); i > 0 {
+			return g.IPFS + uri[i+len("/ipfs/"):], nil
+		}
+		return uri, nil
+
+	case strings.HasPrefix(lower, "qm") && len(uri) == 46, strings.HasPrefix(lower, "bafy"):
+		// a bare CID
+		return g.IPFS + uri, nil
+
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedURI, truncate(uri, 64))
+	}
+}
+
+// IsDataURI reports whether uri carries its content inline
+func IsDataURI(uri string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(uri)), "data:")
+}
+
+// DecodeDataURI
+// Explanation -> decodes a data: URI, base64 or percent-encoded, on-chain metadata uses these
+// Return -> the media type and content
+func DecodeDataURI(uri string) (string, []byte, error) {
+	uri = strings.TrimSpace(uri)
+	if !IsDataURI(uri) {
+		return "", nil, fmt.Errorf("%w: not a data URI", ErrUnsupportedURI)
+	}
+
+	header, payload, ok := strings.Cut(uri[len("data:"):], ",")
+	if !ok {
+		return "", nil, fmt.Errorf("%w: data URI without a comma", ErrUnsupportedURI)
+	}
+
+	mediaType := header
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		mediaType = header[:len(header)-len(";base64")]
+		isBase64 = true
+	}
+	if mediaType == "" {
+		mediaType = "text/plain;charset=US-ASCII"
+	}
+
+	if isBase64 {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// some contracts leave the padding off
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("decoding base64 data URI: %w", err)
+		}
+		return mediaType, data, nil
+	}
+
+	data, err := neturl.PathUnescape(payload)
+	if err != nil {
+		// plenty of contracts put raw JSON in without escaping, take it as is
+		return mediaType, []byte(payload), nil
+	}
+	return mediaType, []byte(data), nil
+}
+
+// ExpandID fills in the ERC-1155 {id} placeholder, the token ID as 64 lowercase hex digits
+func ExpandID(uri, tokenID string) string {
+	if !strings.Contains(uri, "{id}") {
+		return uri
+	}
+	id, ok := new(big.Int).SetString(strings.TrimSpace(tokenID), 10)
+	if !ok {
+		return uri
+	}
+	return strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", id))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
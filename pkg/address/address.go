@@ -0,0 +1,187 @@
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Length of an address in bytes
+const Length = 20
+
+// RoninPrefix is how the Ronin wallet shows addresses, in place of 0x
+const RoninPrefix = "ronin:"
+
+// ErrInvalid is wrapped by every parse error, check with errors.Is
+var ErrInvalid = errors.New("invalid address")
+
+// Address is a 20 byte Ronin/EVM account or contract address
+type Address [Length]byte
+
+// Format is how an address is displayed
+type Format string
+
+const (
+	FormatHex   Format = "0x"    // EIP-55 checksummed, 0xAbC...
+	FormatRonin Format = "ronin" // ronin:abc..., lowercase like the Ronin wallet
+)
+
+// ParseFormat turns "0x", "hex" or "ronin" into a Format
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0x", "hex":
+		return FormatHex, nil
+	case "ronin", "ronin:":
+		return FormatRonin, nil
+	default:
+		return "", fmt.Errorf("unknown address format %q, use 0x or ronin", s)
+	}
+}
+
+// Parse
+// Explanation -> accepts ronin:abc..., 0xabc... or bare hex, surrounding whitespace is ignored
+// mixed case input must carry a valid EIP-55 checksum, all lower or all upper case is accepted as is
+// Return -> the address, or an error wrapping ErrInvalid
+func Parse(s string) (Address, error) {
+	var a Address
+
+	raw := strings.TrimSpace(s)
+	body := raw
+	switch {
+	case len(body) >= len(RoninPrefix) && strings.EqualFold(body[:len(RoninPrefix)], RoninPrefix):
+		body = body[len(RoninPrefix):]
+	case strings.HasPrefix(body, "0x"), strings.HasPrefix(body, "0X"):
+		body = body[2:]
+	}
+
+	if len(body) != 2*Length {
+		return a, fmt.Errorf("%w %q: expected %d hex characters, got %d", ErrInvalid, raw, 2*Length, len(body))
+	}
+
+	decoded, err := hex.DecodeString(body)
+	if err != nil {
+		return a, fmt.Errorf("%w %q: not hex", ErrInvalid, raw)
+	}
+	copy(a[:], decoded)
+
+	if isMixedCase(body) && body != a.checksumBody() {
+		return a, fmt.Errorf("%w %q: bad checksum", ErrInvalid, raw)
+	}
+
+	return a, nil
+}
+
+// MustParse is Parse for constants, it panics on bad input
+func MustParse(s string) Address {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Valid reports whether s parses as an address
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Equal compares two address strings regardless of prefix or case
+// Return -> false if either doesn't parse
+func Equal(a, b string) bool {
+	addrA, errA := Parse(a)
+	addrB, errB := Parse(b)
+	return errA == nil && errB == nil && addrA == addrB
+}
+
+// Normalize parses s and renders it in the given format
+// Return -> the formatted address, or an error wrapping ErrInvalid
+func Normalize(s string, f Format) (string, error) {
+	a, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return a.Format(f), nil
+}
+
+// Hex returns the EIP-55 checksummed 0x form
+func (a Address) Hex() string {
+	return "0x" + a.checksumBody()
+}
+
+// Lower returns the all lowercase 0x form, handy as a map key
+func (a Address) Lower() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// Ronin returns the ronin: form
+func (a Address) Ronin() string {
+	return RoninPrefix + hex.EncodeToString(a[:])
+}
+
+// Format renders the address in the given display format
+func (a Address) Format(f Format) string {
+	if f == FormatRonin {
+		return a.Ronin()
+	}
+	return a.Hex()
+}
+
+// String returns the checksummed 0x form
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// IsZero reports whether the address is 0x000...0
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// MarshalText encodes the address in checksummed 0x form
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText accepts anything Parse does
+func (a *Address) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// checksumBody applies EIP-55: a hex letter is uppercased when the matching
+// nibble of keccak256(lowercase hex) is 8 or more
+func (a Address) checksumBody() string {
+	lower := hex.EncodeToString(a[:])
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hash.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' || c > 'f' {
+			continue
+		}
+
+		nibble := digest[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// isMixedCase reports whether s has both upper and lower case hex letters
+func isMixedCase(s string) bool {
+	return strings.ToLower(s) != s && strings.ToUpper(s) != s
+}
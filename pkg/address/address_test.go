@@ -0,0 +1,125 @@
+package address
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// EIP-55's own test vectors, the last four checksum to all caps and all lower case
+var eip55 = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+}
+
+func TestChecksumVectors(t *testing.T) {
+	for _, want := range eip55 {
+		a, err := Parse(strings.ToLower(want))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", want, err)
+		}
+		if got := a.Hex(); got != want {
+			t.Errorf("Hex = %s, want %s", got, want)
+		}
+		if _, err := Parse(want); err != nil {
+			t.Errorf("Parse(%s) rejected its own checksum: %v", want, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	const lower = "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	want := MustParse("0x" + lower)
+
+	tests := []struct {
+		name string
+		in   string
+		ok   bool
+	}{
+		{"checksummed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"lower case", "0x" + lower, true},
+		{"upper case", "0x" + strings.ToUpper(lower), true},
+		{"upper case prefix", "0X" + lower, true},
+		{"bare hex", lower, true},
+		{"ronin", "ronin:" + lower, true},
+		{"ronin upper case prefix", "RONIN:" + lower, true},
+		{"ronin checksummed", "ronin:5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"surrounding space", "  ronin:" + lower + "\n", true},
+		{"bad checksum", "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false},
+		{"ronin bad checksum", "ronin:5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false},
+		{"too short", "0x" + lower[:38], false},
+		{"too long", "0x" + lower + "00", false},
+		{"not hex", "0x" + lower[:38] + "zz", false},
+		{"ronin and 0x", "ronin:0x" + lower[:38], false},
+		{"empty", "", false},
+		{"prefix only", "ronin:", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("Parse(%q) = %s, %v, want an ErrInvalid", tt.in, got, err)
+				}
+				return
+			}
+			if err != nil || got != want {
+				t.Errorf("Parse(%q) = %s, %v, want %s", tt.in, got, err, want)
+			}
+		})
+	}
+}
+
+func TestFormats(t *testing.T) {
+	a := MustParse("ronin:fb6916095ca1df60bb79ce92ce3ea74c37c5d359")
+	if got := a.Ronin(); got != "ronin:fb6916095ca1df60bb79ce92ce3ea74c37c5d359" {
+		t.Errorf("Ronin = %s", got)
+	}
+	if got := a.Lower(); got != "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359" {
+		t.Errorf("Lower = %s", got)
+	}
+	if got, err := Normalize("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", FormatHex); err != nil || got != "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359" {
+		t.Errorf("Normalize hex = %s, %v", got, err)
+	}
+	if !Equal("ronin:fb6916095ca1df60bb79ce92ce3ea74c37c5d359", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359") {
+		t.Error("Equal across formats = false")
+	}
+	if Equal("not an address", "not an address") {
+		t.Error("Equal of two unparseable strings = true")
+	}
+
+	for in, want := range map[string]Format{"": FormatHex, "0x": FormatHex, "HEX": FormatHex, "ronin": FormatRonin, "ronin:": FormatRonin} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("base58"); err == nil {
+		t.Error("ParseFormat(base58) accepted")
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	var v struct {
+		Owner Address `json:"owner"`
+	}
+	if err := json.Unmarshal([]byte(`{"owner":"ronin:5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got := string(out); got != `{"owner":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}` {
+		t.Errorf("Marshal = %s", got)
+	}
+	if err := json.Unmarshal([]byte(`{"owner":"0x1234"}`), &v); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Unmarshal of a short address = %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,151 @@
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MaxPlaces is how many decimal places String keeps, enough for wei
+const MaxPlaces = 18
+
+// ErrInvalid is wrapped by every parse error
+var ErrInvalid = errors.New("invalid decimal")
+
+// Decimal is an exact decimal number for prices and token amounts, never a float
+// the zero value is 0, values are immutable, every operation returns a new one
+type Decimal struct {
+	r *big.Rat
+}
+
+// Zero is 0
+var Zero = Decimal{}
+
+// Parse
+// Explanation -> reads a plain decimal string, e.g. "0.0215", "-3", "1e-4"
+// fractions like 1/3 are rejected, the API never sends them and they'd hide typos
+// Return -> the value, or an error wrapping ErrInvalid
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "/") {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	return Decimal{r: r}, nil
+}
+
+// MustParse is Parse for constants, panics on bad input
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// FromInt returns n as a decimal
+func FromInt(n int64) Decimal {
+	return Decimal{r: new(big.Rat).SetInt64(n)}
+}
+
+// FromUnits
+// Explanation -> converts an integer amount in base units to whole tokens, e.g. wei to RON
+// with decimals 18, units is a base 10 string as the API and chain return it
+// Return -> the value, or an error wrapping ErrInvalid
+func FromUnits(units string, decimals int) (Decimal, error) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(units), 10)
+	if !ok || decimals < 0 {
+		return Zero, fmt.Errorf("%w: %q with %d decimals", ErrInvalid, units, decimals)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return Decimal{r: new(big.Rat).SetFrac(n, scale)}, nil
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+// Add returns d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Add(d.rat(), o.rat())}
+}
+
+// Sub returns d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Sub(d.rat(), o.rat())}
+}
+
+// Mul returns d * o
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Mul(d.rat(), o.rat())}
+}
+
+// Div returns d / o, exact until formatted
+// Return -> false if o is zero
+func (d Decimal) Div(o Decimal) (Decimal, bool) {
+	if o.IsZero() {
+		return Zero, false
+	}
+	return Decimal{r: new(big.Rat).Quo(d.rat(), o.rat())}, true
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{r: new(big.Rat).Neg(d.rat())}
+}
+
+// Cmp compares d and o, -1, 0 or +1
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// String formats d as a plain decimal with up to MaxPlaces places, trailing zeros dropped
+func (d Decimal) String() string {
+	r := d.rat()
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := strings.TrimRight(r.FloatString(MaxPlaces), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// StringFixed formats d rounded half away from zero to exactly places decimal places
+func (d Decimal) StringFixed(places int) string {
+	return d.rat().FloatString(places)
+}
+
+// MarshalText writes d as its String, JSON gets a quoted decimal so no precision is lost
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText reads a decimal as Parse does
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
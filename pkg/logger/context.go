@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"cmd/pkg/requestid"
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextHandler attaches the request ID and trace IDs carried in the context to every record.
+// They belong at the top level, so once a group is open they can't go on the record, which
+// would put them in the group: base is kept from before any WithAttrs or WithGroup, the IDs
+// are added to it and the calls are replayed on top
+type contextHandler struct {
+	slog.Handler              // base with every call applied
+	base         slog.Handler // the handler before any call
+	calls        []handlerCall
+	grouped      bool // a group is open
+}
+
+// handlerCall is one WithAttrs or WithGroup, group is "" for WithAttrs
+type handlerCall struct {
+	group string
+	attrs []slog.Attr
+}
+
+func newContextHandler(h slog.Handler) contextHandler {
+	return contextHandler{Handler: h, base: h}
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	var ids []slog.Attr
+	if id := requestid.FromContext(ctx); id != "" {
+		ids = append(ids, slog.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		ids = append(ids,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	if len(ids) == 0 || !h.grouped {
+		r.AddAttrs(ids...)
+		return h.Handler.Handle(ctx, r)
+	}
+
+	handler := h.base.WithAttrs(ids)
+	for _, call := range h.calls {
+		if call.group != "" {
+			handler = handler.WithGroup(call.group)
+		} else {
+			handler = handler.WithAttrs(call.attrs)
+		}
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(h.Handler.WithAttrs(attrs), handlerCall{attrs: attrs})
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := h.with(h.Handler.WithGroup(name), handlerCall{group: name})
+	next.grouped = true
+	return next
+}
+
+// with returns a copy of h on handler, with call recorded for replaying
+func (h contextHandler) with(handler slog.Handler, call handlerCall) contextHandler {
+	calls := make([]handlerCall, len(h.calls), len(h.calls)+1)
+	copy(calls, h.calls)
+	return contextHandler{
+		Handler: handler,
+		base:    h.base,
+		calls:   append(calls, call),
+		grouped: h.grouped,
+	}
+}
+
+// InfoContext logs info level messages, tagged with the request ID in ctx
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+// ErrorContext logs error level messages, tagged with the request ID in ctx
+func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelError, msg, args...)
+}
+
+// WarnContext logs warning level messages, tagged with the request ID in ctx
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+// DebugContext logs debug level messages, tagged with the request ID in ctx
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelDebug, msg, args...)
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"cmd/pkg/requestid"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextIDsStayTopLevel(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	tests := []struct {
+		name string
+		log  func(l *Logger)
+		want string // the record's attrs, without time, level, source and msg
+	}{
+		{
+			"no group",
+			func(l *Logger) { l.With("service", "api").InfoContext(ctx, "hi", "n", 1) },
+			`{"service":"api","n":1,"request_id":"req-1","trace_id":"01000000000000000000000000000000","span_id":"0200000000000000"}`,
+		},
+		{
+			"group",
+			func(l *Logger) { l.WithGroup("rarity").InfoContext(ctx, "hi", "n", 1) },
+			`{"request_id":"req-1","trace_id":"01000000000000000000000000000000","span_id":"0200000000000000","rarity":{"n":1}}`,
+		},
+		{
+			"attrs around groups",
+			func(l *Logger) {
+				l.With("service", "api").WithGroup("a").With("x", 1).WithGroup("b").InfoContext(ctx, "hi", "n", 1)
+			},
+			`{"request_id":"req-1","trace_id":"01000000000000000000000000000000","span_id":"0200000000000000","service":"api","a":{"x":1,"b":{"n":1}}}`,
+		},
+		{
+			"group without ids",
+			func(l *Logger) { l.WithGroup("rarity").InfoContext(context.Background(), "hi", "n", 1) },
+			`{"rarity":{"n":1}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newTestLogger(t, FormatJSON)
+			tt.log(log)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("parsing %s: %v", buf, err)
+			}
+			for _, key := range []string{"time", "level", "source", "msg"} {
+				delete(record, key)
+			}
+			got, _ := json.Marshal(record)
+			var want map[string]any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			wantJSON, _ := json.Marshal(want)
+			if string(got) != string(wantJSON) {
+				t.Errorf("record = %s\nwant     %s", got, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted is what a masked value is replaced with
+const Redacted = "[REDACTED]"
+
+// Secrets shorter than this aren't matched by value, they'd mask half the log
+const minSecretLen = 6
+
+// defaultSecretKeys are attribute keys whose values are always masked
+// keys are compared lowercased with - turned into _, so X-API-Key matches x_api_key
+var defaultSecretKeys = []string{
+	"authorization",
+	"proxy_authorization",
+	"x_api_key",
+	"api_key",
+	"apikey",
+	"moralis_api_key",
+	"discord_bot_token",
+	"discord_token",
+	"bot_token",
+	"access_token",
+	"refresh_token",
+	"jwt_secret",
+	"client_secret",
+	"password",
+	"cookie",
+	"set_cookie",
+}
+
+// Redactor masks secrets in log records
+// Use ReplaceAttr as the slog.HandlerOptions.ReplaceAttr func
+type Redactor struct {
+	mu      sync.RWMutex
+	keys    map[string]struct{}
+	secrets []string
+}
+
+// NewRedactor
+// Explanation -> creates a redactor for the default secret keys plus the given secret values
+// empty and very short values are ignored, so unset config can be passed as is
+// Return -> redactor
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{
+		keys: make(map[string]struct{}, len(defaultSecretKeys)),
+	}
+	r.AddKeys(defaultSecretKeys...)
+	r.AddSecrets(secrets...)
+	return r
+}
+
+// AddKeys marks more attribute keys as secret
+func (r *Redactor) AddKeys(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		r.keys[normalizeKey(key)] = struct{}{}
+	}
+}
+
+// AddSecrets registers secret values, masked wherever they show up in a record
+func (r *Redactor) AddSecrets(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minSecretLen {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+	}
+}
+
+// ReplaceAttr
+// Explanation -> masks the attribute if its key is secret, otherwise masks any secret values inside it
+// Return -> the attribute, redacted where needed
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.isSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.RedactString(a.Value.String()))
+	case slog.KindAny:
+		if v, ok := r.redactAny(a.Value.Any()); ok {
+			return slog.Any(a.Key, v)
+		}
+	}
+
+	return a
+}
+
+// RedactString replaces every known secret value in s
+func (r *Redactor) RedactString(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// RedactHeader returns a copy of h with secret headers masked
+func (r *Redactor) RedactHeader(h http.Header) http.Header {
+	clean := make(http.Header, len(h))
+	for key, values := range h {
+		if r.isSecretKey(key) {
+			clean[key] = []string{Redacted}
+			continue
+		}
+
+		cleanValues := make([]string, len(values))
+		for i, v := range values {
+			cleanValues[i] = r.RedactString(v)
+		}
+		clean[key] = cleanValues
+	}
+	return clean
+}
+
+// RedactURL returns u as a string with secret query params and values masked
+func (r *Redactor) RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	clean := *u
+	if clean.User != nil {
+		if _, ok := clean.User.Password(); ok {
+			clean.User = url.UserPassword(clean.User.Username(), Redacted)
+		}
+	}
+
+	query := clean.Query()
+	for key := range query {
+		if r.isSecretKey(key) {
+			query.Set(key, Redacted)
+		}
+	}
+	clean.RawQuery = query.Encode()
+
+	return r.RedactString(clean.String())
+}
+
+// redactAny handles the non-string values we know how to look inside
+// anything else is formatted and only replaced if a secret shows up in it
+// Return -> the replacement value, and whether a replacement is needed
+func (r *Redactor) redactAny(v any) (any, bool) {
+	switch val := v.(type) {
+	case http.Header:
+		return r.RedactHeader(val), true
+	case *url.URL:
+		return r.RedactURL(val), true
+	case error:
+		msg := val.Error()
+		if clean := r.RedactString(msg); clean != msg {
+			return errors.New(clean), true
+		}
+		return nil, false
+	}
+
+	if !r.hasSecrets() {
+		return nil, false
+	}
+
+	formatted := fmt.Sprintf("%+v", v)
+	if clean := r.RedactString(formatted); clean != formatted {
+		return clean, true
+	}
+	return nil, false
+}
+
+func (r *Redactor) isSecretKey(key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.keys[normalizeKey(key)]
+	return ok
+}
+
+func (r *Redactor) hasSecrets() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.secrets) > 0
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
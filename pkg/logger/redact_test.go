@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const (
+	testAPIKey    = "moralis-key-0123456789abcdef"
+	testBotToken  = "discord.bot.token.ZYXWVU"
+	testJWTSecret = "jwt-super-secret-value"
+)
+
+var testSecrets = []string{testAPIKey, testBotToken, testJWTSecret}
+
+// newTestLogger returns a logger writing to a buffer, redacting the test secrets
+func newTestLogger(t *testing.T, format string) (*Logger, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log, err := newWithWriter(&buf, Options{
+		Level:     slog.LevelDebug,
+		Format:    format,
+		AddSource: true,
+		Redactor:  NewRedactor(testSecrets...),
+	})
+	if err != nil {
+		t.Fatalf("creating logger: %v", err)
+	}
+	return log, &buf
+}
+
+type testConfig struct {
+	MoralisAPIKey string
+	Port          string
+}
+
+func TestRedactionNeverLeaksSecrets(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-API-Key", testAPIKey)
+	header.Set("Authorization", "Bearer "+testJWTSecret)
+	header.Set("Accept", "application/json")
+
+	reqURL, _ := url.Parse("https://deep-index.moralis.io/api/v2.2/0xabc/nft?chain=ronin&api_key=" + testAPIKey)
+
+	tests := []struct {
+		name string
+		log  func(l *Logger)
+	}{
+		{
+			name: "secret keys",
+			log: func(l *Logger) {
+				l.Info("config", "moralis_api_key", testAPIKey, "DISCORD_BOT_TOKEN", testBotToken, "jwt_secret", testJWTSecret)
+			},
+		},
+		{
+			name: "secret values under innocent keys",
+			log: func(l *Logger) {
+				l.Info("loaded", "value", testAPIKey, "note", "token is "+testBotToken)
+			},
+		},
+		{
+			name: "secret in message",
+			log: func(l *Logger) {
+				l.Error("bad key " + testAPIKey)
+			},
+		},
+		{
+			name: "request headers",
+			log: func(l *Logger) {
+				l.Debug("outbound request", "headers", header)
+			},
+		},
+		{
+			name: "header attrs",
+			log: func(l *Logger) {
+				l.Debug("outbound request", "X-API-Key", testAPIKey, "Authorization", "Bearer "+testJWTSecret)
+			},
+		},
+		{
+			name: "request url",
+			log: func(l *Logger) {
+				l.Info("request", "url", reqURL, "raw_url", reqURL.String())
+			},
+		},
+		{
+			name: "errors",
+			log: func(l *Logger) {
+				l.Error("request failed", "error", fmt.Errorf("calling %s: unauthorized", testAPIKey))
+			},
+		},
+		{
+			name: "structs",
+			log: func(l *Logger) {
+				l.Info("config", "config", testConfig{MoralisAPIKey: testAPIKey, Port: "8080"})
+			},
+		},
+		{
+			name: "groups and With",
+			log: func(l *Logger) {
+				l.WithGroup("moralis_client").With("api_key", testAPIKey).Info("client", "token", testBotToken)
+			},
+		},
+	}
+
+	for _, format := range []string{FormatJSON, FormatText} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				log, buf := newTestLogger(t, format)
+				tt.log(log)
+
+				out := buf.String()
+				if out == "" {
+					t.Fatal("nothing was logged")
+				}
+				for _, secret := range testSecrets {
+					if strings.Contains(out, secret) {
+						t.Errorf("secret %q leaked into output: %s", secret, out)
+					}
+				}
+				if !strings.Contains(out, "REDACTED") {
+					t.Errorf("expected a redaction marker in output: %s", out)
+				}
+			})
+		}
+	}
+}
+
+func TestRedactionKeepsOtherData(t *testing.T) {
+	log, buf := newTestLogger(t, FormatJSON)
+
+	header := http.Header{}
+	header.Set("X-API-Key", testAPIKey)
+	header.Set("Accept", "application/json")
+
+	log.Info("request", "wallet_address", "0xabc", "limit", 10, "headers", header)
+
+	out := buf.String()
+	for _, want := range []string{`"wallet_address":"0xabc"`, `"limit":10`, "application/json"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output: %s", want, out)
+		}
+	}
+}
+
+func TestRedactorIgnoresShortSecrets(t *testing.T) {
+	r := NewRedactor("", "ab")
+
+	if got := r.RedactString("abc def"); got != "abc def" {
+		t.Errorf("short secrets should be ignored, got %q", got)
+	}
+}
+
+func TestDefaultLoggerRedactsSecretKeys(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := newWithWriter(&buf, DefaultOptions())
+	if err != nil {
+		t.Fatalf("creating logger: %v", err)
+	}
+
+	log.Info("config", "MORALIS_API_KEY", testAPIKey)
+
+	if strings.Contains(buf.String(), testAPIKey) {
+		t.Errorf("default logger leaked secret key: %s", buf.String())
+	}
+}
@@ -1,67 +1,188 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Supported handler formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Supported destinations, anything else is treated as a file path
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
 )
 
 type Logger struct {
 	logger *slog.Logger
+	closer io.Closer
 }
 
-func New() *Logger {
-	// Create structured logger with JSON output
-	opts := &slog.HandlerOptions{
+// Options controls the handler a Logger is built with
+type Options struct {
+	Level     slog.Level
+	Format    string // json or text
+	Output    string // stdout, stderr or a file path
+	AddSource bool   // adds file and line number
+
+	// Masks secrets before they're written, nil disables redaction
+	Redactor *Redactor
+
+	// Only used when Output is a file
+	MaxSizeMB  int // rotate once the file reaches this size, 0 disables rotation
+	MaxBackups int // rotated files to keep around
+}
+
+// DefaultOptions returns the options used by New
+// Logs go to stderr so they don't end up mixed with command output
+func DefaultOptions() Options {
+	return Options{
 		Level:     slog.LevelInfo,
-		AddSource: true, // Adds file and line number
+		Format:    FormatJSON,
+		Output:    OutputStderr,
+		AddSource: true,
+		Redactor:  NewRedactor(),
 	}
+}
 
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	logger := slog.New(handler)
+func New() *Logger {
+	return NewWithLevel(slog.LevelInfo)
+}
 
-	return &Logger{
-		logger: logger,
+func NewWithLevel(level slog.Level) *Logger {
+	opts := DefaultOptions()
+	opts.Level = level
+
+	// stderr can't fail to open, so the error is safe to ignore here
+	logger, _ := NewWithOptions(opts)
+	return logger
+}
+
+// NewWithOptions
+// Explanation -> builds a logger from the given options, opening the log file if one is set
+// Return -> the logger, or an error if the format is unknown or the file can't be opened
+func NewWithOptions(opts Options) (*Logger, error) {
+	var (
+		w      io.Writer
+		closer io.Closer
+	)
+
+	switch strings.ToLower(opts.Output) {
+	case "", OutputStderr:
+		w = os.Stderr
+	case OutputStdout:
+		w = os.Stdout
+	default:
+		file, err := newRotatingFile(opts.Output, opts.MaxSizeMB, opts.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		w, closer = file, file
+	}
+
+	logger, err := newWithWriter(w, opts)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
 	}
+	logger.closer = closer
+
+	return logger, nil
 }
 
-func NewWithLevel(level slog.Level) *Logger {
-	opts := &slog.HandlerOptions{
-		Level:     level,
-		AddSource: true,
+// newWithWriter builds the handler on top of an already opened writer
+func newWithWriter(w io.Writer, opts Options) (*Logger, error) {
+	handlerOpts := &slog.HandlerOptions{
+		Level:     opts.Level,
+		AddSource: opts.AddSource,
+	}
+	if opts.Redactor != nil {
+		handlerOpts.ReplaceAttr = opts.Redactor.ReplaceAttr
 	}
 
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	logger := slog.New(handler)
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
 
 	return &Logger{
-		logger: logger,
+		logger: slog.New(newContextHandler(handler)),
+	}, nil
+}
+
+// ParseLevel turns debug/info/warn/error into a slog.Level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return slog.LevelInfo
 	}
+	return l
+}
+
+// Close flushes and closes the log file, if there is one
+func (l *Logger) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
 }
 
 // Info logs info level messages
 func (l *Logger) Info(msg string, args ...any) {
-	l.logger.Info(msg, args...)
+	l.log(context.Background(), slog.LevelInfo, msg, args...)
 }
 
 // Error logs error level messages
 func (l *Logger) Error(msg string, args ...any) {
-	l.logger.Error(msg, args...)
+	l.log(context.Background(), slog.LevelError, msg, args...)
 }
 
 // Warn logs warning level messages
 func (l *Logger) Warn(msg string, args ...any) {
-	l.logger.Warn(msg, args...)
+	l.log(context.Background(), slog.LevelWarn, msg, args...)
 }
 
 // Debug logs debug level messages
 func (l *Logger) Debug(msg string, args ...any) {
-	l.logger.Debug(msg, args...)
+	l.log(context.Background(), slog.LevelDebug, msg, args...)
+}
+
+// log builds the record itself so the source points at our caller, not this file
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip runtime.Callers, log and the exported method
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+
+	_ = l.logger.Handler().Handle(ctx, r)
 }
 
 // With creates a new logger with additional context
 func (l *Logger) With(args ...any) *Logger {
 	return &Logger{
 		logger: l.logger.With(args...),
+		closer: l.closer,
 	}
 }
 
@@ -69,5 +190,6 @@ func (l *Logger) With(args ...any) *Logger {
 func (l *Logger) WithGroup(name string) *Logger {
 	return &Logger{
 		logger: l.logger.WithGroup(name),
+		closer: l.closer,
 	}
 }
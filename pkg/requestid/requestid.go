@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header the request ID travels in, both inbound and outbound
+const Header = "X-Request-ID"
+
+// Longest inbound ID we accept, anything bigger gets replaced
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New generates a random 16 byte request ID, hex encoded
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewContext returns a copy of ctx carrying the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID in ctx, or an empty string if there isn't one
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Valid
+// Explanation -> checks an ID received from a caller is safe to log and echo back
+// only letters, digits, - _ . and : are allowed
+// Return -> true if the ID can be used as is
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}